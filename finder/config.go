@@ -14,10 +14,204 @@
 
 package finder
 
+import (
+	"fmt"
+	"net"
+
+	"github.com/cybergarage/go-finder/finder/node"
+)
+
+const (
+	nodeConfigPortMax = 65535
+)
+
+const (
+	errorNodeConfigNoNameAndAddress = "%s or %s is required"
+	errorNodeConfigInvalidAddress   = "invalid %s %q"
+	errorNodeConfigInvalidPort      = "invalid %s %d"
+	errorNodeConfigDuplicated       = "duplicated node of nodes[%d]"
+	errorNodeConfigConflictedPort   = "%s %d conflicts with %s port %d"
+	errorConfigUnknownKey           = "unknown key %q"
+)
+
+// FinderConfig represents a configuration of static finders.
 type FinderConfig struct {
-	Hosts []string
+	// Type is the registered name of the finder which is created by NewFinderWithConfig.
+	Type string `toml:"type" json:"type,omitempty" yaml:"type,omitempty"`
+	// File is the configuration file of the static file finders.
+	File string `toml:"file" json:"file,omitempty" yaml:"file,omitempty"`
+	// Service is the SRV name of the DNS finder such as "_finder._tcp.example.com".
+	Service string `toml:"service" json:"service,omitempty" yaml:"service,omitempty"`
+	// Server is the DNS server address of the DNS finder such as "192.168.100.53:53", and the system resolver is used when it is empty.
+	Server string `toml:"server" json:"server,omitempty" yaml:"server,omitempty"`
+	// Seeds is a list of the gossip addresses such as "192.168.100.1:7946" which the gossip finder joins to, and the default port is used when the port is omitted.
+	Seeds []string `toml:"seeds" json:"seeds,omitempty" yaml:"seeds,omitempty"`
+	// URL is the registry URL of the HTTP finder such as "http://registry.example.com:8080".
+	URL string `toml:"url" json:"url,omitempty" yaml:"url,omitempty"`
+	// Hosts is a list of host names which have only the host.
+	Hosts []string `toml:"hosts" json:"hosts,omitempty" yaml:"hosts,omitempty"`
+	// Nodes is a list of full node descriptors.
+	Nodes []NodeConfig `toml:"nodes" json:"nodes,omitempty" yaml:"nodes,omitempty"`
 }
 
+// NodeConfig represents a full node descriptor of static finders.
+type NodeConfig struct {
+	Cluster    string      `toml:"cluster" json:"cluster,omitempty" yaml:"cluster,omitempty"`
+	Name       string      `toml:"name" json:"name,omitempty" yaml:"name,omitempty"`
+	Address    string      `toml:"address" json:"address,omitempty" yaml:"address,omitempty"`
+	RPCPort    uint        `toml:"rpc_port" json:"rpc_port,omitempty" yaml:"rpc_port,omitempty"`
+	CarbonPort uint        `toml:"carbon_port" json:"carbon_port,omitempty" yaml:"carbon_port,omitempty"`
+	RenderPort uint        `toml:"render_port" json:"render_port,omitempty" yaml:"render_port,omitempty"`
+	Ports      node.Ports  `toml:"ports" json:"ports,omitempty" yaml:"ports,omitempty"`
+	Labels     node.Labels `toml:"labels" json:"labels,omitempty" yaml:"labels,omitempty"`
+}
+
+// Config represents a configuration file of static finders, and the schema is shared by all file formats.
 type Config struct {
-	Finder FinderConfig
+	Finder FinderConfig `toml:"finder" json:"finder" yaml:"finder"`
+}
+
+// NodeConfigError represents a validation error of a node descriptor.
+type NodeConfigError struct {
+	// Filename is the configuration file name, and empty when the configuration is not loaded from a file.
+	Filename string
+	// Line is the line number of the node descriptor, and zero when the line is unknown.
+	Line int
+	// Index is the index of the node descriptor in the nodes.
+	Index int
+	// Message is the validation error message.
+	Message string
+}
+
+// Error returns the error message with the file name and line number.
+func (e *NodeConfigError) Error() string {
+	if e.Filename == "" {
+		return fmt.Sprintf("finder.nodes[%d]: %s", e.Index, e.Message)
+	}
+	if e.Line <= 0 {
+		return fmt.Sprintf("%s: finder.nodes[%d]: %s", e.Filename, e.Index, e.Message)
+	}
+	return fmt.Sprintf("%s:%d: finder.nodes[%d]: %s", e.Filename, e.Line, e.Index, e.Message)
+}
+
+// ConfigKeyError represents an unknown key of a configuration file.
+type ConfigKeyError struct {
+	// Filename is the configuration file name.
+	Filename string
+	// Line is the line number of the key, and zero when the line is unknown.
+	Line int
+	// Key is the dotted path of the unknown key such as "finder.nodes.rpc_prot".
+	Key string
+}
+
+// Error returns the error message with the file name and line number.
+func (e *ConfigKeyError) Error() string {
+	if e.Line <= 0 {
+		return fmt.Sprintf("%s: "+errorConfigUnknownKey, e.Filename, e.Key)
+	}
+	return fmt.Sprintf("%s:%d: "+errorConfigUnknownKey, e.Filename, e.Line, e.Key)
+}
+
+// Validate returns a NodeConfigError when a node descriptor is invalid.
+func (conf *FinderConfig) Validate() error {
+	for n := range conf.Nodes {
+		nodeConf := &conf.Nodes[n]
+		if msg := nodeConf.validate(); msg != "" {
+			return &NodeConfigError{Index: n, Message: msg}
+		}
+		for i := 0; i < n; i++ {
+			if conf.Nodes[i].isSameNode(nodeConf) {
+				return &NodeConfigError{Index: n, Message: fmt.Sprintf(errorNodeConfigDuplicated, i)}
+			}
+		}
+	}
+	return nil
+}
+
+// validate returns the error message when the node descriptor is invalid, otherwise an empty string.
+func (conf *NodeConfig) validate() string {
+	if conf.Name == "" && conf.Address == "" {
+		return fmt.Sprintf(errorNodeConfigNoNameAndAddress, FinderNodeName, FinderNodeAddress)
+	}
+
+	if conf.Address != "" && net.ParseIP(conf.Address) == nil {
+		return fmt.Sprintf(errorNodeConfigInvalidAddress, FinderNodeAddress, conf.Address)
+	}
+
+	ports := []struct {
+		name string
+		port uint
+	}{
+		{FinderNodeRpcPort, conf.RPCPort},
+		{FinderNodeCarbonPort, conf.CarbonPort},
+		{FinderNodeRenderPort, conf.RenderPort},
+	}
+	for _, port := range ports {
+		if nodeConfigPortMax < port.port {
+			return fmt.Sprintf(errorNodeConfigInvalidPort, port.name, port.port)
+		}
+	}
+
+	for _, name := range conf.Ports.Names() {
+		if err := node.ValidatePort(name, conf.Ports[name]); err != nil {
+			return err.Error()
+		}
+	}
+
+	namedPorts := []struct {
+		key  string
+		name string
+		port uint
+	}{
+		{FinderNodeCarbonPort, node.PortCarbon, conf.CarbonPort},
+		{FinderNodeRenderPort, node.PortRender, conf.RenderPort},
+	}
+	for _, namedPort := range namedPorts {
+		port, ok := conf.Ports.Get(namedPort.name)
+		if namedPort.port != 0 && ok && port != namedPort.port {
+			return fmt.Sprintf(errorNodeConfigConflictedPort, namedPort.key, namedPort.port, namedPort.name, port)
+		}
+	}
+
+	for _, key := range conf.Labels.Keys() {
+		if err := node.ValidateLabel(key, conf.Labels[key]); err != nil {
+			return err.Error()
+		}
+	}
+
+	return ""
+}
+
+// isSameNode returns true when the specified descriptor has the same node, otherwise false.
+func (conf *NodeConfig) isSameNode(other *NodeConfig) bool {
+	return conf.Cluster == other.Cluster &&
+		conf.Name == other.Name &&
+		conf.Address == other.Address &&
+		conf.RPCPort == other.RPCPort
+}
+
+// newNode returns a new node of the descriptor.
+func (conf *NodeConfig) newNode() *node.BaseNode {
+	node := node.NewBaseNode()
+	node.SetCluster(conf.Cluster)
+	node.SetHost(conf.Name)
+	if conf.Address != "" {
+		node.SetAddress(net.ParseIP(conf.Address))
+	}
+	node.SetRPCPort(conf.RPCPort)
+	node.SetPorts(conf.namedPorts())
+	node.SetLabels(conf.Labels)
+	return node
+}
+
+// namedPorts returns the named service ports of the descriptor including the carbon and render ports.
+func (conf *NodeConfig) namedPorts() node.Ports {
+	ports := conf.Ports.Copy()
+	if conf.CarbonPort != 0 {
+		ports[node.PortCarbon] = conf.CarbonPort
+	}
+	if conf.RenderPort != 0 {
+		ports[node.PortRender] = conf.RenderPort
+	}
+	return ports
 }
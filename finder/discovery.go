@@ -0,0 +1,221 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cybergarage/go-logger/log"
+)
+
+const (
+	// DefaultDiscoveryMaxInterval is the default max interval of the background discovery backoff.
+	DefaultDiscoveryMaxInterval = 5 * time.Minute
+	// DefaultDiscoveryJitter is the default jitter ratio of the background discovery interval.
+	DefaultDiscoveryJitter = 0.2
+)
+
+const (
+	errorDiscoveryInvalidInterval = "Invalid discovery interval : %s"
+	errorDiscoveryInvalidJitter   = "Invalid discovery jitter : %f"
+	msgDiscoverySearchFailed      = "Background discovery search failed : %s"
+)
+
+// DiscoveryOptions represents options for the periodic background discovery.
+type DiscoveryOptions struct {
+	// Interval is the base interval between searches, and zero disables the background discovery.
+	Interval time.Duration
+	// MaxInterval is the max interval which the interval is doubled up to while the membership is not changed.
+	MaxInterval time.Duration
+	// Jitter is the ratio of the random jitter added to each interval in [0, 1].
+	Jitter float64
+	// Search is the options for each search.
+	Search *SearchOptions
+}
+
+// NewDiscoveryOptions returns new options with the specified base interval.
+func NewDiscoveryOptions(interval time.Duration) *DiscoveryOptions {
+	return &DiscoveryOptions{
+		Interval:    interval,
+		MaxInterval: DefaultDiscoveryMaxInterval,
+		Jitter:      DefaultDiscoveryJitter,
+		Search:      NewSearchOptions(),
+	}
+}
+
+// Validate returns an error when the options are invalid.
+func (opts *DiscoveryOptions) Validate() error {
+	if opts.Interval < 0 {
+		return fmt.Errorf(errorDiscoveryInvalidInterval, opts.Interval)
+	}
+	if opts.MaxInterval < opts.Interval {
+		return fmt.Errorf(errorDiscoveryInvalidInterval, opts.MaxInterval)
+	}
+	if opts.Jitter < 0 || 1 < opts.Jitter {
+		return fmt.Errorf(errorDiscoveryInvalidJitter, opts.Jitter)
+	}
+	if opts.Search != nil {
+		return opts.Search.Validate()
+	}
+	return nil
+}
+
+// IsEnabled returns true when the background discovery is enabled, otherwise false.
+func (opts *DiscoveryOptions) IsEnabled() bool {
+	return 0 < opts.Interval
+}
+
+// jitteredInterval returns the specified interval with the random jitter.
+func (opts *DiscoveryOptions) jitteredInterval(interval time.Duration) time.Duration {
+	if opts.Jitter <= 0 {
+		return interval
+	}
+	jitter := (rand.Float64()*2 - 1) * opts.Jitter * float64(interval)
+	return interval + time.Duration(jitter)
+}
+
+// nextInterval returns the next interval which is doubled when the membership is not changed, otherwise reset to the base interval.
+func (opts *DiscoveryOptions) nextInterval(interval time.Duration, isChanged bool) time.Duration {
+	if isChanged {
+		return opts.Interval
+	}
+	interval *= 2
+	if opts.MaxInterval < interval {
+		interval = opts.MaxInterval
+	}
+	return interval
+}
+
+// discoveryLoop represents a background discovery goroutine.
+type discoveryLoop struct {
+	finder Finder
+	opts   *DiscoveryOptions
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startDiscoveryLoop starts searching the specified finder periodically with the specified options.
+func startDiscoveryLoop(finder Finder, opts *DiscoveryOptions) *discoveryLoop {
+	ctx, cancel := context.WithCancel(context.Background())
+	loop := &discoveryLoop{
+		finder: finder,
+		opts:   opts,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go loop.run(ctx)
+	return loop
+}
+
+// run searches the finder periodically until the specified context is done.
+func (loop *discoveryLoop) run(ctx context.Context) {
+	defer close(loop.done)
+
+	interval := loop.opts.Interval
+	for {
+		timer := time.NewTimer(loop.opts.jitteredInterval(interval))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		before := loop.membership()
+		_, err := loop.finder.SearchContext(ctx, loop.opts.Search)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Warnf(msgDiscoverySearchFailed, err.Error())
+		}
+		after := loop.membership()
+
+		interval = loop.opts.nextInterval(interval, before != after)
+	}
+}
+
+// membership returns the sorted node UUIDs of the finder as a string.
+func (loop *discoveryLoop) membership() string {
+	nodes, err := loop.finder.GetAllNodes()
+	if err != nil {
+		return ""
+	}
+	uuids := make([]string, len(nodes))
+	for n, node := range nodes {
+		uuids[n] = node.UUID()
+	}
+	sort.Strings(uuids)
+	return fmt.Sprintf("%v", uuids)
+}
+
+// stop stops the background discovery and waits until the goroutine is terminated.
+func (loop *discoveryLoop) stop() {
+	loop.cancel()
+	<-loop.done
+}
+
+// discoveryRunner represents an embeddable helper which runs the background discovery of a finder between Start and Stop.
+type discoveryRunner struct {
+	discoveryMutex sync.Mutex
+	discoveryOpts  *DiscoveryOptions
+	discovery      *discoveryLoop
+}
+
+// newDiscoveryRunner returns a new runner whose background discovery is disabled.
+func newDiscoveryRunner() *discoveryRunner {
+	return &discoveryRunner{
+		discoveryOpts: NewDiscoveryOptions(0),
+		discovery:     nil,
+	}
+}
+
+// SetDiscoveryOptions sets the options of the periodic background discovery which is run by Start, and nil disables the background discovery.
+func (runner *discoveryRunner) SetDiscoveryOptions(opts *DiscoveryOptions) error {
+	if opts == nil {
+		opts = NewDiscoveryOptions(0)
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	runner.discoveryMutex.Lock()
+	defer runner.discoveryMutex.Unlock()
+	runner.discoveryOpts = opts
+	return nil
+}
+
+// startDiscovery starts the background discovery of the specified finder when it is enabled and not running.
+func (runner *discoveryRunner) startDiscovery(finder Finder) {
+	runner.discoveryMutex.Lock()
+	defer runner.discoveryMutex.Unlock()
+	if runner.discovery == nil && runner.discoveryOpts.IsEnabled() {
+		runner.discovery = startDiscoveryLoop(finder, runner.discoveryOpts)
+	}
+}
+
+// stopDiscovery stops the background discovery, and waits until the goroutine is terminated.
+func (runner *discoveryRunner) stopDiscovery() {
+	runner.discoveryMutex.Lock()
+	defer runner.discoveryMutex.Unlock()
+	if runner.discovery != nil {
+		runner.discovery.stop()
+		runner.discovery = nil
+	}
+}
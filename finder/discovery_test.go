@@ -0,0 +1,178 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testDiscoveryFinder struct {
+	*StaticFinder
+	searchCount int32
+}
+
+func (finder *testDiscoveryFinder) SearchContext(ctx context.Context, opts *SearchOptions) ([]Node, error) {
+	atomic.AddInt32(&finder.searchCount, 1)
+	return finder.StaticFinder.SearchContext(ctx, opts)
+}
+
+func (finder *testDiscoveryFinder) SearchCount() int {
+	return int(atomic.LoadInt32(&finder.searchCount))
+}
+
+func TestDiscoveryOptions(t *testing.T) {
+	opts := NewDiscoveryOptions(time.Second)
+	opts.MaxInterval = 4 * time.Second
+	if err := opts.Validate(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Backoff while the membership is not changed
+
+	expectedIntervals := []time.Duration{2 * time.Second, 4 * time.Second, 4 * time.Second}
+	interval := opts.Interval
+	for _, expectedInterval := range expectedIntervals {
+		interval = opts.nextInterval(interval, false)
+		if interval != expectedInterval {
+			t.Errorf("%s != %s", interval, expectedInterval)
+		}
+	}
+
+	// Reset when the membership is changed
+
+	interval = opts.nextInterval(interval, true)
+	if interval != opts.Interval {
+		t.Errorf("%s != %s", interval, opts.Interval)
+	}
+
+	// Jitter
+
+	for n := 0; n < 100; n++ {
+		interval := opts.jitteredInterval(time.Second)
+		if interval < 800*time.Millisecond || 1200*time.Millisecond < interval {
+			t.Errorf("%s is out of the jitter range", interval)
+		}
+	}
+
+	// Invalid options
+
+	invalidOpts := []*DiscoveryOptions{
+		{Interval: -time.Second, MaxInterval: time.Second},
+		{Interval: time.Second, MaxInterval: time.Millisecond},
+		{Interval: time.Second, MaxInterval: time.Second, Jitter: 2},
+	}
+	for _, opts := range invalidOpts {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("Invalid options are accepted : %v", opts)
+		}
+	}
+}
+
+func TestDiscoveryLoop(t *testing.T) {
+	finder := &testDiscoveryFinder{
+		StaticFinder: NewStaticFinderWithNodes(setupTestHashRingNodes(2)).(*StaticFinder),
+	}
+
+	opts := NewDiscoveryOptions(5 * time.Millisecond)
+	opts.MaxInterval = 20 * time.Millisecond
+
+	loop := startDiscoveryLoop(finder, opts)
+
+	for n := 0; n < 100; n++ {
+		if 3 <= finder.SearchCount() {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	loop.stop()
+
+	searchCount := finder.SearchCount()
+	if searchCount < 3 {
+		t.Errorf("Background discovery searched only %d times", searchCount)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if finder.SearchCount() != searchCount {
+		t.Errorf("Background discovery is running after stopped")
+	}
+}
+
+func TestEchonetFinderDiscovery(t *testing.T) {
+	finder := NewEchonetFinder().(*EchonetFinder)
+
+	if err := finder.SetDiscoveryOptions(&DiscoveryOptions{Interval: -time.Second}); err == nil {
+		t.Errorf("Invalid options are accepted")
+	}
+
+	opts := NewDiscoveryOptions(10 * time.Millisecond)
+	opts.Search.Wait = 10 * time.Millisecond
+	if err := finder.SetDiscoveryOptions(opts); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := finder.Start(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if err := finder.Stop(); err != nil {
+		t.Error(err)
+	}
+
+	if finder.discovery != nil {
+		t.Errorf("Background discovery is not stopped")
+	}
+}
+
+func TestDiscoveryRunnerOptions(t *testing.T) {
+	finder := &testDiscoveryFinder{
+		StaticFinder: NewStaticFinderWithNodes(setupTestHashRingNodes(2)).(*StaticFinder),
+	}
+	runner := newDiscoveryRunner()
+
+	// The nil options disable the background discovery
+
+	if err := runner.SetDiscoveryOptions(NewDiscoveryOptions(5 * time.Millisecond)); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := runner.SetDiscoveryOptions(nil); err != nil {
+		t.Error(err)
+		return
+	}
+
+	runner.startDiscovery(finder)
+	defer runner.stopDiscovery()
+	if runner.discovery != nil {
+		t.Errorf("Background discovery is started without the interval")
+	}
+
+	// The invalid options are never applied
+
+	if err := runner.SetDiscoveryOptions(&DiscoveryOptions{Interval: -time.Second}); err == nil {
+		t.Errorf("Invalid options are accepted")
+	}
+	if runner.discoveryOpts.Interval != 0 {
+		t.Errorf("%s != %s", runner.discoveryOpts.Interval, time.Duration(0))
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	messageHeaderSize    = 12
+	messageFlagResponse  = 0x8000
+	messageFlagAuthority = 0x0400
+	messageFlagTruncated = 0x0200
+	messageFlagRecursion = 0x0100
+	messageOpcodeMask    = 0x7800
+	messageRCodeMask     = 0x000F
+	questionSize         = 4
+	// messageUDPPayloadSize is the max UDP payload size of the unicast DNS messages without EDNS0.
+	messageUDPPayloadSize = 512
+)
+
+const (
+	// MessageMaxSize is the max size of the DNS messages which is limited by the length prefix of the messages over TCP.
+	MessageMaxSize = 65535
+)
+
+const (
+	errorMessageTooShort      = "message is too short (%d)"
+	errorMessageTooLong       = "message is too long (%d)"
+	errorMessageInvalidOpcode = "invalid opcode (%d)"
+)
+
+// RCode represents a response code of the DNS messages.
+type RCode uint16
+
+const (
+	RCodeSuccess        RCode = 0
+	RCodeFormatError    RCode = 1
+	RCodeServerFailure  RCode = 2
+	RCodeNameError      RCode = 3
+	RCodeNotImplemented RCode = 4
+	RCodeRefused        RCode = 5
+)
+
+// Message represents a DNS message which is shared by the unicast and multicast DNS.
+type Message struct {
+	ID            uint16
+	Response      bool
+	Authoritative bool
+	Truncated     bool
+	// RecursionDesired is used only by the unicast DNS queries.
+	RecursionDesired bool
+	RCode            RCode
+	Questions        []*Question
+	Answers          []*Record
+	Authorities      []*Record
+	Additionals      []*Record
+}
+
+// NewQueryMessage returns a new query message of the specified questions.
+func NewQueryMessage(questions ...*Question) *Message {
+	return &Message{
+		Questions: questions,
+	}
+}
+
+// NewResponseMessage returns a new authoritative response message of the specified answers.
+func NewResponseMessage(answers ...*Record) *Message {
+	return &Message{
+		Response:      true,
+		Authoritative: true,
+		Answers:       answers,
+	}
+}
+
+// NewMessageWithBytes returns a new message of the specified bytes.
+func NewMessageWithBytes(b []byte) (*Message, error) {
+	if len(b) < messageHeaderSize {
+		return nil, fmt.Errorf(errorMessageTooShort, len(b))
+	}
+
+	flags := binary.BigEndian.Uint16(b[2:])
+	if opcode := (flags & messageOpcodeMask) >> 11; opcode != 0 {
+		return nil, fmt.Errorf(errorMessageInvalidOpcode, opcode)
+	}
+
+	msg := &Message{
+		ID:               binary.BigEndian.Uint16(b),
+		Response:         flags&messageFlagResponse != 0,
+		Authoritative:    flags&messageFlagAuthority != 0,
+		Truncated:        flags&messageFlagTruncated != 0,
+		RecursionDesired: flags&messageFlagRecursion != 0,
+		RCode:            RCode(flags & messageRCodeMask),
+	}
+
+	offset := messageHeaderSize
+	for n := 0; n < int(binary.BigEndian.Uint16(b[4:])); n++ {
+		name, end, err := readName(b, offset)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) < end+questionSize {
+			return nil, fmt.Errorf(errorMessageTooShort, len(b))
+		}
+		msg.Questions = append(msg.Questions, &Question{
+			Name:  name,
+			Type:  Type(binary.BigEndian.Uint16(b[end:])),
+			Class: binary.BigEndian.Uint16(b[end+2:]),
+		})
+		offset = end + questionSize
+	}
+
+	sections := []*[]*Record{&msg.Answers, &msg.Authorities, &msg.Additionals}
+	for n, section := range sections {
+		count := int(binary.BigEndian.Uint16(b[6+n*2:]))
+		for i := 0; i < count; i++ {
+			record, end, err := readRecord(b, offset)
+			if err != nil {
+				return nil, err
+			}
+			*section = append(*section, record)
+			offset = end
+		}
+	}
+
+	return msg, nil
+}
+
+// String returns the response code name.
+func (rcode RCode) String() string {
+	switch rcode {
+	case RCodeSuccess:
+		return "NOERROR"
+	case RCodeFormatError:
+		return "FORMERR"
+	case RCodeServerFailure:
+		return "SERVFAIL"
+	case RCodeNameError:
+		return "NXDOMAIN"
+	case RCodeNotImplemented:
+		return "NOTIMP"
+	case RCodeRefused:
+		return "REFUSED"
+	}
+	return fmt.Sprintf("RCODE%d", uint16(rcode))
+}
+
+// IsResponse returns true when the message is a response, otherwise false.
+func (msg *Message) IsResponse() bool {
+	return msg.Response
+}
+
+// IsQuery returns true when the message is a query, otherwise false.
+func (msg *Message) IsQuery() bool {
+	return !msg.Response
+}
+
+// AddAdditionals adds the specified records into the additional section.
+func (msg *Message) AddAdditionals(records ...*Record) *Message {
+	msg.Additionals = append(msg.Additionals, records...)
+	return msg
+}
+
+// UDPPayloadSize returns the UDP payload size advertised by the EDNS0 OPT record, or 512 when the message has no OPT record.
+func (msg *Message) UDPPayloadSize() int {
+	for _, record := range msg.Additionals {
+		if record.Type == TypeOPT && messageUDPPayloadSize < int(record.Class) {
+			return int(record.Class)
+		}
+	}
+	return messageUDPPayloadSize
+}
+
+// Records returns all records of the answer, authority and additional sections.
+func (msg *Message) Records() []*Record {
+	records := make([]*Record, 0, len(msg.Answers)+len(msg.Authorities)+len(msg.Additionals))
+	records = append(records, msg.Answers...)
+	records = append(records, msg.Authorities...)
+	records = append(records, msg.Additionals...)
+	return records
+}
+
+// Bytes returns the message in the wire format without the name compression.
+func (msg *Message) Bytes() ([]byte, error) {
+	var flags uint16
+	if msg.Response {
+		flags |= messageFlagResponse
+	}
+	if msg.Authoritative {
+		flags |= messageFlagAuthority
+	}
+	if msg.Truncated {
+		flags |= messageFlagTruncated
+	}
+	if msg.RecursionDesired {
+		flags |= messageFlagRecursion
+	}
+	flags |= uint16(msg.RCode) & messageRCodeMask
+
+	b := make([]byte, 0, messageHeaderSize)
+	b = binary.BigEndian.AppendUint16(b, msg.ID)
+	b = binary.BigEndian.AppendUint16(b, flags)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(msg.Questions)))
+	b = binary.BigEndian.AppendUint16(b, uint16(len(msg.Answers)))
+	b = binary.BigEndian.AppendUint16(b, uint16(len(msg.Authorities)))
+	b = binary.BigEndian.AppendUint16(b, uint16(len(msg.Additionals)))
+
+	var err error
+	for _, question := range msg.Questions {
+		b, err = appendName(b, question.Name)
+		if err != nil {
+			return nil, err
+		}
+		b = binary.BigEndian.AppendUint16(b, uint16(question.Type))
+		b = binary.BigEndian.AppendUint16(b, question.Class)
+	}
+
+	for _, record := range msg.Records() {
+		b, err = record.appendRecord(b)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if MessageMaxSize < len(b) {
+		return nil, fmt.Errorf(errorMessageTooLong, len(b))
+	}
+
+	return b, nil
+}
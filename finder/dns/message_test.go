@@ -0,0 +1,175 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+const (
+	testServiceName = "_finder._tcp.local."
+	testRecordTTL   = 120
+)
+
+func TestMessage(t *testing.T) {
+	msg := NewResponseMessage(
+		NewPTRRecord(testServiceName, "node\\.01."+testServiceName, testRecordTTL),
+		NewSRVRecord("node\\.01."+testServiceName, "node-01.local.", 8001, testRecordTTL),
+		NewTXTRecord("node\\.01."+testServiceName, []string{"cluster=a", "label.zone=b"}, testRecordTTL),
+	).AddAdditionals(
+		NewAddressRecord("node-01.local.", net.ParseIP("192.168.100.1"), testRecordTTL),
+		NewAddressRecord("node-01.local.", net.ParseIP("fe80::1"), testRecordTTL),
+	)
+	msg.Questions = []*Question{{Name: testServiceName, Type: TypePTR, Class: ClassINET}}
+
+	b, err := msg.Bytes()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	parsedMsg, err := NewMessageWithBytes(b)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !parsedMsg.IsResponse() || !parsedMsg.Authoritative {
+		t.Errorf("%v is not an authoritative response", parsedMsg)
+	}
+	if len(parsedMsg.Questions) != 1 || parsedMsg.Questions[0].Name != testServiceName {
+		t.Errorf("%v != %v", parsedMsg.Questions, msg.Questions)
+	}
+
+	records := msg.Records()
+	parsedRecords := parsedMsg.Records()
+	if len(parsedRecords) != len(records) {
+		t.Errorf("%d != %d", len(parsedRecords), len(records))
+		return
+	}
+	for n, record := range records {
+		parsedRecord := parsedRecords[n]
+		if parsedRecord.Name != record.Name || parsedRecord.Type != record.Type || parsedRecord.Class != record.Class || parsedRecord.TTL != record.TTL {
+			t.Errorf("%s != %s", parsedRecord, record)
+		}
+		if parsedRecord.Target != record.Target || parsedRecord.Port != record.Port || !parsedRecord.IP.Equal(record.IP) {
+			t.Errorf("%v != %v", parsedRecord, record)
+		}
+		if len(parsedRecord.Texts) != len(record.Texts) {
+			t.Errorf("%v != %v", parsedRecord.Texts, record.Texts)
+		}
+	}
+
+	if labels := NameLabels(parsedRecords[0].Target); len(labels) != 4 || labels[0] != "node.01" {
+		t.Errorf("%v", labels)
+	}
+}
+
+func TestMessageCompression(t *testing.T) {
+	// A response which has a PTR record of "_finder._tcp.local." to "node._finder._tcp.local." with the compressed names.
+	b := []byte{
+		0x00, 0x00, 0x84, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+		0x07, '_', 'f', 'i', 'n', 'd', 'e', 'r', 0x04, '_', 't', 'c', 'p', 0x05, 'l', 'o', 'c', 'a', 'l', 0x00,
+		0x00, 0x0C, 0x00, 0x01, 0x00, 0x00, 0x00, 0x78, 0x00, 0x07,
+		0x04, 'n', 'o', 'd', 'e', 0xC0, 0x0C,
+	}
+	msg, err := NewMessageWithBytes(b)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(msg.Answers) != 1 {
+		t.Errorf("%d != %d", len(msg.Answers), 1)
+		return
+	}
+	if msg.Answers[0].Name != testServiceName || msg.Answers[0].Target != "node."+testServiceName {
+		t.Errorf("%s -> %s", msg.Answers[0].Name, msg.Answers[0].Target)
+	}
+
+	// Compression pointers to themselves are rejected
+
+	loop := append(bytes.Clone(b[:12]), 0xC0, 0x0C, 0x00, 0x0C, 0x00, 0x01, 0x00, 0x00, 0x00, 0x78, 0x00, 0x00)
+	if _, err := NewMessageWithBytes(loop); err == nil {
+		t.Errorf("a looped name is parsed")
+	}
+
+	for n := 0; n < len(b); n++ {
+		if _, err := NewMessageWithBytes(b[:n]); err == nil {
+			t.Errorf("a truncated message (%d) is parsed", n)
+		}
+	}
+}
+
+func TestMessageInvalidNames(t *testing.T) {
+	names := []string{
+		"a..local.",
+		string(bytes.Repeat([]byte{'a'}, 64)) + ".local.",
+	}
+	for _, name := range names {
+		msg := NewQueryMessage(&Question{Name: name, Type: TypePTR, Class: ClassINET})
+		if _, err := msg.Bytes(); err == nil {
+			t.Errorf("%s is encoded", name)
+		}
+	}
+}
+
+func TestMessageHeaderFlags(t *testing.T) {
+	msg := NewResponseMessage()
+	msg.ID = 0x1234
+	msg.Truncated = true
+	msg.RecursionDesired = true
+	msg.RCode = RCodeNameError
+
+	b, err := msg.Bytes()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	parsedMsg, err := NewMessageWithBytes(b)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if parsedMsg.ID != msg.ID || !parsedMsg.Truncated || !parsedMsg.RecursionDesired || parsedMsg.RCode != RCodeNameError {
+		t.Errorf("%v != %v", parsedMsg, msg)
+	}
+}
+
+func TestMessageOPTRecord(t *testing.T) {
+	msg := NewQueryMessage(&Question{Name: "_finder._tcp.example.com.", Type: TypeSRV, Class: ClassINET})
+	if size := msg.UDPPayloadSize(); size != 512 {
+		t.Errorf("%d != %d", size, 512)
+	}
+
+	msg.AddAdditionals(NewOPTRecord(4096))
+	b, err := msg.Bytes()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	parsedMsg, err := NewMessageWithBytes(b)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(parsedMsg.Additionals) != 1 || parsedMsg.Additionals[0].Type != TypeOPT {
+		t.Errorf("%v != %v", parsedMsg.Additionals, msg.Additionals)
+		return
+	}
+	if size := parsedMsg.UDPPayloadSize(); size != 4096 {
+		t.Errorf("%d != %d", size, 4096)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+const (
+	// LabelMaxSize is the max size of the labels of the names.
+	LabelMaxSize = 63
+)
+
+const (
+	nameMaxSize        = 255
+	namePointerMask    = 0xC0
+	namePointerMaxJump = 32
+	nameRoot           = "."
+	nameSeparator      = '.'
+	nameEscape         = '\\'
+)
+
+const (
+	errorNameTooLong       = "name is too long : %q"
+	errorNameLabelTooLong  = "label is too long : %q"
+	errorNameEmptyLabel    = "empty label : %q"
+	errorNameInvalidOffset = "invalid name offset : %d"
+	errorNameTooManyJumps  = "too many compression pointers at %d"
+)
+
+// EscapeLabel returns the label whose separators and escapes are escaped to be a part of a name.
+func EscapeLabel(label string) string {
+	var escaped strings.Builder
+	for n := 0; n < len(label); n++ {
+		c := label[n]
+		if c == nameSeparator || c == nameEscape {
+			escaped.WriteByte(nameEscape)
+		}
+		escaped.WriteByte(c)
+	}
+	return escaped.String()
+}
+
+// NameLabels returns the unescaped labels of the specified name.
+func NameLabels(name string) []string {
+	labels := []string{}
+	var label strings.Builder
+	for n := 0; n < len(name); n++ {
+		c := name[n]
+		switch {
+		case c == nameEscape && n+1 < len(name):
+			n++
+			label.WriteByte(name[n])
+		case c == nameSeparator:
+			labels = append(labels, label.String())
+			label.Reset()
+		default:
+			label.WriteByte(c)
+		}
+	}
+	if 0 < label.Len() {
+		labels = append(labels, label.String())
+	}
+	return labels
+}
+
+// NameEqual returns true when the specified names are the same ignoring the case and the trailing separator, otherwise false.
+func NameEqual(this, other string) bool {
+	return strings.EqualFold(strings.TrimSuffix(this, nameRoot), strings.TrimSuffix(other, nameRoot))
+}
+
+// appendName appends the specified name in the wire format without the compression.
+func appendName(b []byte, name string) ([]byte, error) {
+	size := 1
+	for _, label := range NameLabels(name) {
+		if len(label) == 0 {
+			return nil, fmt.Errorf(errorNameEmptyLabel, name)
+		}
+		if LabelMaxSize < len(label) {
+			return nil, fmt.Errorf(errorNameLabelTooLong, label)
+		}
+		size += 1 + len(label)
+		if nameMaxSize < size {
+			return nil, fmt.Errorf(errorNameTooLong, name)
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0), nil
+}
+
+// readName reads the name at the specified offset of the message, and returns the escaped absolute name and the offset after the name.
+func readName(msg []byte, offset int) (string, int, error) {
+	var name strings.Builder
+	end := -1
+	jumps := 0
+	for {
+		if len(msg) <= offset {
+			return "", 0, fmt.Errorf(errorNameInvalidOffset, offset)
+		}
+		size := int(msg[offset])
+		switch {
+		case size == 0:
+			if end < 0 {
+				end = offset + 1
+			}
+			if name.Len() == 0 {
+				return nameRoot, end, nil
+			}
+			return name.String(), end, nil
+		case size&namePointerMask == namePointerMask:
+			if len(msg) <= offset+1 {
+				return "", 0, fmt.Errorf(errorNameInvalidOffset, offset)
+			}
+			jumps++
+			if namePointerMaxJump < jumps {
+				return "", 0, fmt.Errorf(errorNameTooManyJumps, offset)
+			}
+			if end < 0 {
+				end = offset + 2
+			}
+			offset = int(binary.BigEndian.Uint16(msg[offset:]) &^ (namePointerMask << 8))
+		case size&namePointerMask != 0:
+			return "", 0, fmt.Errorf(errorNameInvalidOffset, offset)
+		default:
+			offset++
+			if len(msg) < offset+size {
+				return "", 0, fmt.Errorf(errorNameInvalidOffset, offset)
+			}
+			name.WriteString(EscapeLabel(string(msg[offset : offset+size])))
+			name.WriteByte(nameSeparator)
+			if nameMaxSize < name.Len() {
+				return "", 0, fmt.Errorf(errorNameTooLong, name.String())
+			}
+			offset += size
+		}
+	}
+}
+
+// AbsoluteName returns the specified name with the trailing separator.
+func AbsoluteName(name string) string {
+	if strings.HasSuffix(name, nameRoot) && !strings.HasSuffix(name, string(nameEscape)+nameRoot) {
+		return name
+	}
+	return name + nameRoot
+}
@@ -0,0 +1,267 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+// Type represents a resource record type.
+type Type uint16
+
+const (
+	TypeA    Type = 1
+	TypePTR  Type = 12
+	TypeTXT  Type = 16
+	TypeAAAA Type = 28
+	TypeSRV  Type = 33
+	TypeOPT  Type = 41
+	TypeANY  Type = 255
+)
+
+const (
+	// ClassINET is the Internet class.
+	ClassINET = 0x0001
+	// ClassCacheFlush is the cache-flush bit of the records, and the unicast-response bit of the questions.
+	ClassCacheFlush = 0x8000
+	// TextMaxSize is the max size of the strings of TXT records.
+	TextMaxSize = 255
+)
+
+const (
+	recordHeaderSize = 10
+	srvHeaderSize    = 6
+)
+
+const (
+	errorRecordTooShort       = "record is too short at %d"
+	errorRecordInvalidData    = "invalid %s record data size %d"
+	errorRecordTextTooLong    = "text is too long : %q"
+	errorRecordInvalidAddress = "invalid address %s of %s record"
+)
+
+// String returns the type name.
+func (t Type) String() string {
+	switch t {
+	case TypeA:
+		return "A"
+	case TypePTR:
+		return "PTR"
+	case TypeTXT:
+		return "TXT"
+	case TypeAAAA:
+		return "AAAA"
+	case TypeSRV:
+		return "SRV"
+	case TypeOPT:
+		return "OPT"
+	case TypeANY:
+		return "ANY"
+	}
+	return fmt.Sprintf("TYPE%d", uint16(t))
+}
+
+// Question represents a question of the DNS messages.
+type Question struct {
+	Name  string
+	Type  Type
+	Class uint16
+}
+
+// Record represents a resource record of the DNS messages.
+// Only the fields of the record type are used, and the data of the other types are kept as it is.
+type Record struct {
+	Name  string
+	Type  Type
+	Class uint16
+	TTL   uint32
+	// Target is the domain name of PTR and SRV records.
+	Target string
+	// Priority, Weight and Port are the fields of SRV records.
+	Priority uint16
+	Weight   uint16
+	Port     uint16
+	// Texts is the strings of TXT records.
+	Texts []string
+	// IP is the address of A and AAAA records.
+	IP   net.IP
+	Data []byte
+}
+
+// NewPTRRecord returns a new PTR record.
+func NewPTRRecord(name string, target string, ttl uint32) *Record {
+	return &Record{Name: name, Type: TypePTR, Class: ClassINET, TTL: ttl, Target: target}
+}
+
+// NewSRVRecord returns a new SRV record.
+func NewSRVRecord(name string, target string, port uint16, ttl uint32) *Record {
+	return &Record{Name: name, Type: TypeSRV, Class: ClassINET | ClassCacheFlush, TTL: ttl, Target: target, Port: port}
+}
+
+// NewTXTRecord returns a new TXT record.
+func NewTXTRecord(name string, texts []string, ttl uint32) *Record {
+	return &Record{Name: name, Type: TypeTXT, Class: ClassINET | ClassCacheFlush, TTL: ttl, Texts: texts}
+}
+
+// NewAddressRecord returns a new A or AAAA record of the specified address.
+func NewAddressRecord(name string, ip net.IP, ttl uint32) *Record {
+	record := &Record{Name: name, Type: TypeAAAA, Class: ClassINET | ClassCacheFlush, TTL: ttl, IP: ip}
+	if ip4 := ip.To4(); ip4 != nil {
+		record.Type = TypeA
+		record.IP = ip4
+	}
+	return record
+}
+
+// NewOPTRecord returns a new EDNS0 OPT pseudo record which advertises the specified UDP payload size.
+func NewOPTRecord(payloadSize uint16) *Record {
+	return &Record{Name: "", Type: TypeOPT, Class: payloadSize, TTL: 0}
+}
+
+// String returns the record description.
+func (record *Record) String() string {
+	return fmt.Sprintf("%s %d %s", record.Name, record.TTL, record.Type)
+}
+
+// appendData appends the record data in the wire format.
+func (record *Record) appendData(b []byte) ([]byte, error) {
+	var err error
+	switch record.Type {
+	case TypeA:
+		ip := record.IP.To4()
+		if ip == nil {
+			return nil, fmt.Errorf(errorRecordInvalidAddress, record.IP, record.Type)
+		}
+		b = append(b, ip...)
+	case TypeAAAA:
+		ip := record.IP.To16()
+		if ip == nil {
+			return nil, fmt.Errorf(errorRecordInvalidAddress, record.IP, record.Type)
+		}
+		b = append(b, ip...)
+	case TypePTR:
+		b, err = appendName(b, record.Target)
+	case TypeSRV:
+		b = binary.BigEndian.AppendUint16(b, record.Priority)
+		b = binary.BigEndian.AppendUint16(b, record.Weight)
+		b = binary.BigEndian.AppendUint16(b, record.Port)
+		b, err = appendName(b, record.Target)
+	case TypeTXT:
+		if len(record.Texts) == 0 {
+			return append(b, 0), nil
+		}
+		for _, text := range record.Texts {
+			if TextMaxSize < len(text) {
+				return nil, fmt.Errorf(errorRecordTextTooLong, text)
+			}
+			b = append(b, byte(len(text)))
+			b = append(b, text...)
+		}
+	default:
+		b = append(b, record.Data...)
+	}
+	return b, err
+}
+
+// appendRecord appends the record in the wire format.
+func (record *Record) appendRecord(b []byte) ([]byte, error) {
+	b, err := appendName(b, record.Name)
+	if err != nil {
+		return nil, err
+	}
+	b = binary.BigEndian.AppendUint16(b, uint16(record.Type))
+	b = binary.BigEndian.AppendUint16(b, record.Class)
+	b = binary.BigEndian.AppendUint32(b, record.TTL)
+	sizeOffset := len(b)
+	b = append(b, 0, 0)
+	b, err = record.appendData(b)
+	if err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint16(b[sizeOffset:], uint16(len(b)-sizeOffset-2))
+	return b, nil
+}
+
+// readRecord reads the record at the specified offset of the message, and returns the offset after the record.
+func readRecord(msg []byte, offset int) (*Record, int, error) {
+	name, offset, err := readName(msg, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(msg) < offset+recordHeaderSize {
+		return nil, 0, fmt.Errorf(errorRecordTooShort, offset)
+	}
+	record := &Record{
+		Name:  name,
+		Type:  Type(binary.BigEndian.Uint16(msg[offset:])),
+		Class: binary.BigEndian.Uint16(msg[offset+2:]),
+		TTL:   binary.BigEndian.Uint32(msg[offset+4:]),
+	}
+	size := int(binary.BigEndian.Uint16(msg[offset+8:]))
+	offset += recordHeaderSize
+	if len(msg) < offset+size {
+		return nil, 0, fmt.Errorf(errorRecordTooShort, offset)
+	}
+	data := msg[offset : offset+size]
+	end := offset + size
+
+	switch record.Type {
+	case TypeA:
+		if len(data) != net.IPv4len {
+			return nil, 0, fmt.Errorf(errorRecordInvalidData, record.Type, len(data))
+		}
+		record.IP = net.IP(append([]byte{}, data...))
+	case TypeAAAA:
+		if len(data) != net.IPv6len {
+			return nil, 0, fmt.Errorf(errorRecordInvalidData, record.Type, len(data))
+		}
+		record.IP = net.IP(append([]byte{}, data...))
+	case TypePTR:
+		record.Target, _, err = readName(msg, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+	case TypeSRV:
+		if len(data) < srvHeaderSize {
+			return nil, 0, fmt.Errorf(errorRecordInvalidData, record.Type, len(data))
+		}
+		record.Priority = binary.BigEndian.Uint16(data)
+		record.Weight = binary.BigEndian.Uint16(data[2:])
+		record.Port = binary.BigEndian.Uint16(data[4:])
+		record.Target, _, err = readName(msg, offset+srvHeaderSize)
+		if err != nil {
+			return nil, 0, err
+		}
+	case TypeTXT:
+		record.Texts = []string{}
+		for n := 0; n < len(data); {
+			textSize := int(data[n])
+			n++
+			if len(data) < n+textSize {
+				return nil, 0, fmt.Errorf(errorRecordInvalidData, record.Type, len(data))
+			}
+			if 0 < textSize {
+				record.Texts = append(record.Texts, string(data[n:n+textSize]))
+			}
+			n += textSize
+		}
+	default:
+		record.Data = append([]byte{}, data...)
+	}
+
+	return record, end, nil
+}
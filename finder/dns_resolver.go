@@ -0,0 +1,303 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	finder_dns "github.com/cybergarage/go-finder/finder/dns"
+)
+
+const (
+	// DefaultDNSTTL is the time-to-live of the records resolved by the resolvers which do not report the TTLs.
+	DefaultDNSTTL = 30 * time.Second
+	// DefaultDNSTimeout is the default timeout of the DNS queries.
+	DefaultDNSTimeout = 5 * time.Second
+	// dnsMessageMaxSize is the max size of the DNS response messages over UDP which is advertised by the EDNS0 OPT record.
+	dnsMessageMaxSize = 4096
+	// dnsTCPLengthSize is the size of the length prefix of the DNS messages over TCP.
+	dnsTCPLengthSize = 2
+)
+
+const (
+	errorDNSResolverResponse   = "DNS query %s %s is failed : %s"
+	errorDNSResolverTruncated  = "DNS response of %s %s is truncated"
+	errorDNSResolverUnexpected = "DNS response of %s %s is unexpected"
+)
+
+// DNSService represents a resolved SRV record.
+type DNSService struct {
+	// Target is the host name of the service.
+	Target   string
+	Port     uint16
+	Priority uint16
+	Weight   uint16
+	TTL      time.Duration
+}
+
+// DNSAddress represents a resolved A or AAAA record.
+type DNSAddress struct {
+	IP  net.IP
+	TTL time.Duration
+}
+
+// DNSResolver represents an abstract resolver of the DNS finder.
+type DNSResolver interface {
+	// LookupSRV returns the SRV records of the specified name.
+	LookupSRV(ctx context.Context, name string) ([]*DNSService, error)
+	// LookupIP returns the A and AAAA records of the specified host.
+	LookupIP(ctx context.Context, host string) ([]*DNSAddress, error)
+}
+
+// dnsSystemResolver represents a resolver of the system which reports the default TTL.
+type dnsSystemResolver struct {
+	resolver *net.Resolver
+}
+
+// NewDNSSystemResolver returns a new resolver of the system, and the resolved records have DefaultDNSTTL.
+func NewDNSSystemResolver() DNSResolver {
+	return &dnsSystemResolver{
+		resolver: net.DefaultResolver,
+	}
+}
+
+// LookupSRV returns the SRV records of the specified name.
+func (resolver *dnsSystemResolver) LookupSRV(ctx context.Context, name string) ([]*DNSService, error) {
+	_, srvs, err := resolver.resolver.LookupSRV(ctx, "", "", name)
+	if err != nil {
+		return nil, err
+	}
+	services := make([]*DNSService, len(srvs))
+	for n, srv := range srvs {
+		services[n] = &DNSService{Target: srv.Target, Port: srv.Port, Priority: srv.Priority, Weight: srv.Weight, TTL: DefaultDNSTTL}
+	}
+	return services, nil
+}
+
+// LookupIP returns the A and AAAA records of the specified host.
+func (resolver *dnsSystemResolver) LookupIP(ctx context.Context, host string) ([]*DNSAddress, error) {
+	ips, err := resolver.resolver.LookupIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]*DNSAddress, len(ips))
+	for n, ip := range ips {
+		addrs[n] = &DNSAddress{IP: ip, TTL: DefaultDNSTTL}
+	}
+	return addrs, nil
+}
+
+// DNSServerResolver represents a resolver which queries the specified DNS server over UDP, and reports the TTLs of the records.
+// The query is retried over TCP when the UDP response is truncated.
+type DNSServerResolver struct {
+	server  string
+	timeout time.Duration
+}
+
+// NewDNSServerResolver returns a new resolver which queries the specified DNS server such as "192.168.100.53:53".
+func NewDNSServerResolver(server string) *DNSServerResolver {
+	return &DNSServerResolver{
+		server:  server,
+		timeout: DefaultDNSTimeout,
+	}
+}
+
+// SetTimeout sets the timeout of each lookup, and the A and AAAA queries of LookupIP share the timeout.
+func (resolver *DNSServerResolver) SetTimeout(timeout time.Duration) {
+	resolver.timeout = timeout
+}
+
+// Server returns the DNS server address.
+func (resolver *DNSServerResolver) Server() string {
+	return resolver.server
+}
+
+// query sends a query of the specified name and type until the deadline of the context, and returns the answer records of the type.
+func (resolver *DNSServerResolver) query(ctx context.Context, name string, recordType finder_dns.Type) ([]*finder_dns.Record, error) {
+	var id [2]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	query := finder_dns.NewQueryMessage(&finder_dns.Question{Name: finder_dns.AbsoluteName(name), Type: recordType, Class: finder_dns.ClassINET})
+	query.ID = binary.BigEndian.Uint16(id[:])
+	query.RecursionDesired = true
+	query.AddAdditionals(finder_dns.NewOPTRecord(dnsMessageMaxSize))
+	b, err := query.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resolver.exchangeUDP(ctx, query, b)
+	if err == nil && res.Truncated {
+		res, err = resolver.exchangeTCP(ctx, query, b)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case res.RCode == finder_dns.RCodeNameError:
+		return []*finder_dns.Record{}, nil
+	case res.RCode != finder_dns.RCodeSuccess:
+		return nil, fmt.Errorf(errorDNSResolverResponse, name, recordType, res.RCode)
+	case res.Truncated:
+		return nil, fmt.Errorf(errorDNSResolverTruncated, name, recordType)
+	}
+	records := []*finder_dns.Record{}
+	for _, record := range res.Answers {
+		if record.Type == recordType {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// dial connects to the DNS server over the specified network until the deadline of the context.
+func (resolver *DNSServerResolver) dial(ctx context.Context, network string) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, resolver.server)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	return conn, nil
+}
+
+// exchangeUDP sends the specified query bytes over UDP, and returns the response of the query.
+func (resolver *DNSServerResolver) exchangeUDP(ctx context.Context, query *finder_dns.Message, b []byte) (*finder_dns.Message, error) {
+	conn, err := resolver.dial(ctx, "udp")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(b); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, dnsMessageMaxSize)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		res, err := finder_dns.NewMessageWithBytes(buf[:n])
+		if err != nil || !res.IsResponse() || res.ID != query.ID {
+			// Ignores the invalid and unexpected responses until the timeout.
+			continue
+		}
+		return res, nil
+	}
+}
+
+// exchangeTCP sends the specified query bytes over TCP, and returns the response of the query.
+func (resolver *DNSServerResolver) exchangeTCP(ctx context.Context, query *finder_dns.Message, b []byte) (*finder_dns.Message, error) {
+	conn, err := resolver.dial(ctx, "tcp")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	req := binary.BigEndian.AppendUint16(make([]byte, 0, dnsTCPLengthSize+len(b)), uint16(len(b)))
+	if _, err := conn.Write(append(req, b...)); err != nil {
+		return nil, err
+	}
+
+	var size [dnsTCPLengthSize]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint16(size[:]))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+	res, err := finder_dns.NewMessageWithBytes(buf)
+	if err != nil {
+		return nil, err
+	}
+	if !res.IsResponse() || res.ID != query.ID {
+		return nil, fmt.Errorf(errorDNSResolverUnexpected, query.Questions[0].Name, query.Questions[0].Type)
+	}
+	return res, nil
+}
+
+// LookupSRV returns the SRV records of the specified name.
+func (resolver *DNSServerResolver) LookupSRV(ctx context.Context, name string) ([]*DNSService, error) {
+	ctx, cancel := context.WithTimeout(ctx, resolver.timeout)
+	defer cancel()
+
+	records, err := resolver.query(ctx, name, finder_dns.TypeSRV)
+	if err != nil {
+		return nil, err
+	}
+	services := make([]*DNSService, len(records))
+	for n, record := range records {
+		services[n] = &DNSService{
+			Target:   record.Target,
+			Port:     record.Port,
+			Priority: record.Priority,
+			Weight:   record.Weight,
+			TTL:      time.Duration(record.TTL) * time.Second,
+		}
+	}
+	return services, nil
+}
+
+// LookupIP returns the A and AAAA records of the specified host, and the A and AAAA queries are sent concurrently within the timeout.
+func (resolver *DNSServerResolver) LookupIP(ctx context.Context, host string) ([]*DNSAddress, error) {
+	ctx, cancel := context.WithTimeout(ctx, resolver.timeout)
+	defer cancel()
+
+	type queryResult struct {
+		records []*finder_dns.Record
+		err     error
+	}
+
+	recordTypes := []finder_dns.Type{finder_dns.TypeA, finder_dns.TypeAAAA}
+	results := make([]chan queryResult, len(recordTypes))
+	for n, recordType := range recordTypes {
+		results[n] = make(chan queryResult, 1)
+		go func(recordType finder_dns.Type, result chan<- queryResult) {
+			records, err := resolver.query(ctx, host, recordType)
+			result <- queryResult{records: records, err: err}
+		}(recordType, results[n])
+	}
+
+	addrs := []*DNSAddress{}
+	errs := []error{}
+	for _, ch := range results {
+		result := <-ch
+		if result.err != nil {
+			errs = append(errs, result.err)
+			continue
+		}
+		for _, record := range result.records {
+			addrs = append(addrs, &DNSAddress{IP: record.IP, TTL: time.Duration(record.TTL) * time.Second})
+		}
+	}
+	if len(addrs) == 0 && 0 < len(errs) {
+		return nil, errors.Join(errs...)
+	}
+	return addrs, nil
+}
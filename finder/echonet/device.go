@@ -34,6 +34,8 @@ const (
 	FinderHostCode      = 0xA1
 	FinderAddressCode   = 0xA2
 	FinderRPCPortCode   = 0xA3
+	FinderLabelsCode    = 0xA4
+	FinderPortsCode     = 0xA5
 	FinderClockCode     = 0xB0
 )
 
@@ -44,10 +46,20 @@ const (
 
 	FinderClockSize   = 8
 	FinderVersionSize = 8
+
+	// FinderPropertyDataMaxSize is the max size of the property data because the size is encoded in a byte.
+	FinderPropertyDataMaxSize = 0xFF
 )
 
-func FinderDeviceAllPropertyCodes() []uecho.PropertyCode {
+const (
+	msgEchonetDeviceLabelsTruncated = "Labels of %s are truncated to %d bytes : %s"
+	msgEchonetDevicePortsTruncated  = "Ports of %s are truncated to %d bytes : %s"
+)
+
+// FinderDeviceRequiredPropertyCodes returns the property codes which all finder nodes have.
+func FinderDeviceRequiredPropertyCodes() []uecho.PropertyCode {
 	props := []uecho.PropertyCode{
+		FinderConditionCode,
 		FinderClusterCode,
 		FinderHostCode,
 		FinderAddressCode,
@@ -57,6 +69,20 @@ func FinderDeviceAllPropertyCodes() []uecho.PropertyCode {
 	return props
 }
 
+// FinderDeviceOptionalPropertyCodes returns the property codes which are not required to parse finder nodes.
+func FinderDeviceOptionalPropertyCodes() []uecho.PropertyCode {
+	props := []uecho.PropertyCode{
+		FinderLabelsCode,
+		FinderPortsCode,
+	}
+	return props
+}
+
+// FinderDeviceAllPropertyCodes returns the required and optional property codes.
+func FinderDeviceAllPropertyCodes() []uecho.PropertyCode {
+	return append(FinderDeviceRequiredPropertyCodes(), FinderDeviceOptionalPropertyCodes()...)
+}
+
 // EchonetDevice represents a base device for Echonet.
 type EchonetDevice struct {
 	*uecho.Device
@@ -81,29 +107,87 @@ func (dev *EchonetDevice) UpdatePropertyWithNode(node node.Node) {
 	}
 
 	for _, propCode := range FinderDeviceAllPropertyCodes() {
-		var propData []byte
-		switch propCode {
-		case FinderConditionCode:
-			propData = []byte{byte(node.Condition())}
-		case FinderClusterCode:
-			propData = []byte(node.Cluster())
-		case FinderHostCode:
-			propData = []byte(node.Host())
-		case FinderAddressCode:
-			propData = []byte(node.Address())
-		case FinderRPCPortCode:
-			propData = make([]byte, FinderRPCPortSize)
-			uecho_encoding.IntegerToByte(uint(node.RPCPort()), propData)
-		case FinderClockCode:
-			propData = make([]byte, FinderClockSize)
-			uecho_encoding.IntegerToByte(uint(node.Clock()), propData)
-		default:
+		propData, ok := NewPropertyDataWithNode(node, propCode)
+		if !ok {
 			continue
 		}
 
 		err := dev.SetPropertyData(uecho_protocol.PropertyCode(propCode), propData)
 		if err != nil {
-			log.Errorf(err.Error())
+			log.Errorf("%s", err.Error())
 		}
 	}
 }
+
+// NewPropertyDataWithNode returns the property data of the specified code for the specified node.
+func NewPropertyDataWithNode(node node.Node, propCode uecho.PropertyCode) ([]byte, bool) {
+	var propData []byte
+	switch propCode {
+	case FinderConditionCode:
+		propData = []byte{byte(node.Condition())}
+	case FinderClusterCode:
+		propData = []byte(node.Cluster())
+	case FinderHostCode:
+		propData = []byte(node.Host())
+	case FinderAddressCode:
+		propData = []byte{}
+		if addr := node.Address(); addr != nil {
+			propData = []byte(addr.String())
+		}
+	case FinderRPCPortCode:
+		propData = make([]byte, FinderRPCPortSize)
+		uecho_encoding.IntegerToByte(uint(node.RPCPort()), propData)
+	case FinderLabelsCode:
+		propData = newLabelsPropertyData(node)
+	case FinderPortsCode:
+		propData = newPortsPropertyData(node)
+	case FinderClockCode:
+		propData = make([]byte, FinderClockSize)
+		uecho_encoding.IntegerToByte(uint(node.Clock()), propData)
+	default:
+		return nil, false
+	}
+	return propData, true
+}
+
+// newLabelsPropertyData returns the labels string of the specified node, which the labels are dropped from to fit in the property data.
+func newLabelsPropertyData(srcNode node.Node) []byte {
+	labels := srcNode.Labels()
+	labelsStr := labels.String()
+	if len(labelsStr) <= FinderPropertyDataMaxSize {
+		return []byte(labelsStr)
+	}
+
+	fittedLabels := node.NewLabels()
+	for _, key := range labels.Keys() {
+		fittedLabels[key] = labels[key]
+		if FinderPropertyDataMaxSize < len(fittedLabels.String()) {
+			delete(fittedLabels, key)
+		}
+	}
+	fittedLabelsStr := fittedLabels.String()
+	log.Warnf(msgEchonetDeviceLabelsTruncated, srcNode.Host(), FinderPropertyDataMaxSize, fittedLabelsStr)
+
+	return []byte(fittedLabelsStr)
+}
+
+// newPortsPropertyData returns the named ports string of the specified node, which the ports are dropped from to fit in the property data.
+func newPortsPropertyData(srcNode node.Node) []byte {
+	ports := srcNode.Ports()
+	portsStr := ports.String()
+	if len(portsStr) <= FinderPropertyDataMaxSize {
+		return []byte(portsStr)
+	}
+
+	fittedPorts := node.NewPorts()
+	for _, name := range ports.Names() {
+		fittedPorts[name] = ports[name]
+		if FinderPropertyDataMaxSize < len(fittedPorts.String()) {
+			delete(fittedPorts, name)
+		}
+	}
+	fittedPortsStr := fittedPorts.String()
+	log.Warnf(msgEchonetDevicePortsTruncated, srcNode.Host(), FinderPropertyDataMaxSize, fittedPortsStr)
+
+	return []byte(fittedPortsStr)
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/cybergarage/go-finder/finder/node"
 	uecho "github.com/cybergarage/uecho-go/net/echonet"
+	uecho_protocol "github.com/cybergarage/uecho-go/net/echonet/protocol"
 )
 
 const (
@@ -34,6 +35,14 @@ type finderNode struct {
 
 // NewFinderNodeWithResponseMesssage returns a new finder node with the specified message.
 func NewFinderNodeWithResponseMesssage(msg *uecho.Message) (node.Node, error) {
+	if msg == nil {
+		return nil, fmt.Errorf(errorEchonetFinderInvalidMessage, msg)
+	}
+	return NewFinderNodeWithMessage(msg.Message)
+}
+
+// NewFinderNodeWithMessage returns a new finder node with the specified protocol message.
+func NewFinderNodeWithMessage(msg *uecho_protocol.Message) (node.Node, error) {
 	// Valdate the specified message
 
 	if msg == nil {
@@ -44,7 +53,7 @@ func NewFinderNodeWithResponseMesssage(msg *uecho.Message) (node.Node, error) {
 		return nil, fmt.Errorf(errorEchonetFinderMessageInvalidObject, msg.SEOJ(), FinderDeviceCode)
 	}
 
-	for _, propCode := range FinderDeviceAllPropertyCodes() {
+	for _, propCode := range FinderDeviceRequiredPropertyCodes() {
 		if !msg.HasProperty(propCode) {
 			return nil, fmt.Errorf(errorEchonetFinderInvalidMessage, msg)
 		}
@@ -68,6 +77,18 @@ func NewFinderNodeWithResponseMesssage(msg *uecho.Message) (node.Node, error) {
 			candidateNode.SetAddress(net.ParseIP(prop.StringData()))
 		case FinderRPCPortCode:
 			candidateNode.SetRPCPort(prop.IntegerData())
+		case FinderLabelsCode:
+			labels, err := node.NewLabelsWithString(prop.StringData())
+			if err != nil {
+				return nil, err
+			}
+			candidateNode.SetLabels(labels)
+		case FinderPortsCode:
+			ports, err := node.NewPortsWithString(prop.StringData())
+			if err != nil {
+				return nil, err
+			}
+			candidateNode.SetPorts(ports)
 		case FinderClockCode:
 			candidateNode.SetClock(node.Clock(prop.IntegerData()))
 		default:
@@ -15,6 +15,7 @@
 package echonet
 
 import (
+	"github.com/cybergarage/go-finder/finder/node"
 	uecho "github.com/cybergarage/uecho-go/net/echonet"
 )
 
@@ -22,7 +23,37 @@ import (
 func NewRequestAllPropertiesMessage() *uecho.Message {
 	msg := uecho.NewMessage()
 	msg.SetESV(uecho.ESVReadRequest)
-	msg.SetSEOJ(FinderDeviceCode)
+	msg.SetDEOJ(FinderDeviceCode)
 	msg.AddProperties(uecho.NewPropertiesWithCodes(FinderDeviceAllPropertyCodes()))
 	return msg
 }
+
+// stoppingNode represents a node which is announced as stopping.
+type stoppingNode struct {
+	node.Node
+}
+
+// Condition returns the stop condition.
+func (stoppingNode *stoppingNode) Condition() node.Condition {
+	return node.ConditionStop
+}
+
+// NewAnnouncementMessageWithNode creates a notification message which has all properties of the specified node.
+func NewAnnouncementMessageWithNode(srcNode node.Node) *uecho.Message {
+	msg := uecho.NewMessage()
+	msg.SetESV(uecho.ESVNotification)
+	msg.SetSEOJ(FinderDeviceCode)
+	for _, propCode := range FinderDeviceAllPropertyCodes() {
+		propData, ok := NewPropertyDataWithNode(srcNode, propCode)
+		if !ok {
+			continue
+		}
+		msg.AddProperty(uecho.NewPropertyWithCode(propCode).SetData(propData))
+	}
+	return msg
+}
+
+// NewStoppingAnnouncementMessageWithNode creates a notification message which announces the specified node is stopping.
+func NewStoppingAnnouncementMessageWithNode(srcNode node.Node) *uecho.Message {
+	return NewAnnouncementMessageWithNode(&stoppingNode{Node: srcNode})
+}
@@ -15,8 +15,12 @@
 package echonet
 
 import (
+	"fmt"
+	"net"
+	"strings"
 	"testing"
 
+	"github.com/cybergarage/go-finder/finder/node"
 	uecho_protocol "github.com/cybergarage/uecho-go/net/echonet/protocol"
 )
 
@@ -28,3 +32,103 @@ func TestNewRequestMessage(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestNewAnnouncementMessage(t *testing.T) {
+	srcNode := node.NewBaseNode().SetCluster("cluster").SetHost("org.cybergarage.finder001").SetAddress(net.ParseIP("192.168.100.1")).SetRPCPort(8080)
+	srcNode.SetLabel("zone", "a").SetLabel("role", "storage")
+	srcNode.SetPort(node.PortCarbon, 2003).SetPort(node.PortRender, 8081)
+	srcNode.SetCondition(node.ConditionReady)
+	srcNode.SetClock(10)
+
+	msgs := []struct {
+		msg  *uecho_protocol.Message
+		cond node.Condition
+	}{
+		{NewAnnouncementMessageWithNode(srcNode).Message, node.ConditionReady},
+		{NewStoppingAnnouncementMessageWithNode(srcNode).Message, node.ConditionStop},
+	}
+
+	for _, m := range msgs {
+		msg, err := uecho_protocol.NewMessageWithBytes(m.msg.Bytes())
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !msg.IsNotification() {
+			t.Errorf("%s is not a notification", msg)
+		}
+
+		announcedNode, err := NewFinderNodeWithMessage(msg)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !node.Equal(srcNode, announcedNode) {
+			t.Errorf("%s != %s", srcNode.Host(), announcedNode.Host())
+		}
+		if announcedNode.Condition() != m.cond {
+			t.Errorf("%s != %s", announcedNode.Condition(), m.cond)
+		}
+		if announcedNode.Clock() != srcNode.Clock() {
+			t.Errorf("%d != %d", announcedNode.Clock(), srcNode.Clock())
+		}
+		if !node.LabelsEqual(announcedNode.Labels(), srcNode.Labels()) {
+			t.Errorf("%s != %s", announcedNode.Labels(), srcNode.Labels())
+		}
+		if !node.PortsEqual(announcedNode.Ports(), srcNode.Ports()) {
+			t.Errorf("%s != %s", announcedNode.Ports(), srcNode.Ports())
+		}
+	}
+}
+
+func TestAnnouncementMessageLabels(t *testing.T) {
+	srcNode := node.NewBaseNode().SetHost("org.cybergarage.finder001").SetAddress(net.ParseIP("192.168.100.1"))
+	for n := 0; n < 32; n++ {
+		srcNode.SetLabel(fmt.Sprintf("label%02d", n), strings.Repeat("v", 8))
+	}
+
+	msg, err := uecho_protocol.NewMessageWithBytes(NewAnnouncementMessageWithNode(srcNode).Bytes())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	announcedNode, err := NewFinderNodeWithMessage(msg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	labels := announcedNode.Labels()
+	if len(labels) == 0 || len(srcNode.Labels()) <= len(labels) {
+		t.Errorf("%d labels are announced", len(labels))
+	}
+	if FinderPropertyDataMaxSize < len(labels.String()) {
+		t.Errorf("%d < %d", FinderPropertyDataMaxSize, len(labels.String()))
+	}
+	for key, value := range labels {
+		if srcValue, ok := srcNode.Label(key); !ok || srcValue != value {
+			t.Errorf("%s : %s != %s", key, value, srcValue)
+		}
+	}
+
+	// The labels property is optional
+
+	noLabelsMsg := uecho_protocol.NewMessage()
+	noLabelsMsg.SetESV(uecho_protocol.ESVNotification)
+	noLabelsMsg.SetSEOJ(FinderDeviceCode)
+	for _, prop := range msg.Properties() {
+		if prop.Code() == FinderLabelsCode {
+			continue
+		}
+		noLabelsMsg.AddProperty(prop)
+	}
+	announcedNode, err = NewFinderNodeWithMessage(noLabelsMsg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(announcedNode.Labels()) != 0 {
+		t.Errorf("%s", announcedNode.Labels())
+	}
+}
@@ -17,8 +17,10 @@ package echonet
 import (
 	"fmt"
 	"reflect"
+	"sync"
 
 	"github.com/cybergarage/go-finder/finder/node"
+	"github.com/cybergarage/go-logger/log"
 	uecho "github.com/cybergarage/uecho-go/net/echonet"
 	uecho_protocol "github.com/cybergarage/uecho-go/net/echonet/protocol"
 )
@@ -27,18 +29,30 @@ const (
 	errorNodeNotRunning = "Node is not running"
 )
 
+// statusHookNode is an interface for source nodes which call the hooks when the condition or the clock is changed.
+type statusHookNode interface {
+	AddConditionHook(hook node.ConditionTransitionHook) *node.BaseNode
+	AddClockHook(hook node.ClockUpdateHook) *node.BaseNode
+}
+
 type EchonetNode struct {
 	*uecho.LocalNode
 	*EchonetDevice
 	node.Node
+	statusMutex   sync.Mutex
+	statusStop    chan struct{}
+	statusDone    chan struct{}
+	statusChanged chan struct{}
 }
 
 // NewEchonetNodeWithNode returns a new finder node.
+// The source node is announced whenever the condition or the clock is changed when the source node calls the hooks such as node.BaseNode.
 func NewEchonetNodeWithNode(srcNode node.Node) (*EchonetNode, error) {
 	node := &EchonetNode{
 		LocalNode:     uecho.NewLocalNode(),
 		EchonetDevice: NewDevice(),
 		Node:          srcNode,
+		statusChanged: make(chan struct{}, 1),
 	}
 
 	node.SetConfig(NewDefaultConfig())
@@ -47,6 +61,8 @@ func NewEchonetNodeWithNode(srcNode node.Node) (*EchonetNode, error) {
 	node.SetListener(node)
 	node.AddDevice(node.EchonetDevice.Device)
 
+	addSourceNodeHooks(node)
+
 	return node, nil
 }
 
@@ -55,6 +71,11 @@ func (node *EchonetNode) Address() string {
 	return node.LocalNode.Address()
 }
 
+// Port returns the Echonet port, use Node.Port to get the named service ports of the source node.
+func (node *EchonetNode) Port() int {
+	return node.LocalNode.Port()
+}
+
 // GetLocalNode returns the local echonet node in the node.
 func (node *EchonetNode) GetLocalNode() *uecho.LocalNode {
 	return node.LocalNode
@@ -92,5 +113,168 @@ func (node *EchonetNode) NodeMessageReceived(msg *uecho_protocol.Message) error
 		return nil
 	}
 
+	node.EchonetDevice.UpdatePropertyWithNode(node.GetSourceNode())
+
 	return nil
 }
+
+// Start starts the node, and announces the source node which is changed to the ready condition through the bootstrap condition.
+func (node *EchonetNode) Start() error {
+	if err := bootstrapSourceNode(node); err != nil {
+		return err
+	}
+
+	err := node.LocalNode.Start()
+	if err != nil {
+		return err
+	}
+
+	if !node.HasSourceNode() {
+		return nil
+	}
+
+	if err := readySourceNode(node); err != nil {
+		return err
+	}
+
+	err = node.Announce()
+	if err != nil {
+		return err
+	}
+
+	node.startStatusWatcher()
+
+	return nil
+}
+
+// Stop changes the source node to the stop condition, announces the source node is stopping, and stops the node.
+func (node *EchonetNode) Stop() error {
+	node.stopStatusWatcher()
+
+	if err := stopSourceNode(node); err != nil {
+		log.Errorf("%s", err.Error())
+	}
+
+	if node.HasSourceNode() && node.IsRunning() {
+		msg := NewStoppingAnnouncementMessageWithNode(node.GetSourceNode())
+		err := node.LocalNode.AnnounceMessage(msg.Message)
+		if err != nil {
+			log.Errorf("%s", err.Error())
+		}
+	}
+
+	return node.LocalNode.Stop()
+}
+
+// Announce updates the device properties with the source node, and announces all properties.
+func (node *EchonetNode) Announce() error {
+	if !node.HasSourceNode() {
+		return nil
+	}
+	node.EchonetDevice.UpdatePropertyWithNode(node.GetSourceNode())
+	msg := NewAnnouncementMessageWithNode(node.GetSourceNode())
+	return node.LocalNode.AnnounceMessage(msg.Message)
+}
+
+// bootstrapSourceNode changes the source node of the specified node to the bootstrap condition.
+func bootstrapSourceNode(echonetNode *EchonetNode) error {
+	if !echonetNode.HasSourceNode() {
+		return nil
+	}
+	return node.Transit(echonetNode.GetSourceNode(), node.ConditionBootstrap)
+}
+
+// readySourceNode changes the source node of the specified node to the ready condition.
+func readySourceNode(echonetNode *EchonetNode) error {
+	if !echonetNode.HasSourceNode() {
+		return nil
+	}
+	return node.Transit(echonetNode.GetSourceNode(), node.ConditionReady)
+}
+
+// stopSourceNode changes the source node of the specified node to the stop condition.
+func stopSourceNode(echonetNode *EchonetNode) error {
+	if !echonetNode.HasSourceNode() {
+		return nil
+	}
+	return node.Transit(echonetNode.GetSourceNode(), node.ConditionStop)
+}
+
+// addSourceNodeHooks adds the hooks which post the status changes of the source node of the specified node.
+func addSourceNodeHooks(echonetNode *EchonetNode) {
+	if !echonetNode.HasSourceNode() {
+		return
+	}
+	hookNode, ok := echonetNode.GetSourceNode().(statusHookNode)
+	if !ok {
+		return
+	}
+	hookNode.AddConditionHook(func(*node.BaseNode, node.Condition, node.Condition) {
+		echonetNode.postStatusChanged()
+	})
+	hookNode.AddClockHook(func(*node.BaseNode, node.Clock, node.Clock) {
+		echonetNode.postStatusChanged()
+	})
+}
+
+// postStatusChanged requests the status watcher to announce the source node without blocking the caller which changed the status.
+func (node *EchonetNode) postStatusChanged() {
+	select {
+	case node.statusChanged <- struct{}{}:
+	default:
+	}
+}
+
+// startStatusWatcher starts announcing the source node whenever the status is changed.
+func (node *EchonetNode) startStatusWatcher() {
+	node.statusMutex.Lock()
+	defer node.statusMutex.Unlock()
+
+	if node.statusStop != nil {
+		return
+	}
+
+	// The status changes before starting are announced by Start
+	select {
+	case <-node.statusChanged:
+	default:
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	node.statusStop = stop
+	node.statusDone = done
+
+	go watchSourceNodeStatus(node, stop, done)
+}
+
+// stopStatusWatcher stops the status watcher and waits until it is terminated.
+func (node *EchonetNode) stopStatusWatcher() {
+	node.statusMutex.Lock()
+	defer node.statusMutex.Unlock()
+
+	if node.statusStop == nil {
+		return
+	}
+	close(node.statusStop)
+	<-node.statusDone
+	node.statusStop = nil
+	node.statusDone = nil
+}
+
+// watchSourceNodeStatus announces the source node of the specified node whenever the status change is posted until the stop channel is closed.
+func watchSourceNodeStatus(echonetNode *EchonetNode, stop chan struct{}, done chan struct{}) {
+	defer close(done)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-echonetNode.statusChanged:
+		}
+
+		if err := echonetNode.Announce(); err != nil {
+			log.Errorf("%s", err.Error())
+		}
+	}
+}
@@ -15,7 +15,11 @@
 package echonet
 
 import (
+	"net"
 	"testing"
+
+	"github.com/cybergarage/go-finder/finder/node"
+	uecho "github.com/cybergarage/uecho-go/net/echonet"
 )
 
 func TestNode(t *testing.T) {
@@ -24,3 +28,153 @@ func TestNode(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestNodeRoundTrip(t *testing.T) {
+	srcNode := node.NewBaseNode().SetCluster("cluster").SetHost("org.cybergarage.finder001").SetAddress(net.ParseIP("192.168.100.1")).SetRPCPort(8080)
+	srcNode.SetCondition(node.ConditionReady)
+	srcNode.SetClock(10)
+
+	// Node process
+
+	echonetNode, err := NewEchonetNodeWithNode(srcNode)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = echonetNode.Start()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer echonetNode.Stop()
+
+	// Controller process
+
+	ctrl := NewController()
+	err = ctrl.Start()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer ctrl.Stop()
+
+	remoteNode := uecho.NewRemoteNode()
+	remoteNode.SetAddress(echonetNode.Address())
+	remoteNode.SetPort(echonetNode.Port())
+
+	roundTripTest := func() {
+		resMsg, err := ctrl.PostMessage(remoteNode, NewRequestAllPropertiesMessage())
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		foundNode, err := NewFinderNodeWithResponseMesssage(resMsg)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if foundNode.Cluster() != srcNode.Cluster() {
+			t.Errorf("%s != %s", foundNode.Cluster(), srcNode.Cluster())
+		}
+		if foundNode.Host() != srcNode.Host() {
+			t.Errorf("%s != %s", foundNode.Host(), srcNode.Host())
+		}
+		if !foundNode.Address().Equal(srcNode.Address()) {
+			t.Errorf("%s != %s", foundNode.Address(), srcNode.Address())
+		}
+		if foundNode.RPCPort() != srcNode.RPCPort() {
+			t.Errorf("%d != %d", foundNode.RPCPort(), srcNode.RPCPort())
+		}
+		if foundNode.Condition() != srcNode.Condition() {
+			t.Errorf("%s != %s", foundNode.Condition(), srcNode.Condition())
+		}
+		if foundNode.Clock() != srcNode.Clock() {
+			t.Errorf("%d != %d", foundNode.Clock(), srcNode.Clock())
+		}
+	}
+
+	roundTripTest()
+
+	// The updated source node is responded
+
+	srcNode.SetCondition(node.ConditionBootstrap)
+	srcNode.UpdateClock()
+
+	roundTripTest()
+}
+
+func TestNodeLifecycle(t *testing.T) {
+	srcNode := node.NewBaseNode().SetCluster("cluster").SetHost("org.cybergarage.finder001").SetAddress(net.ParseIP("192.168.100.1")).SetRPCPort(8080)
+	conds := []node.Condition{}
+	srcNode.AddConditionHook(func(hookNode *node.BaseNode, from node.Condition, to node.Condition) {
+		conds = append(conds, to)
+	})
+
+	echonetNode, err := NewEchonetNodeWithNode(srcNode)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// The source node is ready through the bootstrap condition when the node is started
+
+	if err := echonetNode.Start(); err != nil {
+		t.Error(err)
+		return
+	}
+	if srcNode.Condition() != node.ConditionReady {
+		t.Errorf("%s != %s", srcNode.Condition(), node.ConditionReady)
+	}
+
+	// The source node is stopped when the node is stopped
+
+	if err := echonetNode.Stop(); err != nil {
+		t.Error(err)
+	}
+	expected := []node.Condition{node.ConditionBootstrap, node.ConditionReady, node.ConditionStop}
+	if len(conds) != len(expected) {
+		t.Errorf("%v != %v", conds, expected)
+		return
+	}
+	for n, cond := range expected {
+		if conds[n] != cond {
+			t.Errorf("%s != %s", conds[n], cond)
+		}
+	}
+}
+
+func TestNodeStatusHooks(t *testing.T) {
+	srcNode := node.NewBaseNode().SetCluster("cluster").SetHost("org.cybergarage.finder001").SetAddress(net.ParseIP("192.168.100.1")).SetRPCPort(8080)
+	echonetNode, err := NewEchonetNodeWithNode(srcNode)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// The clock and condition changes are posted to the status watcher without polling
+
+	changes := []func(){
+		srcNode.UpdateClock,
+		func() { srcNode.SetClock(10) },
+		func() { srcNode.Transition(node.ConditionBootstrap) },
+	}
+	for n, change := range changes {
+		change()
+		select {
+		case <-echonetNode.statusChanged:
+		default:
+			t.Errorf("change (%d) is not posted", n)
+		}
+	}
+
+	// The same clock is not posted
+
+	srcNode.SetClock(10)
+	select {
+	case <-echonetNode.statusChanged:
+		t.Errorf("the same clock is posted")
+	default:
+	}
+}
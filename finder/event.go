@@ -0,0 +1,171 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cybergarage/go-finder/finder/node"
+)
+
+const (
+	eventWatcherBufferSize = 64
+)
+
+// EventType represents a membership change type.
+type EventType int
+
+const (
+	NodeAdded EventType = iota + 1
+	NodeUpdated
+	NodeRemoved
+)
+
+// String returns the event type name.
+func (t EventType) String() string {
+	switch t {
+	case NodeAdded:
+		return "added"
+	case NodeUpdated:
+		return "updated"
+	case NodeRemoved:
+		return "removed"
+	}
+	return fmt.Sprintf("unknown(%d)", int(t))
+}
+
+// Event represents a membership change of a finder.
+type Event struct {
+	// Type is the membership change type.
+	Type EventType
+	// Node is the added, updated or removed node.
+	Node Node
+	// OldStatus is the previous status of the node, and nil unless the type is NodeUpdated.
+	OldStatus node.Status
+	// NewStatus is the current status of the node, and nil unless the type is NodeUpdated.
+	NewStatus node.Status
+}
+
+// newNodeAddedEvent returns a new added event of the specified node.
+func newNodeAddedEvent(node Node) *Event {
+	return &Event{Type: NodeAdded, Node: node}
+}
+
+// newNodeUpdatedEvent returns a new updated event of the specified node.
+func newNodeUpdatedEvent(node Node, oldStatus node.Status, newStatus node.Status) *Event {
+	return &Event{Type: NodeUpdated, Node: node, OldStatus: oldStatus, NewStatus: newStatus}
+}
+
+// newNodeRemovedEvent returns a new removed event of the specified node.
+func newNodeRemovedEvent(node Node) *Event {
+	return &Event{Type: NodeRemoved, Node: node}
+}
+
+// String returns the description.
+func (e *Event) String() string {
+	return fmt.Sprintf("%s:%s", e.Type, e.Node.Host())
+}
+
+// FinderEventListener a listener for membership changes of Finder.
+// The events are received one at a time in the order of the membership changes, and the events of
+// the changes made by the listener are received after the listener returns.
+type FinderEventListener interface {
+	FinderEventReceived(*Event)
+}
+
+// eventWatcher represents a channel based event listener for Watch.
+// The watcher queues the received events without blocking the finder, and sends them to the channel in the received order by its own goroutine.
+type eventWatcher struct {
+	mutex  sync.Mutex
+	ctx    context.Context
+	ch     chan Event
+	events []Event
+	queued chan struct{}
+	closed bool
+}
+
+// newEventWatcher returns a new event watcher bound to the specified context.
+func newEventWatcher(ctx context.Context) *eventWatcher {
+	return &eventWatcher{
+		ctx:    ctx,
+		ch:     make(chan Event, eventWatcherBufferSize),
+		events: make([]Event, 0),
+		queued: make(chan struct{}, 1),
+		closed: false,
+	}
+}
+
+// FinderEventReceived queues the specified event without blocking.
+func (watcher *eventWatcher) FinderEventReceived(e *Event) {
+	watcher.mutex.Lock()
+	if watcher.closed {
+		watcher.mutex.Unlock()
+		return
+	}
+	watcher.events = append(watcher.events, *e)
+	watcher.mutex.Unlock()
+
+	select {
+	case watcher.queued <- struct{}{}:
+	default:
+	}
+}
+
+// nextEvent returns the first queued event, and false when no events are queued.
+func (watcher *eventWatcher) nextEvent() (Event, bool) {
+	watcher.mutex.Lock()
+	defer watcher.mutex.Unlock()
+	if len(watcher.events) == 0 {
+		return Event{}, false
+	}
+	e := watcher.events[0]
+	watcher.events = watcher.events[1:]
+	return e, true
+}
+
+// run sends the queued events to the channel until the context is done, and closes the channel.
+func (watcher *eventWatcher) run() {
+	defer watcher.close()
+	for {
+		e, ok := watcher.nextEvent()
+		if !ok {
+			select {
+			case <-watcher.queued:
+				continue
+			case <-watcher.ctx.Done():
+				return
+			}
+		}
+		select {
+		case watcher.ch <- e:
+		case <-watcher.ctx.Done():
+			return
+		}
+	}
+}
+
+// close discards the queued events and closes the channel.
+func (watcher *eventWatcher) close() {
+	watcher.mutex.Lock()
+	defer watcher.mutex.Unlock()
+	if watcher.closed {
+		return
+	}
+	watcher.closed = true
+	watcher.events = nil
+	close(watcher.ch)
+}
@@ -0,0 +1,312 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-finder/finder/node"
+)
+
+type testEventListener struct {
+	sync.Mutex
+	events []*Event
+}
+
+func (l *testEventListener) FinderEventReceived(e *Event) {
+	l.Lock()
+	defer l.Unlock()
+	l.events = append(l.events, e)
+}
+
+func (l *testEventListener) Events() []*Event {
+	l.Lock()
+	defer l.Unlock()
+	return l.events
+}
+
+type testNodeUpdater interface {
+	testNodeMutator
+	updateNode(Node) bool
+}
+
+func finderEventTest(t *testing.T, finder Finder) {
+	t.Helper()
+
+	updater, ok := finder.(testNodeUpdater)
+	if !ok {
+		t.Errorf("%s : not mutable", finder)
+		return
+	}
+
+	listener := &testEventListener{}
+	if err := finder.AddEventListener(listener); err != nil {
+		t.Error(err)
+		return
+	}
+	defer finder.RemoveEventListener(listener)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	eventCh := finder.Watch(ctx)
+
+	testNode := node.NewBaseNode().SetHost("org.cybergarage.event").SetAddress(net.ParseIP("192.168.100.1"))
+	testNode.SetCondition(node.ConditionBootstrap)
+
+	if err := updater.addNode(testNode); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// No event without status changes
+
+	sameNode := node.NewBaseNode().SetHost("org.cybergarage.event").SetAddress(net.ParseIP("192.168.100.1"))
+	sameNode.SetCondition(node.ConditionBootstrap)
+	if !updater.updateNode(sameNode) {
+		t.Errorf(testFinderMatchingError, testNode.Host(), "")
+	}
+
+	updatedNode := node.NewBaseNode().SetHost("org.cybergarage.event").SetAddress(net.ParseIP("192.168.100.1"))
+	updatedNode.SetCondition(node.ConditionReady)
+	updatedNode.SetClock(1)
+	if !updater.updateNode(updatedNode) {
+		t.Errorf(testFinderMatchingError, testNode.Host(), "")
+	}
+
+	if err := finder.RemoveNode(testNode); err != nil {
+		t.Error(err)
+		return
+	}
+
+	expectedTypes := []EventType{NodeAdded, NodeUpdated, NodeRemoved}
+
+	events := listener.Events()
+	if len(events) != len(expectedTypes) {
+		t.Errorf(testFinderNodeCountError, len(events), len(expectedTypes))
+		return
+	}
+
+	for n, e := range events {
+		if e.Type != expectedTypes[n] {
+			t.Errorf(testFinderMatchingError, expectedTypes[n], e.Type)
+		}
+		if !node.Equal(e.Node, testNode) {
+			t.Errorf(testFinderMatchingError, testNode.Host(), e.Node.Host())
+		}
+	}
+
+	updatedEvent := events[1]
+	if updatedEvent.OldStatus.Condition() != node.ConditionBootstrap || updatedEvent.NewStatus.Condition() != node.ConditionReady {
+		t.Errorf("%s -> %s", updatedEvent.OldStatus.Condition(), updatedEvent.NewStatus.Condition())
+	}
+	if updatedEvent.NewStatus.Clock() != 1 {
+		t.Errorf("%d != %d", updatedEvent.NewStatus.Clock(), 1)
+	}
+
+	for _, expectedType := range expectedTypes {
+		select {
+		case e := <-eventCh:
+			if e.Type != expectedType {
+				t.Errorf(testFinderMatchingError, expectedType, e.Type)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("%s event is not received", expectedType)
+			return
+		}
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-eventCh:
+		if ok {
+			t.Errorf("Watch channel is not closed")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Watch channel is not closed")
+	}
+}
+
+func TestFinderEvents(t *testing.T) {
+	finders := []Finder{
+		NewStaticFinderWithNodes(nil),
+		NewSharedFinder(),
+		NewEchonetFinder(),
+	}
+
+	for _, finder := range finders {
+		t.Run(finder.String(), func(t *testing.T) {
+			finderEventTest(t, finder)
+		})
+	}
+}
+
+func TestFinderExpiredEvent(t *testing.T) {
+	finder := newBaseFinder()
+
+	listener := &testEventListener{}
+	finder.AddEventListener(listener)
+
+	testNode := node.NewBaseNode().SetHost("org.cybergarage.event").SetAddress(net.ParseIP("192.168.100.1"))
+	if err := finder.addNode(testNode); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := finder.SetNodeTTL(time.Second); err != nil {
+		t.Error(err)
+		return
+	}
+	finder.sweepNodes(time.Now().Add(time.Minute))
+
+	events := listener.Events()
+	if len(events) != 2 {
+		t.Errorf(testFinderNodeCountError, len(events), 2)
+		return
+	}
+	if events[1].Type != NodeRemoved {
+		t.Errorf(testFinderMatchingError, NodeRemoved, events[1].Type)
+	}
+
+	if err := finder.RemoveEventListener(listener); err != nil {
+		t.Error(err)
+	}
+	if err := finder.RemoveEventListener(listener); err == nil {
+		t.Errorf("Removed listener is removed again")
+	}
+}
+
+func TestFinderEventOrder(t *testing.T) {
+	finder := newBaseFinder()
+
+	listener := &testEventListener{}
+	finder.AddEventListener(listener)
+
+	testNode := node.NewBaseNode().SetHost("org.cybergarage.event").SetAddress(net.ParseIP("192.168.100.1"))
+	if err := finder.addNode(testNode); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// The events of the concurrent changes are posted in the order of the changes
+
+	var wg sync.WaitGroup
+	for n := 1; n <= 100; n++ {
+		wg.Add(1)
+		go func(clock node.Clock) {
+			defer wg.Done()
+			updatedNode := node.NewBaseNode().SetHost(testNode.Host()).SetAddress(testNode.Address())
+			updatedNode.SetClock(clock)
+			finder.updateNode(updatedNode)
+		}(node.Clock(n))
+	}
+	wg.Wait()
+
+	events := listener.Events()
+	if len(events) < 2 {
+		t.Errorf(testFinderNodeCountError, len(events), 2)
+		return
+	}
+	for n := 2; n < len(events); n++ {
+		if events[n].OldStatus.Clock() != events[n-1].NewStatus.Clock() {
+			t.Errorf("%d != %d", events[n].OldStatus.Clock(), events[n-1].NewStatus.Clock())
+		}
+	}
+}
+
+type testRemovingEventListener struct {
+	testEventListener
+	finder *baseFinder
+}
+
+func (l *testRemovingEventListener) FinderEventReceived(e *Event) {
+	l.testEventListener.FinderEventReceived(e)
+	if e.Type == NodeAdded {
+		l.finder.RemoveNode(e.Node)
+	}
+}
+
+func TestFinderEventListenerChanges(t *testing.T) {
+	finder := newBaseFinder()
+
+	listener := &testRemovingEventListener{finder: finder}
+	finder.AddEventListener(listener)
+
+	// The events of the changes made by the listener are posted after the listener returns
+
+	testNode := node.NewBaseNode().SetHost("org.cybergarage.event").SetAddress(net.ParseIP("192.168.100.1"))
+	if err := finder.addNode(testNode); err != nil {
+		t.Error(err)
+		return
+	}
+
+	expectedTypes := []EventType{NodeAdded, NodeRemoved}
+	events := listener.Events()
+	if len(events) != len(expectedTypes) {
+		t.Errorf(testFinderNodeCountError, len(events), len(expectedTypes))
+		return
+	}
+	for n, e := range events {
+		if e.Type != expectedTypes[n] {
+			t.Errorf(testFinderMatchingError, expectedTypes[n], e.Type)
+		}
+	}
+}
+
+func TestFinderWatchSlowReceiver(t *testing.T) {
+	finder := newBaseFinder()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	eventCh := finder.Watch(ctx)
+
+	// The finder is not blocked by the watcher which receives no events
+
+	nodeCount := eventWatcherBufferSize * 2
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for n := 0; n < nodeCount; n++ {
+			testNode := node.NewBaseNode().SetHost(fmt.Sprintf("org.cybergarage.event%03d", n)).SetAddress(net.ParseIP(fmt.Sprintf("192.168.100.%d", n)))
+			if err := finder.addNode(testNode); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("Finder is blocked by the watcher")
+		return
+	}
+
+	for n := 0; n < nodeCount; n++ {
+		select {
+		case e := <-eventCh:
+			host := fmt.Sprintf("org.cybergarage.event%03d", n)
+			if e.Node.Host() != host {
+				t.Errorf(testFinderMatchingError, host, e.Node.Host())
+			}
+		case <-time.After(time.Second):
+			t.Errorf("%d event is not received", n)
+			return
+		}
+	}
+}
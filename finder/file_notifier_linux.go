@@ -0,0 +1,97 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+
+package finder
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	fileNotifierEventMask = syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+)
+
+// fileNotifier represents a file notifier using inotify.
+// The parent directory is watched to follow editors which replace the file by renaming.
+type fileNotifier struct {
+	file   *os.File
+	name   string
+	events chan struct{}
+}
+
+// newFileNotifier returns a new notifier of the specified file.
+func newFileNotifier(filename string) (*fileNotifier, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	_, err = syscall.InotifyAddWatch(fd, filepath.Dir(filename), fileNotifierEventMask)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+
+	notifier := &fileNotifier{
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		name:   filepath.Base(filename),
+		events: make(chan struct{}, 1),
+	}
+	go notifier.run()
+
+	return notifier, nil
+}
+
+// run reads the inotify events until the notifier is closed.
+func (notifier *fileNotifier) run() {
+	defer close(notifier.events)
+	buf := make([]byte, (syscall.SizeofInotifyEvent+syscall.NAME_MAX+1)*16)
+	for {
+		n, err := notifier.file.Read(buf)
+		if err != nil {
+			return
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameOffset := offset + syscall.SizeofInotifyEvent
+			offset = nameOffset + int(event.Len)
+			if n < offset {
+				break
+			}
+			name := string(bytes.TrimRight(buf[nameOffset:offset], "\x00"))
+			if name != notifier.name {
+				continue
+			}
+			select {
+			case notifier.events <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// Events returns the channel which is notified when the file is changed.
+func (notifier *fileNotifier) Events() <-chan struct{} {
+	return notifier.events
+}
+
+// Close stops the notifier.
+func (notifier *fileNotifier) Close() error {
+	return notifier.file.Close()
+}
@@ -0,0 +1,40 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+
+package finder
+
+import (
+	"errors"
+)
+
+// fileNotifier represents a file notifier which is not supported on this platform.
+type fileNotifier struct {
+}
+
+// newFileNotifier returns an error because the file notification is not supported on this platform.
+func newFileNotifier(filename string) (*fileNotifier, error) {
+	return nil, errors.New(errorFileNotifierNotSupported)
+}
+
+// Events returns a nil channel.
+func (notifier *fileNotifier) Events() <-chan struct{} {
+	return nil
+}
+
+// Close does nothing.
+func (notifier *fileNotifier) Close() error {
+	return nil
+}
@@ -0,0 +1,117 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/cybergarage/go-logger/log"
+)
+
+const (
+	errorFileNotifierNotSupported = "File notification is not supported"
+	msgFileNotifierUnavailable    = "File notification is unavailable, polling %s : %s"
+)
+
+// fileStamp represents the modification time and size of a file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+// newFileStamp returns the current stamp of the specified file.
+func newFileStamp(filename string) fileStamp {
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: fi.ModTime(), size: fi.Size(), exists: true}
+}
+
+// fileWatcher represents a watcher which calls the handler when the specified file is changed.
+// The watcher uses the file notification of the platform if available, and always polls the file with the interval as a fallback.
+type fileWatcher struct {
+	filename string
+	interval time.Duration
+	handler  func()
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// startFileWatcher starts watching the specified file, and calls the handler when the file is changed.
+func startFileWatcher(filename string, interval time.Duration, handler func()) *fileWatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	watcher := &fileWatcher{
+		filename: filename,
+		interval: interval,
+		handler:  handler,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+
+	var notifications <-chan struct{}
+	notifier, err := newFileNotifier(filename)
+	if err != nil {
+		log.Infof(msgFileNotifierUnavailable, filename, err.Error())
+	} else {
+		notifications = notifier.Events()
+	}
+
+	go func() {
+		defer close(watcher.done)
+		if notifier != nil {
+			defer notifier.Close()
+		}
+		watcher.run(ctx, notifications)
+	}()
+
+	return watcher
+}
+
+// run calls the handler when the file is notified or the polled stamp is changed until the specified context is done.
+func (watcher *fileWatcher) run(ctx context.Context, notifications <-chan struct{}) {
+	stamp := newFileStamp(watcher.filename)
+	ticker := time.NewTicker(watcher.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-notifications:
+			if !ok {
+				notifications = nil
+				continue
+			}
+			stamp = newFileStamp(watcher.filename)
+			watcher.handler()
+		case <-ticker.C:
+			newStamp := newFileStamp(watcher.filename)
+			if newStamp == stamp {
+				continue
+			}
+			stamp = newStamp
+			watcher.handler()
+		}
+	}
+}
+
+// stop stops the watcher, and waits until the watching goroutine is finished.
+func (watcher *fileWatcher) stop() {
+	watcher.cancel()
+	<-watcher.done
+}
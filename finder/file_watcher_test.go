@@ -0,0 +1,56 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileWatcherPolling(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "finder.conf")
+	writeTestTOMLConfig(t, filename, "")
+
+	changed := make(chan struct{}, 1)
+	watcher := &fileWatcher{
+		filename: filename,
+		interval: 10 * time.Millisecond,
+		handler: func() {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		},
+		done: make(chan struct{}),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	watcher.cancel = cancel
+	go func() {
+		defer close(watcher.done)
+		watcher.run(ctx, nil)
+	}()
+	defer watcher.stop()
+
+	time.Sleep(50 * time.Millisecond)
+	writeTestTOMLConfig(t, filename, "[finder]")
+
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Errorf("%s : change not detected", filename)
+	}
+}
@@ -15,7 +15,9 @@
 package finder
 
 import (
+	"context"
 	"regexp"
+	"time"
 )
 
 // FinderSearchListener a listener for Finder.
@@ -32,18 +34,47 @@ type FinderNotifyListener interface {
 type Finder interface {
 	// SearchAll searches all nodes.
 	Search() error
+	// SearchContext searches nodes with the specified options until the context is done, and returns the responding nodes.
+	SearchContext(ctx context.Context, opts *SearchOptions) ([]Node, error)
 	// SetSearchListener sets a specified listener.
 	SetSearchListener(FinderSearchListener) error
 	// SetNotifyListener sets a specified listener.
 	SetNotifyListener(FinderNotifyListener) error
+	// AddEventListener adds a specified listener of membership changes.
+	// The listeners receive the events one at a time in the order of the membership changes.
+	AddEventListener(FinderEventListener) error
+	// RemoveEventListener removes a specified listener of membership changes.
+	RemoveEventListener(FinderEventListener) error
+	// Watch returns a channel of membership changes until the specified context is done.
+	// The events are sent in the order of the membership changes, and the slow receivers never block the finder.
+	Watch(ctx context.Context) <-chan Event
 	// GetAllNodes returns all found nodes.
 	GetAllNodes() ([]Node, error)
+	// GetClusterNodes returns only nodes of the specified cluster.
+	GetClusterNodes(cluster string) ([]Node, error)
+	// GetClusters returns the member counts of the found clusters by the cluster names.
+	GetClusters() (map[string]int, error)
 	// GetPrefixNodes returns only nodes matching with a specified start string.
 	GetPrefixNodes(string) ([]Node, error)
 	// GetRegexpNodes returns only nodes matching with a specified regular expression.
 	GetRegexpNodes(*regexp.Regexp) ([]Node, error)
+	// GetSelectorNodes returns only nodes whose labels match with a specified label selector such as "role=storage,zone!=a".
+	GetSelectorNodes(selector string) ([]Node, error)
+	// ExpandGraphiteTarget returns the specified Graphite target rewritten for each node which owns the matching series.
+	ExpandGraphiteTarget(target string) (map[Node]string, error)
 	// GetNeighborhoodNode returns a neighborhood node of the specified node.
 	GetNeighborhoodNode(node Node) (Node, error)
+	// GetNeighborhoodNodes returns the specified number of neighborhood nodes of the specified node.
+	GetNeighborhoodNodes(node Node, n int) ([]Node, error)
+	// GetNodeForKey returns the owner node of the specified key.
+	GetNodeForKey(key string) (Node, error)
+	// GetNodesForKey returns the specified number of owner nodes of the specified key.
+	GetNodesForKey(key string, replicas int) ([]Node, error)
+	// RemoveNode removes the specified node.
+	RemoveNode(node Node) error
+	// SetNodeTTL sets the time-to-live of found nodes, zero disables the expiry.
+	// The TTL is not applied to the nodes of the static and shared finders which are never refreshed.
+	SetNodeTTL(ttl time.Duration) error
 	// Start starts the finder.
 	Start() error
 	// Stop stops the finder.
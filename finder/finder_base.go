@@ -15,87 +15,722 @@
 package finder
 
 import (
+	"context"
 	"fmt"
-	"math/rand"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/cybergarage/go-finder/finder/node"
+	"github.com/cybergarage/go-logger/log"
 )
 
 const (
-	errorFinderHasNoNodes  = "Finder hasnt' find any nodes"
-	errorFinderHasSameNode = "Node (%s) is already added"
+	errorFinderHasNoNodes       = "Finder hasnt' find any nodes"
+	errorFinderHasSameNode      = "Node (%s) is already added"
+	errorFinderNodeNotFound     = "Node (%s) is not found"
+	errorFinderInvalidNodeTTL   = "Invalid node TTL : %s"
+	errorFinderInvalidNodeCount = "Invalid node count : %d"
+	errorFinderListenerNotFound = "Listener (%v) is not found"
+	errorFinderNodeOutOfCluster = "Node (%s) is not a member of cluster (%s)"
+	msgFinderNodeExpired        = "Node (%s:%s) is expired"
+	msgFinderEventPosted        = "Event (%s) is posted"
 )
 
+const (
+	finderNodeSweepDivisor = 2
+)
+
+// DefaultFinderConditions returns the node conditions which the finders return by the queries by default, and it is empty
+// so that all finders return the nodes of all conditions because the static and shared nodes have no lifecycle.
+// Use SetConditionFilter with node.ConditionReady to return only the ready nodes.
+func DefaultFinderConditions() []node.Condition {
+	return []node.Condition{}
+}
+
+// foundNode represents a found node with the last seen time.
+type foundNode struct {
+	Node
+	key      string
+	lastSeen time.Time
+}
+
 // baseFinder represents a base finder.
 type baseFinder struct {
-	nodes          []Node
+	mutex          sync.RWMutex
+	nodes          []*foundNode
+	ring           *hashRing
+	nodeTTL        time.Duration
+	sweeperStop    chan struct{}
 	searchListener FinderSearchListener
 	notifyListener FinderNotifyListener
+	eventListeners []FinderEventListener
+	// events is the queued events which are posted in the order of the membership changes.
+	events          []*Event
+	isPostingEvents bool
+	collectors      []*searchCollector
+	// clusterFilter is the only cluster of the accepted nodes when hasClusterFilter is true.
+	clusterFilter    string
+	hasClusterFilter bool
+	// conditions is the node conditions which the queries return, and all conditions are returned when it is empty.
+	conditions []node.Condition
 }
 
 // newBaseFinder returns a new base finder.
 func newBaseFinder() *baseFinder {
 	finder := &baseFinder{
-		nodes:          make([]Node, 0),
+		nodes:          make([]*foundNode, 0),
+		ring:           newHashRing(),
+		nodeTTL:        0,
+		sweeperStop:    nil,
 		searchListener: nil,
 		notifyListener: nil,
+		eventListeners: make([]FinderEventListener, 0),
+		events:         make([]*Event, 0),
+		collectors:     make([]*searchCollector, 0),
+		conditions:     DefaultFinderConditions(),
 	}
 	return finder
 }
 
 // SetSearchListener sets the search listener.
 func (finder *baseFinder) SetSearchListener(l FinderSearchListener) error {
+	finder.mutex.Lock()
+	defer finder.mutex.Unlock()
 	finder.searchListener = l
 	return nil
 }
 
 // SetSearchListener sets the search listener.
 func (finder *baseFinder) SetNotifyListener(l FinderNotifyListener) error {
+	finder.mutex.Lock()
+	defer finder.mutex.Unlock()
 	finder.notifyListener = l
 	return nil
 }
 
+// AddEventListener adds the specified listener of membership changes.
+func (finder *baseFinder) AddEventListener(l FinderEventListener) error {
+	finder.mutex.Lock()
+	defer finder.mutex.Unlock()
+	finder.eventListeners = append(finder.eventListeners, l)
+	return nil
+}
+
+// RemoveEventListener removes the specified listener of membership changes.
+func (finder *baseFinder) RemoveEventListener(l FinderEventListener) error {
+	finder.mutex.Lock()
+	defer finder.mutex.Unlock()
+	for n, listener := range finder.eventListeners {
+		if listener != l {
+			continue
+		}
+		finder.eventListeners = append(finder.eventListeners[:n:n], finder.eventListeners[n+1:]...)
+		return nil
+	}
+	return fmt.Errorf(errorFinderListenerNotFound, l)
+}
+
+// Watch returns a channel of membership changes which is closed when the specified context is done.
+func (finder *baseFinder) Watch(ctx context.Context) <-chan Event {
+	watcher := newEventWatcher(ctx)
+	finder.AddEventListener(watcher)
+	go func() {
+		watcher.run()
+		finder.RemoveEventListener(watcher)
+	}()
+	return watcher.ch
+}
+
+// queueEvent queues the specified event to be posted by postEvents.
+// The caller must hold the mutex while changing the membership so that the events are queued in the order of the changes.
+func (finder *baseFinder) queueEvent(e *Event) {
+	finder.events = append(finder.events, e)
+}
+
+// postEvents posts the queued events to all event listeners in the queued order.
+// The events are posted by only one goroutine at a time, and the events which are queued while another goroutine is posting,
+// including the events queued by the listeners, are posted by the posting goroutine after the current event.
+func (finder *baseFinder) postEvents() {
+	finder.mutex.Lock()
+	if finder.isPostingEvents {
+		finder.mutex.Unlock()
+		return
+	}
+	finder.isPostingEvents = true
+	for 0 < len(finder.events) {
+		e := finder.events[0]
+		finder.events = finder.events[1:]
+		listeners := make([]FinderEventListener, len(finder.eventListeners))
+		copy(listeners, finder.eventListeners)
+		finder.mutex.Unlock()
+
+		log.Tracef(msgFinderEventPosted, e)
+
+		for _, listener := range listeners {
+			listener.FinderEventReceived(e)
+		}
+
+		finder.mutex.Lock()
+	}
+	finder.isPostingEvents = false
+	finder.mutex.Unlock()
+}
+
+// postNotification posts the specified announced node to the notify listener.
+func (finder *baseFinder) postNotification(node Node) {
+	finder.mutex.RLock()
+	listener := finder.notifyListener
+	finder.mutex.RUnlock()
+
+	if listener == nil {
+		return
+	}
+	listener.FinderNotifyReceived(&node)
+}
+
+// startSearchCollector starts collecting responding nodes with the specified options.
+func (finder *baseFinder) startSearchCollector(opts *SearchOptions) *searchCollector {
+	collector := newSearchCollector(opts)
+	finder.mutex.Lock()
+	defer finder.mutex.Unlock()
+	finder.collectors = append(finder.collectors, collector)
+	return collector
+}
+
+// stopSearchCollector stops collecting responding nodes of the specified collector.
+func (finder *baseFinder) stopSearchCollector(collector *searchCollector) {
+	finder.mutex.Lock()
+	defer finder.mutex.Unlock()
+	for n, c := range finder.collectors {
+		if c != collector {
+			continue
+		}
+		finder.collectors = append(finder.collectors[:n:n], finder.collectors[n+1:]...)
+		return
+	}
+}
+
+// postSearchResponse posts the specified responding node to the running searches and the search listener.
+func (finder *baseFinder) postSearchResponse(node Node) {
+	finder.mutex.RLock()
+	listener := finder.searchListener
+	collectors := make([]*searchCollector, len(finder.collectors))
+	copy(collectors, finder.collectors)
+	finder.mutex.RUnlock()
+
+	for _, collector := range collectors {
+		collector.add(node)
+	}
+
+	if listener == nil {
+		return
+	}
+	listener.FinderSearchResponseReceived(&node)
+}
+
+// findNodeIndex returns the index of the specified node, or -1 if not found.
+// The caller must hold the mutex.
+func (finder *baseFinder) findNodeIndex(targetNode Node) int {
+	for n, addedNode := range finder.nodes {
+		if node.Equal(targetNode, addedNode.Node) {
+			return n
+		}
+	}
+	return -1
+}
+
 // HasNode returns true when the specified node is added already, otherwise false.
 func (finder *baseFinder) HasNode(targetNode Node) bool {
-	for _, addedNode := range finder.nodes {
-		if node.Equal(targetNode, addedNode) {
+	finder.mutex.RLock()
+	defer finder.mutex.RUnlock()
+	return 0 <= finder.findNodeIndex(targetNode)
+}
+
+// SetClusterFilter accepts only nodes of the specified cluster, and removes the added nodes of the other clusters.
+func (finder *baseFinder) SetClusterFilter(cluster string) {
+	finder.mutex.Lock()
+	finder.clusterFilter = cluster
+	finder.hasClusterFilter = true
+	finder.mutex.Unlock()
+
+	finder.setNodes(finder.allNodes())
+}
+
+// ClearClusterFilter accepts nodes of all clusters.
+func (finder *baseFinder) ClearClusterFilter() {
+	finder.mutex.Lock()
+	defer finder.mutex.Unlock()
+	finder.clusterFilter = ""
+	finder.hasClusterFilter = false
+}
+
+// ClusterFilter returns the only cluster of the accepted nodes, and false when nodes of all clusters are accepted.
+func (finder *baseFinder) ClusterFilter() (string, bool) {
+	finder.mutex.RLock()
+	defer finder.mutex.RUnlock()
+	return finder.clusterFilter, finder.hasClusterFilter
+}
+
+// IsClusterMember returns true when the specified node is accepted by the cluster filter, otherwise false.
+func (finder *baseFinder) IsClusterMember(node Node) bool {
+	finder.mutex.RLock()
+	defer finder.mutex.RUnlock()
+	return finder.isClusterMember(node)
+}
+
+// isClusterMember returns true when the specified node is accepted by the cluster filter, otherwise false.
+// The caller must hold the mutex.
+func (finder *baseFinder) isClusterMember(node Node) bool {
+	return !finder.hasClusterFilter || node.Cluster() == finder.clusterFilter
+}
+
+// SetConditionFilter sets the node conditions which the queries return, and no conditions means all conditions.
+// The nodes of the other conditions are kept and their membership changes are posted, but they are not returned by the queries.
+func (finder *baseFinder) SetConditionFilter(conds ...node.Condition) {
+	finder.mutex.Lock()
+	defer finder.mutex.Unlock()
+	finder.conditions = make([]node.Condition, len(conds))
+	copy(finder.conditions, conds)
+	for _, foundNode := range finder.nodes {
+		finder.updateRingNode(foundNode)
+	}
+}
+
+// ConditionFilter returns the node conditions which the queries return, and an empty list means all conditions.
+func (finder *baseFinder) ConditionFilter() []node.Condition {
+	finder.mutex.RLock()
+	defer finder.mutex.RUnlock()
+	conds := make([]node.Condition, len(finder.conditions))
+	copy(conds, finder.conditions)
+	return conds
+}
+
+// IsQueryableNode returns true when the specified node is returned by the queries, otherwise false.
+func (finder *baseFinder) IsQueryableNode(node Node) bool {
+	finder.mutex.RLock()
+	defer finder.mutex.RUnlock()
+	return finder.isQueryableNode(node)
+}
+
+// isQueryableNode returns true when the condition of the specified node is accepted by the condition filter, otherwise false.
+// The caller must hold the mutex.
+func (finder *baseFinder) isQueryableNode(node Node) bool {
+	if len(finder.conditions) == 0 {
+		return true
+	}
+	cond := node.Condition()
+	for _, accepted := range finder.conditions {
+		if cond == accepted {
 			return true
 		}
 	}
 	return false
 }
 
+// queryableNodes returns the found nodes which are accepted by the condition filter.
+// The caller must hold the mutex.
+func (finder *baseFinder) queryableNodes() []*foundNode {
+	nodes := make([]*foundNode, 0, len(finder.nodes))
+	for _, foundNode := range finder.nodes {
+		if finder.isQueryableNode(foundNode.Node) {
+			nodes = append(nodes, foundNode)
+		}
+	}
+	return nodes
+}
+
+// updateRingNode adds the specified node into the hash ring when the node is queryable, otherwise removes it from the ring.
+// The caller must hold the mutex.
+func (finder *baseFinder) updateRingNode(foundNode *foundNode) {
+	if finder.isQueryableNode(foundNode.Node) {
+		finder.ring.Add(foundNode.key, foundNode.Node)
+		return
+	}
+	finder.ring.Remove(foundNode.key)
+}
+
 // addNodes adds a specified node.
 func (finder *baseFinder) addNode(node Node) error {
-	if finder.HasNode(node) {
+	nodeKey := hashRingNodeKey(node)
+	finder.mutex.Lock()
+	if !finder.isClusterMember(node) {
+		finder.mutex.Unlock()
+		return fmt.Errorf(errorFinderNodeOutOfCluster, node, finder.clusterFilter)
+	}
+	if 0 <= finder.findNodeIndex(node) {
+		finder.mutex.Unlock()
 		return fmt.Errorf(errorFinderHasSameNode, node)
 	}
-	finder.nodes = append(finder.nodes, node)
+	addedNode := &foundNode{Node: node, key: nodeKey, lastSeen: time.Now()}
+	finder.nodes = append(finder.nodes, addedNode)
+	finder.updateRingNode(addedNode)
+	finder.queueEvent(newNodeAddedEvent(node))
+	finder.mutex.Unlock()
+
+	finder.postEvents()
+
 	return nil
 }
 
-// GetAllNodes returns all found nodes.
+// updateNode refreshes the last seen time of the specified node, replaces the added node when the status, ports or labels are changed, and returns false when the node is not added.
+func (finder *baseFinder) updateNode(updatedNode Node) bool {
+	finder.mutex.Lock()
+	idx := finder.findNodeIndex(updatedNode)
+	if idx < 0 {
+		finder.mutex.Unlock()
+		return false
+	}
+	foundNode := finder.nodes[idx]
+	foundNode.lastSeen = time.Now()
+	oldStatus := node.NewStatusWithStatus(foundNode.Node)
+	newStatus := node.NewStatusWithStatus(updatedNode)
+	isUpdated := !node.StatusEqual(oldStatus, newStatus) || !nodeMetadataEqual(foundNode.Node, updatedNode)
+	if isUpdated {
+		foundNode.Node = updatedNode
+		finder.updateRingNode(foundNode)
+		finder.queueEvent(newNodeUpdatedEvent(updatedNode, oldStatus, newStatus))
+	}
+	finder.mutex.Unlock()
+
+	finder.postEvents()
+
+	return true
+}
+
+// RemoveNode removes the specified node.
+func (finder *baseFinder) RemoveNode(node Node) error {
+	finder.mutex.Lock()
+	idx := finder.findNodeIndex(node)
+	if idx < 0 {
+		finder.mutex.Unlock()
+		return fmt.Errorf(errorFinderNodeNotFound, node)
+	}
+	removedNode := finder.nodes[idx].Node
+	finder.ring.Remove(finder.nodes[idx].key)
+	finder.nodes = append(finder.nodes[:idx], finder.nodes[idx+1:]...)
+	finder.queueEvent(newNodeRemovedEvent(removedNode))
+	finder.mutex.Unlock()
+
+	finder.postEvents()
+
+	return nil
+}
+
+// setNodes replaces all added nodes with the specified nodes atomically, and posts the events of the removed, updated and added nodes.
+// The added nodes which are also in the specified nodes are kept, and replaced when the status, ports or labels are changed.
+// The specified nodes which are not accepted by the cluster filter are ignored.
+// setNodes returns the added or changed nodes and the removed nodes.
+func (finder *baseFinder) setNodes(nodes []Node) ([]Node, []Node) {
+	finder.mutex.Lock()
+	newNodes := make([]*foundNode, 0, len(nodes))
+	addedNodes := []Node{}
+	updatedNodes := []Node{}
+	updatedEvents := []*Event{}
+	isKept := make([]bool, len(finder.nodes))
+	now := time.Now()
+	for _, newNode := range nodes {
+		if !finder.isClusterMember(newNode) {
+			continue
+		}
+		isDuplicated := false
+		for _, keptNode := range newNodes {
+			if node.Equal(newNode, keptNode.Node) {
+				isDuplicated = true
+				break
+			}
+		}
+		if isDuplicated {
+			continue
+		}
+		idx := finder.findNodeIndex(newNode)
+		if 0 <= idx {
+			isKept[idx] = true
+			keptNode := finder.nodes[idx]
+			keptNode.lastSeen = now
+			oldStatus := node.NewStatusWithStatus(keptNode.Node)
+			newStatus := node.NewStatusWithStatus(newNode)
+			if !node.StatusEqual(oldStatus, newStatus) || !nodeMetadataEqual(keptNode.Node, newNode) {
+				keptNode.Node = newNode
+				finder.updateRingNode(keptNode)
+				updatedNodes = append(updatedNodes, newNode)
+				updatedEvents = append(updatedEvents, newNodeUpdatedEvent(newNode, oldStatus, newStatus))
+			}
+			newNodes = append(newNodes, keptNode)
+			continue
+		}
+		addedNode := &foundNode{Node: newNode, key: hashRingNodeKey(newNode), lastSeen: now}
+		newNodes = append(newNodes, addedNode)
+		finder.updateRingNode(addedNode)
+		addedNodes = append(addedNodes, newNode)
+	}
+	removedNodes := []Node{}
+	for n, oldNode := range finder.nodes {
+		if isKept[n] {
+			continue
+		}
+		finder.ring.Remove(oldNode.key)
+		removedNodes = append(removedNodes, oldNode.Node)
+	}
+	finder.nodes = newNodes
+	for _, removedNode := range removedNodes {
+		finder.queueEvent(newNodeRemovedEvent(removedNode))
+	}
+	for _, updatedEvent := range updatedEvents {
+		finder.queueEvent(updatedEvent)
+	}
+	for _, addedNode := range addedNodes {
+		finder.queueEvent(newNodeAddedEvent(addedNode))
+	}
+	finder.mutex.Unlock()
+
+	finder.postEvents()
+
+	return append(updatedNodes, addedNodes...), removedNodes
+}
+
+// applyNodes replaces all added nodes with the specified nodes by setNodes, and posts the added, changed and removed nodes to the notify listener.
+func (finder *baseFinder) applyNodes(nodes []Node) {
+	changedNodes, removedNodes := finder.setNodes(nodes)
+	for _, changedNode := range changedNodes {
+		finder.postNotification(changedNode)
+	}
+	for _, removedNode := range removedNodes {
+		finder.postNotification(removedNode)
+	}
+}
+
+// GetAllNodes returns a snapshot of all found nodes which are accepted by the condition filter.
 func (finder *baseFinder) GetAllNodes() ([]Node, error) {
-	return finder.nodes, nil
+	finder.mutex.RLock()
+	defer finder.mutex.RUnlock()
+	queryableNodes := finder.queryableNodes()
+	nodes := make([]Node, len(queryableNodes))
+	for n, foundNode := range queryableNodes {
+		nodes[n] = foundNode.Node
+	}
+	return nodes, nil
 }
 
-// GetNeighborhoodNode returns a neighborhood node of the specified node.
+// allNodes returns a snapshot of all found nodes regardless of the condition filter.
+func (finder *baseFinder) allNodes() []Node {
+	finder.mutex.RLock()
+	defer finder.mutex.RUnlock()
+	nodes := make([]Node, len(finder.nodes))
+	for n, foundNode := range finder.nodes {
+		nodes[n] = foundNode.Node
+	}
+	return nodes
+}
+
+// GetClusterNodes returns only nodes of the specified cluster.
+func (finder *baseFinder) GetClusterNodes(cluster string) ([]Node, error) {
+	finder.mutex.RLock()
+	defer finder.mutex.RUnlock()
+	nodes := make([]Node, 0)
+	for _, foundNode := range finder.queryableNodes() {
+		if foundNode.Cluster() == cluster {
+			nodes = append(nodes, foundNode.Node)
+		}
+	}
+	return nodes, nil
+}
+
+// GetClusters returns the member counts of the found clusters by the cluster names.
+func (finder *baseFinder) GetClusters() (map[string]int, error) {
+	finder.mutex.RLock()
+	defer finder.mutex.RUnlock()
+	clusters := map[string]int{}
+	for _, foundNode := range finder.queryableNodes() {
+		clusters[foundNode.Cluster()]++
+	}
+	return clusters, nil
+}
+
+// SetNodeTTL sets the time-to-live of found nodes. Nodes not seen within the TTL are removed by the sweeper while the finder is running, and zero disables the expiry.
+func (finder *baseFinder) SetNodeTTL(ttl time.Duration) error {
+	if ttl < 0 {
+		return fmt.Errorf(errorFinderInvalidNodeTTL, ttl)
+	}
+	finder.mutex.Lock()
+	finder.nodeTTL = ttl
+	isSweeping := finder.sweeperStop != nil
+	finder.mutex.Unlock()
+	if isSweeping {
+		finder.stopNodeSweeper()
+		finder.startNodeSweeper()
+	}
+	return nil
+}
+
+// NodeTTL returns the time-to-live of found nodes.
+func (finder *baseFinder) NodeTTL() time.Duration {
+	finder.mutex.RLock()
+	defer finder.mutex.RUnlock()
+	return finder.nodeTTL
+}
+
+// sweepNodes removes nodes not seen within the TTL at the specified time, and returns the removed nodes.
+// The removed nodes transit to the out of date condition without holding the mutex before the removed events are posted.
+func (finder *baseFinder) sweepNodes(now time.Time) []Node {
+	finder.mutex.Lock()
+
+	if finder.nodeTTL <= 0 {
+		finder.mutex.Unlock()
+		return nil
+	}
+
+	expiredNodes := make([]Node, 0)
+	aliveNodes := make([]*foundNode, 0, len(finder.nodes))
+	for _, foundNode := range finder.nodes {
+		if now.Sub(foundNode.lastSeen) <= finder.nodeTTL {
+			aliveNodes = append(aliveNodes, foundNode)
+			continue
+		}
+		finder.ring.Remove(foundNode.key)
+		expiredNodes = append(expiredNodes, foundNode.Node)
+		finder.queueEvent(newNodeRemovedEvent(foundNode.Node))
+	}
+	finder.nodes = aliveNodes
+
+	finder.mutex.Unlock()
+
+	for _, expiredNode := range expiredNodes {
+		log.Infof(msgFinderNodeExpired, expiredNode.Host(), expiredNode.Address())
+		if err := node.Transit(expiredNode, node.ConditionOutOfDate); err != nil {
+			log.Warnf("%s", err.Error())
+		}
+	}
+	finder.postEvents()
+
+	return expiredNodes
+}
+
+// startNodeSweeper starts the sweeper goroutine when the TTL is enabled.
+func (finder *baseFinder) startNodeSweeper() {
+	finder.mutex.Lock()
+	defer finder.mutex.Unlock()
+
+	if finder.sweeperStop != nil || finder.nodeTTL <= 0 {
+		return
+	}
+
+	interval := finder.nodeTTL / finderNodeSweepDivisor
+	if interval <= 0 {
+		interval = finder.nodeTTL
+	}
+
+	stop := make(chan struct{})
+	finder.sweeperStop = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				finder.sweepNodes(now)
+			}
+		}
+	}()
+}
+
+// stopNodeSweeper stops the sweeper goroutine.
+func (finder *baseFinder) stopNodeSweeper() {
+	finder.mutex.Lock()
+	defer finder.mutex.Unlock()
+
+	if finder.sweeperStop == nil {
+		return
+	}
+	close(finder.sweeperStop)
+	finder.sweeperStop = nil
+}
+
+// GetNeighborhoodNode returns the successor node of the specified node on the consistent hash ring.
 func (finder *baseFinder) GetNeighborhoodNode(node Node) (Node, error) {
-	nodes, err := finder.GetAllNodes()
+	nodes, err := finder.GetNeighborhoodNodes(node, 1)
 	if err != nil {
 		return nil, err
 	}
-	nodeCount := len(nodes)
-	if nodeCount <= 0 {
+	return nodes[0], nil
+}
+
+// GetNeighborhoodNodes returns up to the specified number of successor nodes of the specified node on the consistent hash ring.
+func (finder *baseFinder) GetNeighborhoodNodes(node Node, n int) ([]Node, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf(errorFinderInvalidNodeCount, n)
+	}
+
+	nodeKey := hashRingNodeKey(node)
+
+	finder.mutex.RLock()
+	defer finder.mutex.RUnlock()
+
+	nodes := finder.ring.Successors(nodeKey, n)
+	if len(nodes) <= 0 {
 		return nil, fmt.Errorf(errorFinderHasNoNodes)
 	}
 
-	// FIXME : Return  a neighborhood node of the specified node instead of the random node
-	nodeIdx := rand.Int() % nodeCount
+	return nodes, nil
+}
 
-	return nodes[nodeIdx], nil
+// GetNodeForKey returns the owner node of the specified key using the rendezvous hashing.
+func (finder *baseFinder) GetNodeForKey(key string) (Node, error) {
+	nodes, err := finder.GetNodesForKey(key, 1)
+	if err != nil {
+		return nil, err
+	}
+	return nodes[0], nil
+}
+
+// GetNodesForKey returns up to the specified number of owner nodes of the specified key using the rendezvous hashing.
+func (finder *baseFinder) GetNodesForKey(key string, replicas int) ([]Node, error) {
+	if replicas <= 0 {
+		return nil, fmt.Errorf(errorFinderInvalidNodeCount, replicas)
+	}
+
+	finder.mutex.RLock()
+	defer finder.mutex.RUnlock()
+
+	nodes := finder.queryableNodes()
+	if len(nodes) <= 0 {
+		return nil, fmt.Errorf(errorFinderHasNoNodes)
+	}
+
+	return rendezvousNodes(key, nodes, replicas), nil
+}
+
+// nodeHostStrings returns the address and host name of the specified node with and without the RPC port and each named port.
+func nodeHostStrings(node Node) []string {
+	addr := node.Address()
+	name := node.Host()
+
+	hosts := []string{
+		addr.String(),
+		fmt.Sprintf("%s:%d", addr, node.RPCPort()),
+		name,
+		fmt.Sprintf("%s:%d", name, node.RPCPort()),
+	}
+
+	ports := node.Ports()
+	for _, portName := range ports.Names() {
+		port := ports[portName]
+		hosts = append(hosts,
+			fmt.Sprintf("%s:%d", addr, port),
+			fmt.Sprintf("%s:%d", name, port),
+		)
+	}
+
+	return hosts
+}
+
+// nodeMetadataEqual returns true when the specified nodes have the same named ports and labels, otherwise false.
+func nodeMetadataEqual(this, other Node) bool {
+	return node.PortsEqual(this.Ports(), other.Ports()) && node.LabelsEqual(this.Labels(), other.Labels())
 }
 
 // GetPrefixNodes returns only nodes matching with a specified start string.
@@ -108,18 +743,7 @@ func (finder *baseFinder) GetPrefixNodes(targetString string) ([]Node, error) {
 	matchedNodes := make([]Node, 0)
 
 	for _, node := range nodes {
-		port := node.RPCPort()
-		addr := node.Address()
-		name := node.Host()
-
-		hosts := []string{
-			addr.String(),
-			fmt.Sprintf("%s:%d", addr, port),
-			name,
-			fmt.Sprintf("%s:%d", name, port),
-		}
-
-		for _, host := range hosts {
+		for _, host := range nodeHostStrings(node) {
 			if len(host) <= 0 {
 				continue
 			}
@@ -143,18 +767,7 @@ func (finder *baseFinder) GetRegexpNodes(re *regexp.Regexp) ([]Node, error) {
 	matchedNodes := make([]Node, 0)
 
 	for _, node := range nodes {
-		port := node.RPCPort()
-		addr := node.Address()
-		name := node.Host()
-
-		hosts := []string{
-			addr.String(),
-			fmt.Sprintf("%s:%d", addr, port),
-			name,
-			fmt.Sprintf("%s:%d", name, port),
-		}
-
-		for _, host := range hosts {
+		for _, host := range nodeHostStrings(node) {
 			if len(host) <= 0 {
 				continue
 			}
@@ -167,3 +780,42 @@ func (finder *baseFinder) GetRegexpNodes(re *regexp.Regexp) ([]Node, error) {
 
 	return matchedNodes, nil
 }
+
+// GetSelectorNodes returns only nodes whose labels match with the specified label selector.
+func (finder *baseFinder) GetSelectorNodes(selector string) ([]Node, error) {
+	sel, err := NewSelectorWithString(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := finder.GetAllNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	matchedNodes := make([]Node, 0)
+	for _, node := range nodes {
+		if sel.MatchNode(node) {
+			matchedNodes = append(matchedNodes, node)
+		}
+	}
+
+	return matchedNodes, nil
+}
+
+// ExpandGraphiteTarget returns the specified Graphite target rewritten for each node which owns the matching series.
+// The leading path components of the target are matched with the node name or address, and replaced with it.
+func (finder *baseFinder) ExpandGraphiteTarget(target string) (map[Node]string, error) {
+	re := NewRegexp()
+	err := re.CompileGraphite(target)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := finder.GetAllNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	return re.ExpandNodes(nodes), nil
+}
@@ -15,9 +15,536 @@
 package finder
 
 import (
+	"fmt"
+	"net"
+	"reflect"
+	"regexp"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/cybergarage/go-finder/finder/node"
 )
 
 func TestNewBaseFinder(t *testing.T) {
 	newBaseFinder()
 }
+
+type testNodeMutator interface {
+	addNode(Node) error
+}
+
+func finderConcurrencyTest(t *testing.T, finder Finder) {
+	t.Helper()
+
+	mutator, ok := finder.(testNodeMutator)
+	if !ok {
+		t.Errorf("%s : not mutable", finder)
+		return
+	}
+
+	const (
+		workerCount = 8
+		loopCount   = 100
+	)
+
+	// Adds a seed node to have a neighborhood node always, and the nodes are ready to be returned by all finders.
+
+	seedNode := node.NewBaseNode().SetHost("org.cybergarage.race.seed").SetAddress(net.IPv4(127, 0, 255, 255))
+	seedNode.SetCondition(node.ConditionReady)
+	if err := mutator.addNode(seedNode); err != nil {
+		t.Error(err)
+		return
+	}
+	defer finder.RemoveNode(seedNode)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workerCount; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for n := 0; n < loopCount; n++ {
+				node := node.NewBaseNode()
+				node.SetHost(fmt.Sprintf("org.cybergarage.race%03d.%03d", w, n))
+				node.SetAddress(net.IPv4(127, 0, byte(w), byte(n)))
+				node.SetCondition(seedNode.Condition())
+				if err := mutator.addNode(node); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := finder.GetAllNodes(); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := finder.GetPrefixNodes(node.Host()); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := finder.GetRegexpNodes(regexp.MustCompile(node.Host())); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := finder.GetNeighborhoodNode(node); err != nil {
+					t.Error(err)
+					return
+				}
+				if err := finder.RemoveNode(node); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+func TestBaseFinderConcurrency(t *testing.T) {
+	finders := []Finder{
+		NewStaticFinderWithNodes(nil),
+		NewSharedFinder(),
+		NewEchonetFinder(),
+	}
+
+	for _, finder := range finders {
+		t.Run(finder.String(), func(t *testing.T) {
+			before, err := finder.GetAllNodes()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			finderConcurrencyTest(t, finder)
+			after, err := finder.GetAllNodes()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if len(after) != len(before) {
+				t.Errorf(testFinderNodeCountError, len(after), len(before))
+			}
+		})
+	}
+}
+
+func TestBaseFinderSnapshot(t *testing.T) {
+	finder := NewStaticFinderWithNodes([]Node{node.NewBaseNode().SetHost("localhost")})
+
+	nodes, err := finder.GetAllNodes()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	nodes[0] = nil
+
+	nodes, err = finder.GetAllNodes()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if nodes[0] == nil {
+		t.Errorf("GetAllNodes returned the internal node table")
+	}
+}
+
+func TestBaseFinderSnapshotNameResolution(t *testing.T) {
+	hostOnlyNode := node.NewBaseNode().SetHost("localhost")
+	hostOnlyNode.SetCondition(node.ConditionReady)
+	finder := NewStaticFinderWithNodes([]Node{hostOnlyNode})
+
+	// The snapshot readers resolve the address of the shared host-only node concurrently
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nodes, err := finder.GetAllNodes()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for _, n := range nodes {
+				if n.Address() == nil {
+					t.Errorf("No address : %s", n.Host())
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestBaseFinderRemoveNode(t *testing.T) {
+	nodes := setupTestFinderNodes()
+	finder := NewStaticFinderWithNodes(nodes)
+
+	err := finder.RemoveNode(nodes[0])
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = finder.RemoveNode(nodes[0])
+	if err == nil {
+		t.Errorf("Removed node (%s) is removed again", nodes[0].Host())
+	}
+
+	allNodes, err := finder.GetAllNodes()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(allNodes) != (len(nodes) - 1) {
+		t.Errorf(testFinderNodeCountError, len(allNodes), len(nodes)-1)
+	}
+}
+
+func TestBaseFinderSweepNodes(t *testing.T) {
+	finder := newBaseFinder()
+
+	staleNode := node.NewBaseNode().SetHost("org.cybergarage.stale").SetAddress(net.ParseIP("192.168.100.1"))
+	freshNode := node.NewBaseNode().SetHost("org.cybergarage.fresh").SetAddress(net.ParseIP("192.168.100.2"))
+	for _, n := range []*node.BaseNode{staleNode, freshNode} {
+		n.SetCondition(node.ConditionReady)
+		if err := finder.addNode(n); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	now := time.Now()
+	finder.nodes[0].lastSeen = now.Add(-time.Minute)
+	finder.nodes[1].lastSeen = now
+
+	// No expiry without TTL
+
+	if expiredNodes := finder.sweepNodes(now); len(expiredNodes) != 0 {
+		t.Errorf(testFinderNodeCountError, len(expiredNodes), 0)
+	}
+
+	if err := finder.SetNodeTTL(-time.Second); err == nil {
+		t.Errorf("Negative TTL is accepted")
+	}
+
+	if err := finder.SetNodeTTL(time.Second); err != nil {
+		t.Error(err)
+		return
+	}
+
+	expiredNodes := finder.sweepNodes(now)
+	if len(expiredNodes) != 1 {
+		t.Errorf(testFinderNodeCountError, len(expiredNodes), 1)
+		return
+	}
+	if expiredNodes[0] != staleNode {
+		t.Errorf(testFinderMatchingError, staleNode.Host(), expiredNodes[0].Host())
+	}
+	if staleNode.Condition() != node.ConditionOutOfDate {
+		t.Errorf("%s : %s != %s", staleNode.Host(), staleNode.Condition(), node.ConditionOutOfDate)
+	}
+	if !finder.HasNode(freshNode) || finder.HasNode(staleNode) {
+		t.Errorf("%s is not swept", staleNode.Host())
+	}
+
+	// The nodes which can not transit to the out of date condition are also swept
+
+	initialNode := node.NewBaseNode().SetHost("org.cybergarage.initial").SetAddress(net.ParseIP("192.168.100.3"))
+	initialNode.SetCondition(node.ConditionInitial)
+	transitions := []node.Condition{}
+	staleNode.AddConditionHook(func(n *node.BaseNode, from node.Condition, to node.Condition) {
+		transitions = append(transitions, to)
+	})
+	for _, n := range []*node.BaseNode{initialNode, staleNode} {
+		if err := finder.addNode(n); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	staleNode.SetCondition(node.ConditionReady)
+	for _, foundNode := range finder.nodes {
+		if foundNode.Node != freshNode {
+			foundNode.lastSeen = now.Add(-time.Minute)
+		}
+	}
+	if expiredNodes := finder.sweepNodes(now); len(expiredNodes) != 2 {
+		t.Errorf(testFinderNodeCountError, len(expiredNodes), 2)
+	}
+	if initialNode.Condition() != node.ConditionInitial {
+		t.Errorf("%s : %s != %s", initialNode.Host(), initialNode.Condition(), node.ConditionInitial)
+	}
+	if len(transitions) != 1 || transitions[0] != node.ConditionOutOfDate {
+		t.Errorf("%s : %v", staleNode.Host(), transitions)
+	}
+
+	// The touched node is kept
+
+	finder.nodes[0].lastSeen = now.Add(-time.Minute)
+	if !finder.updateNode(freshNode) {
+		t.Errorf(testFinderMatchingError, freshNode.Host(), "")
+	}
+	if expiredNodes := finder.sweepNodes(time.Now()); len(expiredNodes) != 0 {
+		t.Errorf(testFinderNodeCountError, len(expiredNodes), 0)
+	}
+}
+
+func TestBaseFinderNodeSweeper(t *testing.T) {
+	finder := newBaseFinder()
+	if err := finder.addNode(node.NewBaseNode().SetHost("localhost")); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := finder.SetNodeTTL(50 * time.Millisecond); err != nil {
+		t.Error(err)
+		return
+	}
+
+	finder.startNodeSweeper()
+	defer finder.stopNodeSweeper()
+
+	for n := 0; n < 20; n++ {
+		if len(finder.allNodes()) == 0 {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	t.Errorf("Expired nodes are not removed")
+}
+
+func TestBaseFinderSetNodes(t *testing.T) {
+	nodes := setupTestHashRingNodes(3)
+	finder := NewStaticFinderWithNodes(nodes[0:2]).(*StaticFinder)
+
+	listener := &testEventListener{}
+	if err := finder.AddEventListener(listener); err != nil {
+		t.Error(err)
+		return
+	}
+
+	labeledNode := node.NewBaseNode().SetHost(nodes[1].Host()).SetAddress(nodes[1].Address()).SetLabel("zone", "a")
+	finder.setNodes([]Node{labeledNode, nodes[2], nodes[2]})
+
+	expectedTypes := []EventType{NodeRemoved, NodeUpdated, NodeAdded}
+	events := listener.Events()
+	if len(events) != len(expectedTypes) {
+		t.Errorf(testFinderNodeCountError, len(events), len(expectedTypes))
+		return
+	}
+	for n, e := range events {
+		if e.Type != expectedTypes[n] {
+			t.Errorf("%s != %s", e.Type, expectedTypes[n])
+		}
+	}
+
+	selectedNodes, err := finder.GetSelectorNodes("zone=a")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(selectedNodes) != 1 || selectedNodes[0] != labeledNode {
+		t.Errorf(testFinderMatchingCountError, "zone=a", len(selectedNodes), 1)
+	}
+
+	if _, err := finder.GetNodeForKey("key"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBaseFinderApplyNodes(t *testing.T) {
+	nodes := setupTestHashRingNodes(4)
+	finder := NewStaticFinderWithNodes(nodes[0:3]).(*StaticFinder)
+
+	listener := &testNotifyListener{}
+	if err := finder.SetNotifyListener(listener); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// The added, changed and removed nodes are notified, and the unchanged nodes are not
+
+	labeledNode := node.NewBaseNode().SetHost(nodes[1].Host()).SetAddress(nodes[1].Address()).SetLabel("zone", "a")
+	finder.applyNodes([]Node{nodes[0], labeledNode, nodes[3]})
+
+	expectedNodes := []Node{labeledNode, nodes[3], nodes[2]}
+	notifiedNodes := listener.Nodes()
+	if len(notifiedNodes) != len(expectedNodes) {
+		t.Errorf(testFinderNodeCountError, len(notifiedNodes), len(expectedNodes))
+		return
+	}
+	for n, notifiedNode := range notifiedNodes {
+		if !node.Equal(notifiedNode, expectedNodes[n]) {
+			t.Errorf(testFinderMatchingError, expectedNodes[n].Host(), notifiedNode.Host())
+		}
+	}
+}
+
+func TestBaseFinderSetNodesWithTTL(t *testing.T) {
+	nodes := setupTestHashRingNodes(2)
+	finder := newBaseFinder()
+
+	listener := &testEventListener{}
+	if err := finder.AddEventListener(listener); err != nil {
+		t.Error(err)
+		return
+	}
+
+	ttl := 100 * time.Millisecond
+	if err := finder.SetNodeTTL(ttl); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// The nodes which are reported repeatedly never expire
+
+	for n := 0; n < 5; n++ {
+		finder.setNodes(nodes)
+		time.Sleep(ttl * 2 / 3)
+		finder.sweepNodes(time.Now())
+		if len(finder.allNodes()) != len(nodes) {
+			t.Errorf(testFinderNodeCountError, len(finder.allNodes()), len(nodes))
+		}
+	}
+	if events := listener.Events(); len(events) != len(nodes) {
+		t.Errorf(testFinderNodeCountError, len(events), len(nodes))
+	}
+
+	// The nodes expire when they are not reported
+
+	finder.sweepNodes(time.Now().Add(ttl))
+	if len(finder.allNodes()) != 0 {
+		t.Errorf(testFinderNodeCountError, len(finder.allNodes()), 0)
+	}
+}
+
+func TestBaseFinderClusters(t *testing.T) {
+	nodes := []Node{
+		node.NewBaseNode().SetCluster("production").SetHost("org.cybergarage.finder001").SetAddress(net.ParseIP("192.168.100.1")),
+		node.NewBaseNode().SetCluster("production").SetHost("org.cybergarage.finder002").SetAddress(net.ParseIP("192.168.100.2")),
+		node.NewBaseNode().SetCluster("staging").SetHost("org.cybergarage.finder003").SetAddress(net.ParseIP("192.168.100.3")),
+	}
+	finder := NewStaticFinderWithNodes(nodes).(*StaticFinder)
+
+	clusters, err := finder.GetClusters()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expectedClusters := map[string]int{"production": 2, "staging": 1}
+	if !reflect.DeepEqual(clusters, expectedClusters) {
+		t.Errorf("%v != %v", clusters, expectedClusters)
+	}
+
+	clusterNodes, err := finder.GetClusterNodes("production")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(clusterNodes) != 2 {
+		t.Errorf(testFinderMatchingCountError, "production", len(clusterNodes), 2)
+	}
+
+	// The nodes of the other clusters are removed and rejected by the cluster filter
+
+	listener := &testEventListener{}
+	if err := finder.AddEventListener(listener); err != nil {
+		t.Error(err)
+		return
+	}
+
+	finder.SetClusterFilter("production")
+	if cluster, ok := finder.ClusterFilter(); !ok || cluster != "production" {
+		t.Errorf("%s != %s", cluster, "production")
+	}
+	if finder.HasNode(nodes[2]) {
+		t.Errorf("%s of %s is not removed", nodes[2].Host(), nodes[2].Cluster())
+	}
+	events := listener.Events()
+	if len(events) != 1 || events[0].Type != NodeRemoved {
+		t.Errorf(testFinderNodeCountError, len(events), 1)
+	}
+	if err := finder.addNode(nodes[2]); err == nil {
+		t.Errorf("%s of %s is added", nodes[2].Host(), nodes[2].Cluster())
+	}
+
+	finder.ClearClusterFilter()
+	if err := finder.addNode(nodes[2]); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBaseFinderConditionFilter(t *testing.T) {
+	finder := newBaseFinder()
+
+	nodes := setupTestHashRingNodes(2)
+	readyNode := nodes[0].(*node.BaseNode)
+	readyNode.SetCondition(node.ConditionReady)
+	for _, n := range nodes {
+		if err := finder.addNode(n); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	// All nodes are returned by the queries by default
+
+	allNodes, err := finder.GetAllNodes()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(allNodes) != 2 {
+		t.Errorf(testFinderNodeCountError, len(allNodes), 2)
+	}
+
+	// Only the ready nodes are returned by the queries with the ready filter
+
+	finder.SetConditionFilter(node.ConditionReady)
+	allNodes, err = finder.GetAllNodes()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(allNodes) != 1 || allNodes[0] != readyNode {
+		t.Errorf(testFinderNodeCountError, len(allNodes), 1)
+	}
+	for n := 0; n < 16; n++ {
+		ownerNode, err := finder.GetNodeForKey(fmt.Sprintf("key%d", n))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if ownerNode != readyNode {
+			t.Errorf("%s != %s", ownerNode.Host(), readyNode.Host())
+		}
+		neighborNode, err := finder.GetNeighborhoodNode(nodes[1])
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if neighborNode != readyNode {
+			t.Errorf("%s != %s", neighborNode.Host(), readyNode.Host())
+		}
+	}
+
+	// The node becomes queryable when the node is updated to be ready
+
+	updatedNode := node.NewBaseNode().SetHost(nodes[1].Host()).SetAddress(nodes[1].Address())
+	updatedNode.SetCondition(node.ConditionReady)
+	finder.updateNode(updatedNode)
+	allNodes, _ = finder.GetAllNodes()
+	if len(allNodes) != 2 {
+		t.Errorf(testFinderNodeCountError, len(allNodes), 2)
+	}
+
+	finder.SetConditionFilter(node.ConditionStop)
+	if allNodes, _ = finder.GetAllNodes(); len(allNodes) != 0 {
+		t.Errorf(testFinderNodeCountError, len(allNodes), 0)
+	}
+	if _, err := finder.GetNodeForKey("key"); err == nil {
+		t.Errorf("a stopped node is found")
+	}
+
+	finder.SetConditionFilter()
+	if allNodes, _ = finder.GetAllNodes(); len(allNodes) != 2 {
+		t.Errorf(testFinderNodeCountError, len(allNodes), 2)
+	}
+}
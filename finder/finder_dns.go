@@ -0,0 +1,221 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cybergarage/go-finder/finder/node"
+	"github.com/cybergarage/go-logger/log"
+)
+
+const (
+	dnsFinderNoServiceTarget = "."
+)
+
+const (
+	msgDNSFinderResolved        = "%d nodes of %s are resolved (TTL %s)"
+	msgDNSFinderResolveFailed   = "%s is not resolved : %s"
+	msgDNSFinderAddressNotFound = "Address of %s is not resolved : %s"
+)
+
+// DNSFinder represents a finder which resolves the nodes with the SRV records of the specified service name.
+type DNSFinder struct {
+	*baseFinder
+	service                string
+	resolver               DNSResolver
+	resolveMutex           sync.Mutex
+	isAddressLookupEnabled bool
+	expiry                 time.Time
+	runningMutex           sync.Mutex
+	isRunning              bool
+	*discoveryRunner
+}
+
+// NewDNSFinderWithResolver returns a new finder which resolves the specified SRV name such as "_finder._tcp.example.com" with the resolver.
+func NewDNSFinderWithResolver(service string, resolver DNSResolver) Finder {
+	return &DNSFinder{
+		baseFinder:             newBaseFinder(),
+		service:                service,
+		resolver:               resolver,
+		isAddressLookupEnabled: true,
+		expiry:                 time.Time{},
+		isRunning:              false,
+		discoveryRunner:        newDiscoveryRunner(),
+	}
+}
+
+// NewDNSFinder returns a new finder which resolves the specified SRV name with the system resolver.
+func NewDNSFinder(service string) Finder {
+	return NewDNSFinderWithResolver(service, NewDNSSystemResolver())
+}
+
+func init() {
+	mustRegisterFinder(FinderDNS, func(opts *FinderOptions) (Finder, error) {
+		if opts.Config.Service == "" {
+			return nil, fmt.Errorf(errorFinderFactoryNoService, FinderDNS)
+		}
+		if opts.Config.Server != "" {
+			return NewDNSFinderWithResolver(opts.Config.Service, NewDNSServerResolver(opts.Config.Server)), nil
+		}
+		return NewDNSFinder(opts.Config.Service), nil
+	})
+}
+
+// Service returns the SRV name of the finder.
+func (finder *DNSFinder) Service() string {
+	return finder.service
+}
+
+// SetAddressLookupEnabled sets whether the A and AAAA records of the SRV targets are resolved, and it is enabled by default.
+// The nodes without the resolved addresses look up the addresses of the host names with the system resolver when the addresses are required.
+func (finder *DNSFinder) SetAddressLookupEnabled(enabled bool) {
+	finder.resolveMutex.Lock()
+	defer finder.resolveMutex.Unlock()
+	finder.isAddressLookupEnabled = enabled
+	finder.expiry = time.Time{}
+}
+
+// Resolve resolves the SRV records and the addresses regardless of the TTLs, and replaces the nodes with the resolved nodes.
+func (finder *DNSFinder) Resolve(ctx context.Context) ([]Node, error) {
+	finder.resolveMutex.Lock()
+	defer finder.resolveMutex.Unlock()
+	return finder.resolve(ctx)
+}
+
+// resolve resolves the nodes, and the caller must hold the resolve mutex.
+func (finder *DNSFinder) resolve(ctx context.Context) ([]Node, error) {
+	services, err := finder.resolver.LookupSRV(ctx, finder.service)
+	if err != nil {
+		log.Warnf(msgDNSFinderResolveFailed, finder.service, err.Error())
+		return nil, err
+	}
+
+	ttl := time.Duration(-1)
+	updateTTL := func(recordTTL time.Duration) {
+		if ttl < 0 || recordTTL < ttl {
+			ttl = recordTTL
+		}
+	}
+
+	nodes := []Node{}
+	for _, service := range services {
+		updateTTL(service.TTL)
+		if service.Target == dnsFinderNoServiceTarget {
+			continue
+		}
+		host := strings.TrimSuffix(service.Target, dnsFinderNoServiceTarget)
+		resolvedNode := node.NewBaseNode().SetHost(host).SetRPCPort(uint(service.Port))
+		resolvedNode.SetCondition(node.ConditionReady)
+		if finder.isAddressLookupEnabled {
+			addrs, err := finder.resolver.LookupIP(ctx, host)
+			switch {
+			case err != nil:
+				log.Warnf(msgDNSFinderAddressNotFound, host, err.Error())
+			case 0 < len(addrs):
+				resolvedNode.SetAddress(addrs[0].IP)
+			}
+			for _, addr := range addrs {
+				updateTTL(addr.TTL)
+			}
+		}
+		nodes = append(nodes, resolvedNode)
+	}
+	if ttl < 0 {
+		ttl = DefaultDNSTTL
+	}
+
+	finder.setNodes(nodes)
+	finder.expiry = time.Now().Add(ttl)
+	log.Tracef(msgDNSFinderResolved, len(nodes), finder.service, ttl)
+
+	for _, resolvedNode := range nodes {
+		finder.postSearchResponse(resolvedNode)
+	}
+
+	return nodes, nil
+}
+
+// Search re-resolves the nodes when the TTL of the resolved records is expired.
+func (finder *DNSFinder) Search() error {
+	_, err := finder.SearchContext(context.Background(), NewSearchOptions())
+	return err
+}
+
+// SearchContext re-resolves the nodes when the TTL of the resolved records is expired, and returns all nodes.
+// The resolved nodes are posted to the search listener only when the nodes are re-resolved.
+func (finder *DNSFinder) SearchContext(ctx context.Context, opts *SearchOptions) ([]Node, error) {
+	if opts == nil {
+		opts = NewSearchOptions()
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	finder.resolveMutex.Lock()
+	defer finder.resolveMutex.Unlock()
+	if time.Now().Before(finder.expiry) {
+		return finder.GetAllNodes()
+	}
+	if _, err := finder.resolve(ctx); err != nil {
+		return nil, err
+	}
+	return finder.GetAllNodes()
+}
+
+// Start resolves the nodes, and starts the finder even if the nodes are not resolved.
+func (finder *DNSFinder) Start() error {
+	finder.runningMutex.Lock()
+	finder.isRunning = true
+	finder.runningMutex.Unlock()
+
+	finder.Search()
+	finder.startNodeSweeper()
+
+	finder.startDiscovery(finder)
+
+	return nil
+}
+
+// Stop stops the finder.
+func (finder *DNSFinder) Stop() error {
+	finder.stopDiscovery()
+
+	finder.stopNodeSweeper()
+
+	finder.runningMutex.Lock()
+	defer finder.runningMutex.Unlock()
+	finder.isRunning = false
+	return nil
+}
+
+// IsRunning returns true when the finder is running, otherwise false.
+func (finder *DNSFinder) IsRunning() bool {
+	finder.runningMutex.Lock()
+	defer finder.runningMutex.Unlock()
+	return finder.isRunning
+}
+
+// String returns the description.
+func (finder *DNSFinder) String() string {
+	return FinderDNS
+}
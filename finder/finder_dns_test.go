@@ -0,0 +1,387 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	finder_dns "github.com/cybergarage/go-finder/finder/dns"
+)
+
+const (
+	testDNSService = "_finder._tcp.example.com."
+)
+
+// testDNSServer represents an in-process DNS server which answers the SRV and A records of the test nodes over UDP and TCP.
+// The UDP responses which exceed the payload size of the query are truncated, and the queries of the silent types are not answered.
+type testDNSServer struct {
+	conn         *net.UDPConn
+	listener     net.Listener
+	mutex        sync.Mutex
+	records      []*finder_dns.Record
+	queries      map[finder_dns.Type]int
+	silentTypes  map[finder_dns.Type]bool
+	tcpQueries   int
+	payloadSizes []int
+}
+
+func newTestDNSServer(records ...*finder_dns.Record) (*testDNSServer, error) {
+	var lastErr error
+	for range 10 {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0})
+		if err != nil {
+			return nil, err
+		}
+		listener, err := net.Listen("tcp", conn.LocalAddr().String())
+		if err != nil {
+			conn.Close()
+			lastErr = err
+			continue
+		}
+		server := &testDNSServer{
+			conn:         conn,
+			listener:     listener,
+			records:      records,
+			queries:      map[finder_dns.Type]int{},
+			silentTypes:  map[finder_dns.Type]bool{},
+			tcpQueries:   0,
+			payloadSizes: []int{},
+		}
+		go server.serveUDP()
+		go server.serveTCP()
+		return server, nil
+	}
+	return nil, lastErr
+}
+
+func (server *testDNSServer) Address() string {
+	return server.conn.LocalAddr().String()
+}
+
+func (server *testDNSServer) Queries(recordType finder_dns.Type) int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return server.queries[recordType]
+}
+
+func (server *testDNSServer) SetSilentType(recordType finder_dns.Type) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.silentTypes[recordType] = true
+}
+
+func (server *testDNSServer) TCPQueries() int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return server.tcpQueries
+}
+
+func (server *testDNSServer) PayloadSizes() []int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return append([]int{}, server.payloadSizes...)
+}
+
+func (server *testDNSServer) respond(b []byte, isTCP bool) []byte {
+	query, err := finder_dns.NewMessageWithBytes(b)
+	if err != nil || len(query.Questions) != 1 {
+		return nil
+	}
+	question := query.Questions[0]
+
+	server.mutex.Lock()
+	server.queries[question.Type]++
+	if server.silentTypes[question.Type] {
+		server.mutex.Unlock()
+		return nil
+	}
+	if isTCP {
+		server.tcpQueries++
+	} else {
+		server.payloadSizes = append(server.payloadSizes, query.UDPPayloadSize())
+	}
+	res := finder_dns.NewResponseMessage()
+	res.ID = query.ID
+	res.RCode = finder_dns.RCodeNameError
+	for _, record := range server.records {
+		if !finder_dns.NameEqual(record.Name, question.Name) {
+			continue
+		}
+		res.RCode = finder_dns.RCodeSuccess
+		if record.Type == question.Type {
+			res.Answers = append(res.Answers, record)
+		}
+	}
+	server.mutex.Unlock()
+
+	resBytes, err := res.Bytes()
+	if err != nil {
+		return nil
+	}
+	if !isTCP && query.UDPPayloadSize() < len(resBytes) {
+		res.Answers = nil
+		res.Truncated = true
+		resBytes, err = res.Bytes()
+		if err != nil {
+			return nil
+		}
+	}
+	return resBytes
+}
+
+func (server *testDNSServer) serveUDP() {
+	buf := make([]byte, finder_dns.MessageMaxSize)
+	for {
+		n, addr, err := server.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		if b := server.respond(buf[:n], false); b != nil {
+			server.conn.WriteToUDP(b, addr)
+		}
+	}
+}
+
+func (server *testDNSServer) serveTCP() {
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			var size [2]byte
+			if _, err := io.ReadFull(conn, size[:]); err != nil {
+				return
+			}
+			buf := make([]byte, binary.BigEndian.Uint16(size[:]))
+			if _, err := io.ReadFull(conn, buf); err != nil {
+				return
+			}
+			b := server.respond(buf, true)
+			if b == nil {
+				return
+			}
+			conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(b))), b...))
+		}()
+	}
+}
+
+func (server *testDNSServer) Close() error {
+	server.listener.Close()
+	return server.conn.Close()
+}
+
+func TestDNSFinder(t *testing.T) {
+	server, err := newTestDNSServer(
+		finder_dns.NewSRVRecord(testDNSService, "ring001.example.com.", 8001, 1),
+		finder_dns.NewSRVRecord(testDNSService, "ring002.example.com.", 8002, 60),
+		finder_dns.NewAddressRecord("ring001.example.com.", net.ParseIP("192.168.100.1"), 60),
+		finder_dns.NewAddressRecord("ring002.example.com.", net.ParseIP("192.168.100.2"), 60),
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer server.Close()
+
+	resolver := NewDNSServerResolver(server.Address())
+	resolver.SetTimeout(time.Second)
+	finder := NewDNSFinderWithResolver(testDNSService, resolver).(*DNSFinder)
+
+	nodes, err := finder.SearchContext(context.Background(), nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(nodes) != 2 {
+		t.Errorf(testFinderNodeCountError, len(nodes), 2)
+		return
+	}
+
+	expected := map[string]struct {
+		addr string
+		port uint
+	}{
+		"ring001.example.com": {"192.168.100.1", 8001},
+		"ring002.example.com": {"192.168.100.2", 8002},
+	}
+	for _, node := range nodes {
+		exp, ok := expected[node.Host()]
+		if !ok {
+			t.Errorf(testFinderMatchingError, testDNSService, node.Host())
+			continue
+		}
+		if !node.Address().Equal(net.ParseIP(exp.addr)) {
+			t.Errorf("%s != %s", node.Address(), exp.addr)
+		}
+		if node.RPCPort() != exp.port {
+			t.Errorf("%d != %d", node.RPCPort(), exp.port)
+		}
+	}
+
+	// The resolved records are cached until the minimum TTL is expired
+
+	if err := finder.Search(); err != nil {
+		t.Error(err)
+	}
+	if n := server.Queries(finder_dns.TypeSRV); n != 1 {
+		t.Errorf(testFinderMatchingCountError, "SRV", n, 1)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if err := finder.Search(); err != nil {
+		t.Error(err)
+	}
+	if n := server.Queries(finder_dns.TypeSRV); n != 2 {
+		t.Errorf(testFinderMatchingCountError, "SRV", n, 2)
+	}
+
+	// Resolve ignores the TTLs
+
+	if _, err := finder.Resolve(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if n := server.Queries(finder_dns.TypeSRV); n != 3 {
+		t.Errorf(testFinderMatchingCountError, "SRV", n, 3)
+	}
+
+	// The addresses are not resolved when the address lookup is disabled
+
+	queries := server.Queries(finder_dns.TypeA)
+	finder.SetAddressLookupEnabled(false)
+	nodes, err = finder.SearchContext(context.Background(), nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(nodes) != 2 {
+		t.Errorf(testFinderNodeCountError, len(nodes), 2)
+	}
+	if n := server.Queries(finder_dns.TypeA); n != queries {
+		t.Errorf(testFinderMatchingCountError, "A", n, queries)
+	}
+}
+
+func TestDNSFinderNoService(t *testing.T) {
+	server, err := newTestDNSServer()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer server.Close()
+
+	finder := NewDNSFinderWithResolver(testDNSService, NewDNSServerResolver(server.Address()))
+	nodes, err := finder.SearchContext(context.Background(), nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(nodes) != 0 {
+		t.Errorf(testFinderNodeCountError, len(nodes), 0)
+	}
+}
+
+func TestDNSServerResolverLargeResponse(t *testing.T) {
+	// The responses over 512 bytes are received over UDP by EDNS0, and the truncated responses are received over TCP
+
+	testCases := []struct {
+		count      int
+		tcpQueries int
+	}{
+		{count: 20, tcpQueries: 0},
+		{count: 120, tcpQueries: 1},
+	}
+	for _, testCase := range testCases {
+		count := testCase.count
+		records := []*finder_dns.Record{}
+		for n := 0; n < count; n++ {
+			records = append(records, finder_dns.NewSRVRecord(testDNSService, fmt.Sprintf("ring%03d.example.com.", n), 8001, 60))
+		}
+		server, err := newTestDNSServer(records...)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer server.Close()
+
+		resolver := NewDNSServerResolver(server.Address())
+		resolver.SetTimeout(time.Second)
+		services, err := resolver.LookupSRV(context.Background(), testDNSService)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if len(services) != count {
+			t.Errorf(testFinderNodeCountError, len(services), count)
+		}
+
+		payloadSizes := server.PayloadSizes()
+		if len(payloadSizes) != 1 || payloadSizes[0] != dnsMessageMaxSize {
+			t.Errorf("%v != [%d]", payloadSizes, dnsMessageMaxSize)
+		}
+		if n := server.TCPQueries(); n != testCase.tcpQueries {
+			t.Errorf(testFinderMatchingCountError, "TCP", n, testCase.tcpQueries)
+		}
+	}
+}
+
+func TestDNSServerResolverLookupIPTimeout(t *testing.T) {
+	server, err := newTestDNSServer(finder_dns.NewAddressRecord("ring001.example.com.", net.ParseIP("192.0.2.1"), 60))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer server.Close()
+
+	timeout := 500 * time.Millisecond
+	resolver := NewDNSServerResolver(server.Address())
+	resolver.SetTimeout(timeout)
+
+	// The A records are returned even if the AAAA query is not answered
+
+	server.SetSilentType(finder_dns.TypeAAAA)
+	addrs, err := resolver.LookupIP(context.Background(), "ring001.example.com.")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(addrs) != 1 {
+		t.Errorf(testFinderNodeCountError, len(addrs), 1)
+	}
+
+	// The A and AAAA queries share the timeout
+
+	server.SetSilentType(finder_dns.TypeA)
+	start := time.Now()
+	if _, err := resolver.LookupIP(context.Background(), "ring001.example.com."); err == nil {
+		t.Errorf("%s : resolved without answers", "ring001.example.com.")
+	}
+	if elapsed := time.Since(start); timeout*3/2 < elapsed {
+		t.Errorf("%s : %s < %s", "ring001.example.com.", timeout*3/2, elapsed)
+	}
+	for _, recordType := range []finder_dns.Type{finder_dns.TypeA, finder_dns.TypeAAAA} {
+		if n := server.Queries(recordType); n != 2 {
+			t.Errorf(testFinderMatchingCountError, recordType, n, 2)
+		}
+	}
+}
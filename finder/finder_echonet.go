@@ -15,6 +15,7 @@
 package finder
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"time"
@@ -35,6 +36,8 @@ const (
 	msgEchonetFinderFoundEchonetNode = "Echonet node (%s:%d) is found"
 	msgEchonetFinderFoundCadiateNode = "Candidate finder node (%s:%d) is found"
 	msgEchonetFinderFoundNewNode     = "New finder node (%s:%d) is found"
+	msgEchonetFinderAnnouncedNode    = "Finder node (%s:%d) is announced (%s)"
+	msgEchonetFinderOutOfClusterNode = "Finder node (%s:%d) of cluster (%s) is ignored"
 )
 
 // EchonetFinder represents a base finder.
@@ -42,16 +45,22 @@ type EchonetFinder struct {
 	*baseFinder
 	localNode node.Node
 	*finder_echonet.EchonetController
+	*discoveryRunner
 }
 
 // NewEchonetFinderWithLocalNode returns a new finder with the specified node.
+// The finder accepts only nodes of the same cluster as the specified node, use ClearClusterFilter to accept nodes of all clusters.
 func NewEchonetFinderWithLocalNode(node node.Node) Finder {
 	finder := &EchonetFinder{
 		baseFinder:        newBaseFinder(),
 		localNode:         node,
 		EchonetController: finder_echonet.NewController(),
+		discoveryRunner:   newDiscoveryRunner(),
 	}
 	finder.EchonetController.SetListener(finder)
+	if node != nil && !reflect.ValueOf(node).IsNil() {
+		finder.SetClusterFilter(node.Cluster())
+	}
 	return finder
 }
 
@@ -60,14 +69,46 @@ func NewEchonetFinder() Finder {
 	return NewEchonetFinderWithLocalNode(nil)
 }
 
+func init() {
+	mustRegisterFinder(FinderEchonet, func(opts *FinderOptions) (Finder, error) {
+		return NewEchonetFinderWithLocalNode(opts.LocalNode), nil
+	})
+}
+
 // Search searches all nodes.
 func (finder *EchonetFinder) Search() error {
-	err := finder.EchonetController.SearchAllObjects()
+	opts := NewSearchOptions()
+	opts.Wait = time.Second * echonetFinderSearchSleepSecond
+	_, err := finder.SearchContext(context.Background(), opts)
+	return err
+}
+
+// SearchContext searches all nodes with the specified options until the context is done, and returns the responding nodes.
+func (finder *EchonetFinder) SearchContext(ctx context.Context, opts *SearchOptions) ([]Node, error) {
+	if opts == nil {
+		opts = NewSearchOptions()
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	collector := finder.startSearchCollector(opts)
+	defer finder.stopSearchCollector(collector)
+
+	// Clears the found Echonet nodes to query all responding nodes again and refresh their last seen time.
+	err := finder.EchonetController.Clear()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	time.Sleep(time.Second * echonetFinderSearchSleepSecond)
-	return nil
+	err = finder.EchonetController.SearchAllObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	return collector.wait(ctx, opts)
 }
 
 // IsLocalNode returns true when the specified node is the local node, otherwise false.
@@ -81,11 +122,22 @@ func (finder *EchonetFinder) IsLocalNode(candidateNode node.Node) bool {
 
 // Start starts the finder.
 func (finder *EchonetFinder) Start() error {
-	return finder.EchonetController.Start()
+	err := finder.EchonetController.Start()
+	if err != nil {
+		return err
+	}
+	finder.startNodeSweeper()
+
+	finder.startDiscovery(finder)
+
+	return nil
 }
 
 // Stop stops the finder.
 func (finder *EchonetFinder) Stop() error {
+	finder.stopDiscovery()
+
+	finder.stopNodeSweeper()
 	return finder.EchonetController.Stop()
 }
 
@@ -100,11 +152,62 @@ func (finder *EchonetFinder) String() string {
 }
 
 func (finder *EchonetFinder) ControllerMessageReceived(msg *uecho_protocol.Message) {
-	if !msg.IsReadRequest() {
+	if msg.IsReadRequest() {
+		finder.EchonetController.EchonetDevice.UpdatePropertyWithNode(finder.localNode)
+		return
+	}
+
+	if !msg.IsReadResponse() && !msg.IsNotification() {
 		return
 	}
 
-	finder.EchonetController.EchonetDevice.UpdatePropertyWithNode(finder.localNode)
+	if msg.SEOJ() != finder_echonet.FinderDeviceCode {
+		return
+	}
+
+	candidateNode, err := finder_echonet.NewFinderNodeWithMessage(msg)
+	if err != nil {
+		return
+	}
+
+	if finder.IsLocalNode(candidateNode) {
+		return
+	}
+
+	if !finder.IsClusterMember(candidateNode) {
+		log.Tracef(msgEchonetFinderOutOfClusterNode, candidateNode.Address(), candidateNode.RPCPort(), candidateNode.Cluster())
+		return
+	}
+
+	if msg.IsNotification() {
+		finder.announcementReceived(candidateNode)
+		return
+	}
+
+	// Refreshes the last seen time of the known node
+	finder.updateNode(candidateNode)
+}
+
+// announcementReceived adds, updates or removes the announced node.
+func (finder *EchonetFinder) announcementReceived(announcedNode Node) {
+	log.Tracef(msgEchonetFinderAnnouncedNode, announcedNode.Address(), announcedNode.RPCPort(), announcedNode.Condition())
+
+	if announcedNode.Condition() == node.ConditionStop {
+		if !finder.HasNode(announcedNode) {
+			return
+		}
+		if err := finder.RemoveNode(announcedNode); err != nil {
+			log.Errorf("%s", err.Error())
+			return
+		}
+	} else if !finder.updateNode(announcedNode) {
+		if err := finder.addNode(announcedNode); err != nil {
+			log.Errorf("%s", err.Error())
+			return
+		}
+	}
+
+	finder.postNotification(announcedNode)
 }
 
 func (finder *EchonetFinder) ControllerNewNodeFound(echonetNode *uecho.RemoteNode) {
@@ -134,7 +237,13 @@ func (finder *EchonetFinder) ControllerNewNodeFound(echonetNode *uecho.RemoteNod
 		return
 	}
 
-	if finder.HasNode(candidateNode) {
+	if !finder.IsClusterMember(candidateNode) {
+		log.Tracef(msgEchonetFinderOutOfClusterNode, candidateNode.Address(), candidateNode.RPCPort(), candidateNode.Cluster())
+		return
+	}
+
+	if finder.updateNode(candidateNode) {
+		finder.postSearchResponse(candidateNode)
 		return
 	}
 
@@ -145,4 +254,6 @@ func (finder *EchonetFinder) ControllerNewNodeFound(echonetNode *uecho.RemoteNod
 		log.Errorf("%s", err.Error())
 		return
 	}
+
+	finder.postSearchResponse(candidateNode)
 }
@@ -15,11 +15,16 @@
 package finder
 
 import (
+	"net"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/cybergarage/go-finder/finder/echonet"
+	"github.com/cybergarage/go-finder/finder/node"
 	"github.com/cybergarage/go-logger/log"
+	uecho "github.com/cybergarage/uecho-go/net/echonet"
+	uecho_protocol "github.com/cybergarage/uecho-go/net/echonet/protocol"
 )
 
 func setupTestEchonetFinderNodes() ([]*echonet.EchonetNode, error) {
@@ -99,3 +104,166 @@ func TestEchonetFinder(t *testing.T) {
 		}
 	}
 }
+
+type testNotifyListener struct {
+	sync.Mutex
+	nodes []Node
+}
+
+func (l *testNotifyListener) FinderNotifyReceived(node *Node) {
+	l.Lock()
+	defer l.Unlock()
+	l.nodes = append(l.nodes, *node)
+}
+
+func (l *testNotifyListener) Nodes() []Node {
+	l.Lock()
+	defer l.Unlock()
+	return l.nodes
+}
+
+func postTestEchonetAnnouncement(t *testing.T, finder *EchonetFinder, msg *uecho.Message) {
+	t.Helper()
+	protoMsg, err := uecho_protocol.NewMessageWithBytes(msg.Bytes())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	finder.ControllerMessageReceived(protoMsg)
+}
+
+func TestEchonetFinderAnnouncement(t *testing.T) {
+	finder := NewEchonetFinder().(*EchonetFinder)
+
+	notifyListener := &testNotifyListener{}
+	finder.SetNotifyListener(notifyListener)
+
+	eventListener := &testEventListener{}
+	finder.AddEventListener(eventListener)
+
+	srcNode := node.NewBaseNode().SetHost("org.cybergarage.finder001").SetAddress(net.ParseIP("192.168.100.1")).SetRPCPort(8080)
+	srcNode.SetCondition(node.ConditionReady)
+
+	// Starting announcement
+
+	postTestEchonetAnnouncement(t, finder, echonet.NewAnnouncementMessageWithNode(srcNode))
+	if !finder.HasNode(srcNode) {
+		t.Errorf("%s is not added", srcNode.Host())
+	}
+
+	// Status change announcement
+
+	srcNode.SetClock(1)
+	postTestEchonetAnnouncement(t, finder, echonet.NewAnnouncementMessageWithNode(srcNode))
+	nodes, err := finder.GetAllNodes()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(nodes) != 1 || nodes[0].Clock() != srcNode.Clock() {
+		t.Errorf("%s is not updated", srcNode.Host())
+	}
+
+	// Stopping announcement
+
+	postTestEchonetAnnouncement(t, finder, echonet.NewStoppingAnnouncementMessageWithNode(srcNode))
+	if finder.HasNode(srcNode) {
+		t.Errorf("%s is not removed", srcNode.Host())
+	}
+
+	if len(notifyListener.Nodes()) != 3 {
+		t.Errorf(testFinderNodeCountError, len(notifyListener.Nodes()), 3)
+	}
+
+	expectedTypes := []EventType{NodeAdded, NodeUpdated, NodeRemoved}
+	events := eventListener.Events()
+	if len(events) != len(expectedTypes) {
+		t.Errorf(testFinderNodeCountError, len(events), len(expectedTypes))
+		return
+	}
+	for n, e := range events {
+		if e.Type != expectedTypes[n] {
+			t.Errorf(testFinderMatchingError, expectedTypes[n], e.Type)
+		}
+	}
+
+	// Stopping announcement of unknown nodes is ignored
+
+	postTestEchonetAnnouncement(t, finder, echonet.NewStoppingAnnouncementMessageWithNode(srcNode))
+	if len(notifyListener.Nodes()) != 3 {
+		t.Errorf(testFinderNodeCountError, len(notifyListener.Nodes()), 3)
+	}
+}
+
+func TestEchonetFinderClusterFilter(t *testing.T) {
+	localNode := node.NewBaseNode().SetCluster("production").SetHost("org.cybergarage.finder000").SetAddress(net.ParseIP("192.168.100.100"))
+	finder := NewEchonetFinderWithLocalNode(localNode).(*EchonetFinder)
+
+	clusterNodes := []*node.BaseNode{
+		node.NewBaseNode().SetCluster("production").SetHost("org.cybergarage.finder001").SetAddress(net.ParseIP("192.168.100.1")),
+		node.NewBaseNode().SetCluster("staging").SetHost("org.cybergarage.finder002").SetAddress(net.ParseIP("192.168.100.2")),
+	}
+	for _, clusterNode := range clusterNodes {
+		clusterNode.SetCondition(node.ConditionReady)
+		postTestEchonetAnnouncement(t, finder, echonet.NewAnnouncementMessageWithNode(clusterNode))
+	}
+
+	if !finder.HasNode(clusterNodes[0]) {
+		t.Errorf("%s is not added", clusterNodes[0].Host())
+	}
+	if finder.HasNode(clusterNodes[1]) {
+		t.Errorf("%s of %s is added", clusterNodes[1].Host(), clusterNodes[1].Cluster())
+	}
+
+	finder.ClearClusterFilter()
+	postTestEchonetAnnouncement(t, finder, echonet.NewAnnouncementMessageWithNode(clusterNodes[1]))
+	if !finder.HasNode(clusterNodes[1]) {
+		t.Errorf("%s is not added", clusterNodes[1].Host())
+	}
+}
+
+func TestEchonetFinderNodeAnnouncement(t *testing.T) {
+	finder := NewEchonetFinder().(*EchonetFinder)
+
+	err := finder.Start()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer finder.Stop()
+
+	srcNode := node.NewBaseNode().SetHost("org.cybergarage.finder001").SetAddress(net.ParseIP("192.168.100.1")).SetRPCPort(8080)
+	srcNode.SetCondition(node.ConditionReady)
+
+	echonetNode, err := echonet.NewEchonetNodeWithNode(srcNode)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = echonetNode.Start()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	time.Sleep(500 * time.Millisecond)
+
+	// Multicast announcements are not looped back on some hosts
+	if !finder.HasNode(srcNode) {
+		echonetNode.Stop()
+		t.Skipf("%s is not announced", srcNode.Host())
+	}
+
+	err = echonetNode.Stop()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	time.Sleep(500 * time.Millisecond)
+
+	if finder.HasNode(srcNode) {
+		t.Errorf("%s is not removed", srcNode.Host())
+	}
+}
@@ -14,8 +14,139 @@
 
 package finder
 
-// NewFinder returns a new finder.
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cybergarage/go-finder/finder/node"
+	"github.com/cybergarage/go-logger/log"
+)
+
+const (
+	// DefaultFinderType is the finder type which is created when the type is not specified.
+	DefaultFinderType = FinderEchonet
+)
+
+// The names of the finders which are not defined in the generated constants.
+const (
+	FinderStaticJson = "static_json"
+	FinderStaticYaml = "static_yaml"
+	FinderMulti      = "multi"
+	FinderMDNS       = "mdns"
+	FinderDNS        = "dns"
+	FinderGossip     = "gossip"
+	FinderHTTP       = "http"
+	FinderKV         = "kv"
+)
+
+const (
+	errorFinderFactoryUnknown     = "Unknown finder %q (registered finders: %s)"
+	errorFinderFactoryRegistered  = "Finder %q is already registered"
+	errorFinderFactoryInvalid     = "Invalid finder factory %q"
+	errorFinderFactoryNoFilename  = "Finder %q requires a configuration file"
+	errorFinderFactoryNoService   = "Finder %q requires a service name"
+	errorFinderFactoryNoLocalNode = "Finder %q requires a local node"
+	errorFinderFactoryNoURL       = "Finder %q requires a registry URL"
+)
+
+// FinderOptions represents options to create a finder by the factory.
+type FinderOptions struct {
+	// Config is the configuration of the finder.
+	Config FinderConfig
+	// LocalNode is the local node of the finder which announces the node.
+	LocalNode node.Node
+}
+
+// FinderFactory represents a function which creates a new finder with the options.
+type FinderFactory func(opts *FinderOptions) (Finder, error)
+
+var finderFactoryMutex sync.RWMutex
+var finderFactories = map[string]FinderFactory{}
+
+// RegisterFinder registers the specified factory with the finder name, and returns an error when the name is already registered.
+func RegisterFinder(name string, factory FinderFactory) error {
+	if name == "" || factory == nil {
+		return fmt.Errorf(errorFinderFactoryInvalid, name)
+	}
+	finderFactoryMutex.Lock()
+	defer finderFactoryMutex.Unlock()
+	if _, ok := finderFactories[name]; ok {
+		return fmt.Errorf(errorFinderFactoryRegistered, name)
+	}
+	finderFactories[name] = factory
+	return nil
+}
+
+// mustRegisterFinder registers the specified factory of the builtin finder, and panics when the registration is failed.
+func mustRegisterFinder(name string, factory FinderFactory) {
+	if err := RegisterFinder(name, factory); err != nil {
+		panic(err)
+	}
+}
+
+// UnregisterFinder removes the factory of the specified finder name.
+func UnregisterFinder(name string) {
+	finderFactoryMutex.Lock()
+	defer finderFactoryMutex.Unlock()
+	delete(finderFactories, name)
+}
+
+// RegisteredFinders returns the sorted names of the registered finders.
+func RegisteredFinders() []string {
+	finderFactoryMutex.RLock()
+	defer finderFactoryMutex.RUnlock()
+	names := make([]string, 0, len(finderFactories))
+	for name := range finderFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewFinder returns a new finder of DefaultFinderType, and returns nil when the default finder is not registered.
 func NewFinder() Finder {
-	finder := NewEchonetFinder()
+	finder, err := NewFinderByName(DefaultFinderType, nil)
+	if err != nil {
+		log.Errorf("%s", err.Error())
+		return nil
+	}
 	return finder
 }
+
+// NewFinderByName returns a new finder of the specified registered name with the options.
+func NewFinderByName(name string, opts *FinderOptions) (Finder, error) {
+	finderFactoryMutex.RLock()
+	factory, ok := finderFactories[name]
+	finderFactoryMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf(errorFinderFactoryUnknown, name, strings.Join(RegisteredFinders(), ", "))
+	}
+	if opts == nil {
+		opts = &FinderOptions{}
+	}
+	return factory(opts)
+}
+
+// NewFinderWithConfig returns a new finder of the type in the specified configuration, or DefaultFinderType when the type is not specified.
+func NewFinderWithConfig(config FinderConfig) (Finder, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	name := config.Type
+	if name == "" {
+		name = DefaultFinderType
+	}
+	return NewFinderByName(name, &FinderOptions{Config: config})
+}
+
+// newStaticFileFinderFactory returns a factory of the static finder with the configuration file in the specified format.
+func newStaticFileFinderFactory(format string) FinderFactory {
+	return func(opts *FinderOptions) (Finder, error) {
+		if opts.Config.File == "" {
+			return nil, fmt.Errorf(errorFinderFactoryNoFilename, format)
+		}
+		return newStaticFileFinder(format, opts.Config.File)
+	}
+}
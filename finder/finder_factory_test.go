@@ -0,0 +1,194 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFinderFactoryBuiltinFinders(t *testing.T) {
+	names := []string{
+		FinderEchonet,
+		FinderShared,
+		FinderStatic,
+		FinderStaticToml,
+		FinderStaticJson,
+		FinderStaticYaml,
+	}
+	registeredNames := strings.Join(RegisteredFinders(), ",")
+	for _, name := range names {
+		if !strings.Contains(registeredNames, name) {
+			t.Errorf("%s is not registered (%s)", name, registeredNames)
+		}
+	}
+
+	for _, name := range []string{FinderEchonet, FinderShared, FinderStatic} {
+		finder, err := NewFinderByName(name, nil)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !strings.HasPrefix(finder.String(), name) {
+			t.Errorf("%s != %s", finder.String(), name)
+		}
+	}
+}
+
+func TestFinderFactoryWithConfig(t *testing.T) {
+	testCases := []struct {
+		config FinderConfig
+		name   string
+		count  int
+	}{
+		{
+			config: FinderConfig{
+				Type: FinderStatic,
+				Nodes: []NodeConfig{
+					{Name: "org.cybergarage.finder001", Address: "192.168.100.1"},
+					{Name: "org.cybergarage.finder002", Address: "192.168.100.2"},
+				},
+			},
+			name:  FinderStatic,
+			count: 2,
+		},
+		{
+			config: FinderConfig{Type: FinderStaticJson, File: "finder_config_nodes_test.json"},
+			name:   FinderStaticJson,
+			count:  3,
+		},
+		{
+			config: FinderConfig{Type: FinderStaticYaml, File: "finder_config_nodes_test.yaml"},
+			name:   FinderStaticYaml,
+			count:  3,
+		},
+		{
+			config: FinderConfig{},
+			name:   DefaultFinderType,
+			count:  0,
+		},
+	}
+
+	for _, testCase := range testCases {
+		finder, err := NewFinderWithConfig(testCase.config)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !strings.HasPrefix(finder.String(), testCase.name) {
+			t.Errorf("%s != %s", finder.String(), testCase.name)
+		}
+		nodes, err := finder.GetAllNodes()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if len(nodes) != testCase.count {
+			t.Errorf(testFinderNodeCountError, len(nodes), testCase.count)
+		}
+	}
+}
+
+func TestFinderFactoryErrors(t *testing.T) {
+	_, err := NewFinderByName("unknown", nil)
+	if err == nil || !strings.Contains(err.Error(), "unknown") || !strings.Contains(err.Error(), FinderStatic) {
+		t.Errorf("%v", err)
+	}
+
+	_, err = NewFinderWithConfig(FinderConfig{Type: FinderStaticToml})
+	if err == nil || !strings.Contains(err.Error(), FinderStaticToml) {
+		t.Errorf("%v", err)
+	}
+
+	_, err = NewFinderWithConfig(FinderConfig{Type: FinderStatic, Nodes: []NodeConfig{{RPCPort: 8001}}})
+	if err == nil {
+		t.Errorf("%s : created with an invalid node", FinderStatic)
+	}
+
+	if err := RegisterFinder(FinderStatic, func(opts *FinderOptions) (Finder, error) { return nil, nil }); err == nil {
+		t.Errorf("%s : registered twice", FinderStatic)
+	}
+	if err := RegisterFinder("", func(opts *FinderOptions) (Finder, error) { return nil, nil }); err == nil {
+		t.Errorf("registered an empty name")
+	}
+	if err := RegisterFinder("nil", nil); err == nil {
+		t.Errorf("registered a nil factory")
+	}
+}
+
+func TestFinderFactoryCustomFinder(t *testing.T) {
+	name := "custom"
+	err := RegisterFinder(name, func(opts *FinderOptions) (Finder, error) {
+		return NewStaticFinderWithNodes(setupTestFinderNodes()), nil
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer UnregisterFinder(name)
+
+	finder, err := NewFinderWithConfig(FinderConfig{Type: name})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	nodes, err := finder.GetAllNodes()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(nodes) != len(testFinderNodeNames) {
+		t.Errorf(testFinderNodeCountError, len(nodes), len(testFinderNodeNames))
+	}
+
+	UnregisterFinder(name)
+	if _, err := NewFinderByName(name, nil); err == nil {
+		t.Errorf("%s : created after unregistered", name)
+	}
+}
+
+func TestFinderFactoryDefaultFinder(t *testing.T) {
+	finder := NewFinder()
+	if finder == nil || !strings.HasPrefix(finder.String(), DefaultFinderType) {
+		t.Errorf("%v != %s", finder, DefaultFinderType)
+	}
+
+	// NewFinder creates the default finder by the registered factory
+
+	finderFactoryMutex.RLock()
+	defaultFactory := finderFactories[DefaultFinderType]
+	finderFactoryMutex.RUnlock()
+	UnregisterFinder(DefaultFinderType)
+	defer func() {
+		UnregisterFinder(DefaultFinderType)
+		mustRegisterFinder(DefaultFinderType, defaultFactory)
+	}()
+
+	if finder := NewFinder(); finder != nil {
+		t.Errorf("%s : created after unregistered", DefaultFinderType)
+	}
+
+	err := RegisterFinder(DefaultFinderType, func(opts *FinderOptions) (Finder, error) {
+		return NewStaticFinderWithNodes(setupTestFinderNodes()), nil
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	finder = NewFinder()
+	if finder == nil || finder.String() != FinderStatic {
+		t.Errorf("%v != %s", finder, FinderStatic)
+	}
+}
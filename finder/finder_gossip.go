@@ -0,0 +1,335 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	finder_gossip "github.com/cybergarage/go-finder/finder/gossip"
+	"github.com/cybergarage/go-finder/finder/node"
+	"github.com/cybergarage/go-logger/log"
+)
+
+const (
+	gossipFinderSearchSleepSecond = 1
+	// gossipFinderTicksPerPingTimeout is the number of the protocol ticks in the ping timeout.
+	gossipFinderTicksPerPingTimeout = 4
+)
+
+const (
+	msgGossipFinderFoundNewNode     = "New finder node (%s:%d) is found"
+	msgGossipFinderLeftNode         = "Finder node (%s:%d) leaves"
+	msgGossipFinderReclaimedNode    = "Finder node (%s:%d) is reclaimed"
+	msgGossipFinderOutOfClusterNode = "Finder node (%s:%d) of cluster (%s) is ignored"
+)
+
+// GossipFinder represents a finder which maintains the membership of the finder nodes with the SWIM gossip protocol over UDP,
+// so the nodes are found across the subnets. The members which are not acked are suspected and then confirmed as dead,
+// and the nodes of the dead members are out of date until the members are reclaimed.
+type GossipFinder struct {
+	*baseFinder
+	localNode  node.Node
+	membership *finder_gossip.Membership
+	tickMutex  sync.Mutex
+	tickStop   chan struct{}
+	tickDone   chan struct{}
+	*discoveryRunner
+}
+
+// gossipMembershipListener applies the membership changes to the finder.
+type gossipMembershipListener struct {
+	finder *GossipFinder
+}
+
+// NewGossipFinderWithTransport returns a new finder of the specified local node which joins to the specified seed addresses on the transport.
+// The finder accepts only nodes of the same cluster as the local node.
+func NewGossipFinderWithTransport(localNode node.Node, transport finder_gossip.Transport, seeds ...string) Finder {
+	finder := &GossipFinder{
+		baseFinder:      newBaseFinder(),
+		localNode:       localNode,
+		membership:      finder_gossip.NewMembership(localNode, transport, finder_gossip.NewDefaultConfig()),
+		tickStop:        nil,
+		tickDone:        nil,
+		discoveryRunner: newDiscoveryRunner(),
+	}
+	finder.membership.SetSeeds(seeds...)
+	finder.membership.SetListener(&gossipMembershipListener{finder: finder})
+	finder.SetClusterFilter(localNode.Cluster())
+	return finder
+}
+
+// NewGossipFinderWithLocalNode returns a new finder of the specified local node which joins to the specified seeds over UDP.
+// The finder listens on the address of the local node with the gossip port of the local node, or the default gossip port when it is not set.
+// The seeds are the host names or the addresses, and the default gossip port is used when the port is omitted.
+func NewGossipFinderWithLocalNode(localNode node.Node, seeds ...string) Finder {
+	return NewGossipFinderWithTransport(localNode, finder_gossip.NewUDPTransport(gossipNodeAddress(localNode)), gossipSeedAddresses(seeds)...)
+}
+
+func init() {
+	mustRegisterFinder(FinderGossip, func(opts *FinderOptions) (Finder, error) {
+		if opts.LocalNode == nil || reflect.ValueOf(opts.LocalNode).IsNil() {
+			return nil, fmt.Errorf(errorFinderFactoryNoLocalNode, FinderGossip)
+		}
+		return NewGossipFinderWithLocalNode(opts.LocalNode, opts.Config.Seeds...), nil
+	})
+}
+
+// gossipNodeAddress returns the gossip address of the specified node.
+func gossipNodeAddress(localNode node.Node) string {
+	port, ok := localNode.Port(node.PortGossip)
+	if !ok {
+		port = finder_gossip.DefaultPort
+	}
+	host := ""
+	if addr := localNode.Address(); addr != nil {
+		host = addr.String()
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(port)))
+}
+
+// gossipSeedAddresses returns the gossip addresses of the specified seeds with the default gossip port when the port is omitted.
+func gossipSeedAddresses(seeds []string) []string {
+	addrs := make([]string, 0, len(seeds))
+	for _, seed := range seeds {
+		if _, _, err := net.SplitHostPort(seed); err != nil {
+			seed = net.JoinHostPort(seed, strconv.Itoa(finder_gossip.DefaultPort))
+		}
+		addrs = append(addrs, seed)
+	}
+	return addrs
+}
+
+// SetGossipConfig sets the parameters of the SWIM protocol, and it must be set before the finder is started.
+func (finder *GossipFinder) SetGossipConfig(config *finder_gossip.Config) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	finder.membership.SetConfig(config)
+	return nil
+}
+
+// Address returns the gossip address of the local node.
+func (finder *GossipFinder) Address() string {
+	return finder.membership.Address()
+}
+
+// Members returns the gossip members except the local node.
+func (finder *GossipFinder) Members() []*finder_gossip.Member {
+	return finder.membership.Members()
+}
+
+// Search searches all nodes.
+func (finder *GossipFinder) Search() error {
+	opts := NewSearchOptions()
+	opts.Wait = time.Second * gossipFinderSearchSleepSecond
+	_, err := finder.SearchContext(context.Background(), opts)
+	return err
+}
+
+// SearchContext syncs the membership with the seeds and the active members until the context is done, and returns the synced nodes.
+func (finder *GossipFinder) SearchContext(ctx context.Context, opts *SearchOptions) ([]Node, error) {
+	if opts == nil {
+		opts = NewSearchOptions()
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	collector := finder.startSearchCollector(opts)
+	defer finder.stopSearchCollector(collector)
+
+	if err := finder.membership.Sync(); err != nil {
+		return nil, err
+	}
+
+	return collector.wait(ctx, opts)
+}
+
+// Start joins to the seeds with the local node which is changed to the bootstrap condition, changes the local node to the ready condition,
+// and starts probing the members.
+func (finder *GossipFinder) Start() error {
+	if err := finder.startMembership(); err != nil {
+		return err
+	}
+	finder.startNodeSweeper()
+	finder.startTicker()
+
+	finder.startDiscovery(finder)
+
+	return nil
+}
+
+// Stop changes the local node to the stop condition, tells the members that the local node leaves, and stops the finder.
+func (finder *GossipFinder) Stop() error {
+	finder.stopDiscovery()
+
+	finder.stopTicker()
+	finder.stopNodeSweeper()
+	return finder.stopMembership()
+}
+
+// startMembership joins to the seeds with the local node which is changed to the bootstrap condition,
+// and changes the local node to the ready condition which is disseminated by the next protocol tick.
+func (finder *GossipFinder) startMembership() error {
+	if err := node.Transit(finder.localNode, node.ConditionBootstrap); err != nil {
+		return err
+	}
+	if err := finder.membership.Start(); err != nil {
+		if stopErr := node.Transit(finder.localNode, node.ConditionStop); stopErr != nil {
+			log.Errorf("%s", stopErr.Error())
+		}
+		return err
+	}
+	return node.Transit(finder.localNode, node.ConditionReady)
+}
+
+// stopMembership changes the local node to the stop condition, and tells the members that the local node leaves.
+func (finder *GossipFinder) stopMembership() error {
+	if err := node.Transit(finder.localNode, node.ConditionStop); err != nil {
+		log.Errorf("%s", err.Error())
+	}
+	return finder.membership.Stop()
+}
+
+// IsRunning returns true when the finder is running, otherwise false.
+func (finder *GossipFinder) IsRunning() bool {
+	return finder.membership.IsRunning()
+}
+
+// String returns the description.
+func (finder *GossipFinder) String() string {
+	return FinderGossip
+}
+
+// startTicker starts driving the protocol.
+func (finder *GossipFinder) startTicker() {
+	finder.tickMutex.Lock()
+	defer finder.tickMutex.Unlock()
+
+	if finder.tickStop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	finder.tickStop = stop
+	finder.tickDone = done
+
+	interval := finder.membership.Config().PingTimeout / gossipFinderTicksPerPingTimeout
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				finder.membership.Tick(now)
+			}
+		}
+	}()
+}
+
+// stopTicker stops driving the protocol and waits until the ticker is terminated.
+func (finder *GossipFinder) stopTicker() {
+	finder.tickMutex.Lock()
+	defer finder.tickMutex.Unlock()
+
+	if finder.tickStop == nil {
+		return
+	}
+	close(finder.tickStop)
+	<-finder.tickDone
+	finder.tickStop = nil
+	finder.tickDone = nil
+}
+
+// MemberChanged adds, updates or removes the node of the changed member, and posts the node to the notify listener.
+// The nodes of the suspected members are kept, and the nodes of the dead members are out of date.
+func (listener *gossipMembershipListener) MemberChanged(member *finder_gossip.Member) {
+	finder := listener.finder
+	memberNode := member.Node()
+
+	if !finder.IsClusterMember(memberNode) {
+		log.Tracef(msgGossipFinderOutOfClusterNode, memberNode.Address(), memberNode.RPCPort(), memberNode.Cluster())
+		return
+	}
+
+	if member.State == finder_gossip.StateLeft {
+		log.Tracef(msgGossipFinderLeftNode, memberNode.Address(), memberNode.RPCPort())
+		if !finder.HasNode(memberNode) {
+			return
+		}
+		if err := finder.RemoveNode(memberNode); err != nil {
+			log.Errorf("%s", err.Error())
+			return
+		}
+		finder.postNotification(memberNode)
+		return
+	}
+
+	if !finder.updateNode(memberNode) {
+		log.Infof(msgGossipFinderFoundNewNode, memberNode.Address(), memberNode.RPCPort())
+		if err := finder.addNode(memberNode); err != nil {
+			log.Errorf("%s", err.Error())
+			return
+		}
+	}
+
+	finder.postNotification(memberNode)
+}
+
+// MembersSynced adds or updates the nodes of the synced members, and posts the nodes to the running searches and the search listener.
+func (listener *gossipMembershipListener) MembersSynced(members []*finder_gossip.Member) {
+	finder := listener.finder
+	for _, member := range members {
+		memberNode := member.Node()
+		if !finder.IsClusterMember(memberNode) {
+			continue
+		}
+		if !finder.updateNode(memberNode) {
+			if err := finder.addNode(memberNode); err != nil {
+				log.Errorf("%s", err.Error())
+				continue
+			}
+		}
+		finder.postSearchResponse(memberNode)
+	}
+}
+
+// MemberReclaimed removes the node of the reclaimed member, and posts the node to the notify listener.
+func (listener *gossipMembershipListener) MemberReclaimed(member *finder_gossip.Member) {
+	finder := listener.finder
+	memberNode := member.Node()
+	if !finder.HasNode(memberNode) {
+		return
+	}
+	log.Tracef(msgGossipFinderReclaimedNode, memberNode.Address(), memberNode.RPCPort())
+	if err := finder.RemoveNode(memberNode); err != nil {
+		log.Errorf("%s", err.Error())
+		return
+	}
+	finder.postNotification(memberNode)
+}
@@ -0,0 +1,220 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	finder_gossip "github.com/cybergarage/go-finder/finder/gossip"
+	"github.com/cybergarage/go-finder/finder/node"
+)
+
+func setupTestGossipFinders(t *testing.T, network *finder_gossip.MemoryNetwork, clusters ...string) []*GossipFinder {
+	t.Helper()
+	finders := []*GossipFinder{}
+	seed := ""
+	for n, cluster := range clusters {
+		localNode := node.NewBaseNode().SetCluster(cluster).SetHost(fmt.Sprintf("org.cybergarage.gossip%03d", n+1)).SetAddress(net.ParseIP(fmt.Sprintf("192.168.100.%d", n+1))).SetRPCPort(8001)
+		transport := network.NewTransport()
+		if seed == "" {
+			seed = transport.Address()
+		}
+		finders = append(finders, NewGossipFinderWithTransport(localNode, transport, seed).(*GossipFinder))
+	}
+	return finders
+}
+
+// tickTestGossipFinders drives the protocol of the specified finders deterministically until the specified duration is elapsed.
+func tickTestGossipFinders(finders []*GossipFinder, now time.Time, d time.Duration) time.Time {
+	for end := now.Add(d); now.Before(end); {
+		now = now.Add(finder_gossip.DefaultPingTimeout / gossipFinderTicksPerPingTimeout)
+		for _, finder := range finders {
+			finder.membership.Tick(now)
+		}
+	}
+	return now
+}
+
+func TestGossipFinder(t *testing.T) {
+	network := finder_gossip.NewMemoryNetwork()
+	finders := setupTestGossipFinders(t, network, "cluster", "cluster", "cluster", "other")
+
+	notifyListener := &testNotifyListener{}
+	finders[0].SetNotifyListener(notifyListener)
+	finders[0].SetConditionFilter(node.ConditionReady)
+
+	for _, finder := range finders {
+		if err := finder.startMembership(); err != nil {
+			t.Error(err)
+			return
+		}
+		defer finder.stopMembership()
+		if cond := finder.localNode.Condition(); cond != node.ConditionReady {
+			t.Errorf(testFinderMatchingError, node.ConditionReady, cond)
+		}
+	}
+
+	// The joined members are disseminated with the ready condition, and only nodes of the same cluster are added
+
+	now := tickTestGossipFinders(finders, time.Now(), 5*time.Second)
+	for _, finder := range finders[:3] {
+		nodes, _ := finder.GetAllNodes()
+		if len(nodes) != 2 {
+			t.Errorf(testFinderNodeCountError, len(nodes), 2)
+		}
+	}
+
+	// The node of the unreachable member is out of date after the suspicion timeout
+
+	for _, finder := range []*GossipFinder{finders[0], finders[1], finders[3]} {
+		network.SetReachable(finder.Address(), finders[2].Address(), false)
+	}
+	now = tickTestGossipFinders(finders, now, 3*time.Second)
+	if nodes, _ := finders[0].GetAllNodes(); len(nodes) != 2 {
+		t.Errorf(testFinderNodeCountError, len(nodes), 2)
+	}
+
+	// The node of the suspected member is excluded by the state label
+
+	selector := finder_gossip.LabelState + "!=" + finder_gossip.StateSuspect.String()
+	if nodes, _ := finders[0].GetSelectorNodes(selector); len(nodes) != 1 {
+		t.Errorf(testFinderMatchingCountError, selector, len(nodes), 1)
+	}
+
+	now = tickTestGossipFinders(finders, now, finder_gossip.DefaultSuspicionTimeout+4*time.Second)
+	if nodes, _ := finders[0].GetAllNodes(); len(nodes) != 1 {
+		t.Errorf(testFinderNodeCountError, len(nodes), 1)
+	}
+	outOfDateNodes := 0
+	for _, foundNode := range finders[0].allNodes() {
+		if foundNode.Condition() == node.ConditionOutOfDate {
+			outOfDateNodes++
+		}
+	}
+	if outOfDateNodes != 1 {
+		t.Errorf(testFinderNodeCountError, outOfDateNodes, 1)
+	}
+	notifiedNodes := notifyListener.Nodes()
+	if len(notifiedNodes) == 0 || notifiedNodes[len(notifiedNodes)-1].Condition() != node.ConditionOutOfDate {
+		t.Errorf("%v", notifiedNodes)
+	}
+
+	// The node is ready again when the member refutes the death
+
+	for _, finder := range []*GossipFinder{finders[0], finders[1], finders[3]} {
+		network.SetReachable(finder.Address(), finders[2].Address(), true)
+	}
+	now = tickTestGossipFinders(finders, now, 10*time.Second)
+	if nodes, _ := finders[0].GetAllNodes(); len(nodes) != 2 {
+		t.Errorf(testFinderNodeCountError, len(nodes), 2)
+	}
+
+	// The node of the left member is removed
+
+	if err := finders[2].stopMembership(); err != nil {
+		t.Error(err)
+	}
+	if cond := finders[2].localNode.Condition(); cond != node.ConditionStop {
+		t.Errorf(testFinderMatchingError, node.ConditionStop, cond)
+	}
+	tickTestGossipFinders(finders[:2], now, time.Second)
+	for _, finder := range finders[:2] {
+		if nodes := finder.allNodes(); len(nodes) != 1 {
+			t.Errorf(testFinderNodeCountError, len(nodes), 1)
+		}
+	}
+}
+
+func TestGossipFinderReclaim(t *testing.T) {
+	network := finder_gossip.NewMemoryNetwork()
+	finders := setupTestGossipFinders(t, network, "cluster", "cluster", "cluster")
+
+	for _, finder := range finders {
+		if err := finder.startMembership(); err != nil {
+			t.Error(err)
+			return
+		}
+		defer finder.stopMembership()
+	}
+	now := tickTestGossipFinders(finders, time.Now(), 5*time.Second)
+
+	// The out of date node of the dead member is removed after the reclaim timeout
+
+	for _, finder := range finders[:2] {
+		network.SetReachable(finder.Address(), finders[2].Address(), false)
+	}
+	now = tickTestGossipFinders(finders, now, finder_gossip.DefaultSuspicionTimeout+6*time.Second)
+	for _, finder := range finders[:2] {
+		if nodes := finder.allNodes(); len(nodes) != 2 {
+			t.Errorf(testFinderNodeCountError, len(nodes), 2)
+		}
+	}
+
+	notifyListener := &testNotifyListener{}
+	finders[0].SetNotifyListener(notifyListener)
+
+	tickTestGossipFinders(finders, now, finder_gossip.DefaultReclaimTimeout)
+	for _, finder := range finders[:2] {
+		if nodes := finder.allNodes(); len(nodes) != 1 {
+			t.Errorf(testFinderNodeCountError, len(nodes), 1)
+		}
+	}
+	if notifiedNodes := notifyListener.Nodes(); len(notifiedNodes) != 1 || !node.Equal(notifiedNodes[0], finders[2].localNode) {
+		t.Errorf("%v", notifiedNodes)
+	}
+}
+
+func TestGossipFinderSearch(t *testing.T) {
+	network := finder_gossip.NewMemoryNetwork()
+	finders := setupTestGossipFinders(t, network, "cluster", "cluster")
+
+	for _, finder := range finders {
+		if err := finder.Start(); err != nil {
+			t.Error(err)
+			return
+		}
+		defer finder.Stop()
+	}
+
+	opts := NewSearchOptions()
+	opts.Wait = 5 * time.Second
+	opts.MaxResponses = 1
+	nodes, err := finders[1].SearchContext(context.Background(), opts)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(nodes) != 1 || !node.Equal(nodes[0], finders[0].localNode) {
+		t.Errorf(testFinderNodeCountError, len(nodes), 1)
+	}
+}
+
+func TestGossipSeedAddresses(t *testing.T) {
+	seeds := map[string]string{
+		"192.168.100.1":      "192.168.100.1:7946",
+		"192.168.100.1:8000": "192.168.100.1:8000",
+		"fe80::1":            "[fe80::1]:7946",
+		"gossip.local":       "gossip.local:7946",
+	}
+	for seed, expected := range seeds {
+		if addrs := gossipSeedAddresses([]string{seed}); len(addrs) != 1 || addrs[0] != expected {
+			t.Errorf(testFinderMatchingError, seed, addrs)
+		}
+	}
+}
@@ -0,0 +1,418 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cybergarage/go-finder/finder/node"
+	"github.com/cybergarage/go-finder/finder/registry"
+	"github.com/cybergarage/go-logger/log"
+)
+
+const (
+	// DefaultHTTPPollInterval is the default min interval of the requests to watch the registry.
+	DefaultHTTPPollInterval = time.Second
+	// DefaultHTTPLongPollWait is the default wait of the long polling requests.
+	DefaultHTTPLongPollWait = 30 * time.Second
+	// httpFinderHeartbeatsPerTTL is the number of the heartbeats in the time-to-live of the registration.
+	httpFinderHeartbeatsPerTTL = 3
+	// httpFinderRequestTimeout is the timeout of the requests except the long polling wait.
+	httpFinderRequestTimeout = 10 * time.Second
+)
+
+const (
+	errorHTTPFinderInvalidInterval = "Invalid HTTP poll interval : %s"
+	errorHTTPFinderInvalidWait     = "Invalid HTTP long poll wait : %s"
+	errorHTTPFinderBadStatus       = "%s %s : %s"
+)
+
+const (
+	msgHTTPFinderRegistrationFailed = "Local node is not registered to %s : %s"
+	msgHTTPFinderWatchFailed        = "Nodes are not fetched from %s : %s"
+)
+
+// HTTPFinder represents a finder which registers the local node to an HTTP registry with the heartbeats,
+// and watches the registered nodes by polling or long polling the registry.
+type HTTPFinder struct {
+	*baseFinder
+	url          string
+	localNode    node.Node
+	client       *http.Client
+	configMutex  sync.Mutex
+	pollInterval time.Duration
+	longPollWait time.Duration
+	loopMutex    sync.Mutex
+	loopCancel   context.CancelFunc
+	loopDone     sync.WaitGroup
+	*discoveryRunner
+}
+
+// NewHTTPFinderWithLocalNode returns a new finder of the registry URL such as "http://registry.example.com:8080" which registers the specified local node.
+// The local node is not registered when it is nil, and the finder accepts only nodes of the same cluster as the local node.
+func NewHTTPFinderWithLocalNode(registryURL string, localNode node.Node) Finder {
+	finder := &HTTPFinder{
+		baseFinder:      newBaseFinder(),
+		url:             registryURL,
+		localNode:       localNode,
+		client:          http.DefaultClient,
+		pollInterval:    DefaultHTTPPollInterval,
+		longPollWait:    DefaultHTTPLongPollWait,
+		loopCancel:      nil,
+		discoveryRunner: newDiscoveryRunner(),
+	}
+	if finder.hasLocalNode() {
+		finder.SetClusterFilter(localNode.Cluster())
+	}
+	return finder
+}
+
+// NewHTTPFinder returns a new finder of the registry URL which watches only.
+func NewHTTPFinder(registryURL string) Finder {
+	return NewHTTPFinderWithLocalNode(registryURL, nil)
+}
+
+func init() {
+	mustRegisterFinder(FinderHTTP, func(opts *FinderOptions) (Finder, error) {
+		if opts.Config.URL == "" {
+			return nil, fmt.Errorf(errorFinderFactoryNoURL, FinderHTTP)
+		}
+		return NewHTTPFinderWithLocalNode(opts.Config.URL, opts.LocalNode), nil
+	})
+}
+
+// hasLocalNode returns true when the finder has the local node, otherwise false.
+func (finder *HTTPFinder) hasLocalNode() bool {
+	return finder.localNode != nil && !reflect.ValueOf(finder.localNode).IsNil()
+}
+
+// URL returns the registry URL.
+func (finder *HTTPFinder) URL() string {
+	return finder.url
+}
+
+// SetHTTPClient sets the client of the registry requests.
+func (finder *HTTPFinder) SetHTTPClient(client *http.Client) {
+	finder.configMutex.Lock()
+	defer finder.configMutex.Unlock()
+	finder.client = client
+}
+
+// SetPollInterval sets the min interval of the requests to watch the registry.
+func (finder *HTTPFinder) SetPollInterval(interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf(errorHTTPFinderInvalidInterval, interval)
+	}
+	finder.configMutex.Lock()
+	defer finder.configMutex.Unlock()
+	finder.pollInterval = interval
+	return nil
+}
+
+// SetLongPollWait sets the wait of the long polling requests, and zero disables the long polling so the registry is polled at the poll interval.
+func (finder *HTTPFinder) SetLongPollWait(wait time.Duration) error {
+	if wait < 0 {
+		return fmt.Errorf(errorHTTPFinderInvalidWait, wait)
+	}
+	finder.configMutex.Lock()
+	defer finder.configMutex.Unlock()
+	finder.longPollWait = wait
+	return nil
+}
+
+// config returns the client, the poll interval and the long poll wait.
+func (finder *HTTPFinder) config() (*http.Client, time.Duration, time.Duration) {
+	finder.configMutex.Lock()
+	defer finder.configMutex.Unlock()
+	return finder.client, finder.pollInterval, finder.longPollWait
+}
+
+// nodesURL returns the URL of the registered nodes with the specified path elements.
+func (finder *HTTPFinder) nodesURL(elem ...string) (string, error) {
+	return url.JoinPath(finder.url, append([]string{registry.NodesPath}, elem...)...)
+}
+
+// do sends the specified request, and decodes the JSON response to the specified value unless it is nil.
+func (finder *HTTPFinder) do(req *http.Request, v any) error {
+	client, _, _ := finder.config()
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < http.StatusOK || http.StatusMultipleChoices <= res.StatusCode {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf(errorHTTPFinderBadStatus, req.Method, req.URL, bytes.TrimSpace(body))
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// register registers the local node, and returns the registration.
+func (finder *HTTPFinder) register(ctx context.Context) (*registry.Registration, error) {
+	nodesURL, err := finder.nodesURL()
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(registry.NewNodeWithNode(finder.localNode))
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, httpFinderRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, nodesURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	registration := &registry.Registration{}
+	if err := finder.do(req, registration); err != nil {
+		return nil, err
+	}
+	return registration, nil
+}
+
+// deregister deregisters the local node.
+func (finder *HTTPFinder) deregister(ctx context.Context) error {
+	nodeURL, err := finder.nodesURL(registry.NewNodeWithNode(finder.localNode).ID())
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, httpFinderRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, nodeURL, nil)
+	if err != nil {
+		return err
+	}
+	return finder.do(req, nil)
+}
+
+// fetchNodes returns the registered nodes, and waits until the version is changed from the specified version when the wait is positive.
+func (finder *HTTPFinder) fetchNodes(ctx context.Context, version uint64, wait time.Duration) (*registry.NodeList, error) {
+	nodesURL, err := finder.nodesURL()
+	if err != nil {
+		return nil, err
+	}
+	if 0 < wait {
+		query := url.Values{}
+		query.Set(registry.VersionParam, strconv.FormatUint(version, 10))
+		query.Set(registry.WaitParam, wait.String())
+		nodesURL += "?" + query.Encode()
+	}
+	ctx, cancel := context.WithTimeout(ctx, wait+httpFinderRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, nodesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	list := &registry.NodeList{}
+	if err := finder.do(req, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// applyNodeList replaces the nodes with the registered nodes except the local node by applyNodes, and returns the registered nodes.
+func (finder *HTTPFinder) applyNodeList(list *registry.NodeList) []Node {
+	localID := ""
+	if finder.hasLocalNode() {
+		localID = registry.NewNodeWithNode(finder.localNode).ID()
+	}
+
+	nodes := make([]Node, 0, len(list.Nodes))
+	for _, n := range list.Nodes {
+		if n.ID() == localID {
+			continue
+		}
+		nodes = append(nodes, n.Node())
+	}
+
+	finder.applyNodes(nodes)
+
+	return nodes
+}
+
+// Search fetches all registered nodes.
+func (finder *HTTPFinder) Search() error {
+	_, err := finder.SearchContext(context.Background(), NewSearchOptions())
+	return err
+}
+
+// SearchContext fetches all registered nodes, posts them to the search listener, and returns all nodes.
+func (finder *HTTPFinder) SearchContext(ctx context.Context, opts *SearchOptions) ([]Node, error) {
+	if opts == nil {
+		opts = NewSearchOptions()
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	list, err := finder.fetchNodes(ctx, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range finder.applyNodeList(list) {
+		if finder.IsClusterMember(n) {
+			finder.postSearchResponse(n)
+		}
+	}
+
+	return finder.GetAllNodes()
+}
+
+// Start registers the local node which is changed to the ready condition, and starts the heartbeats and watching the registry.
+// The finder is started even if the registry is not available, and the registration is retried by the heartbeats.
+func (finder *HTTPFinder) Start() error {
+	finder.loopMutex.Lock()
+	if finder.loopCancel != nil {
+		finder.loopMutex.Unlock()
+		return nil
+	}
+	if finder.hasLocalNode() {
+		if err := node.Transit(finder.localNode, node.ConditionBootstrap, node.ConditionReady); err != nil {
+			finder.loopMutex.Unlock()
+			return err
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	finder.loopCancel = cancel
+	if finder.hasLocalNode() {
+		finder.loopDone.Add(1)
+		go finder.heartbeat(ctx)
+	}
+	finder.loopDone.Add(1)
+	go finder.watch(ctx)
+	finder.loopMutex.Unlock()
+
+	finder.startNodeSweeper()
+
+	finder.startDiscovery(finder)
+
+	return nil
+}
+
+// Stop stops watching the registry, and deregisters the local node which is changed to the stop condition.
+func (finder *HTTPFinder) Stop() error {
+	finder.stopDiscovery()
+
+	finder.loopMutex.Lock()
+	cancel := finder.loopCancel
+	finder.loopCancel = nil
+	finder.loopMutex.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	finder.loopDone.Wait()
+
+	finder.stopNodeSweeper()
+
+	if !finder.hasLocalNode() {
+		return nil
+	}
+	if err := node.Transit(finder.localNode, node.ConditionStop); err != nil {
+		log.Errorf("%s", err.Error())
+	}
+	return finder.deregister(context.Background())
+}
+
+// IsRunning returns true when the finder is running, otherwise false.
+func (finder *HTTPFinder) IsRunning() bool {
+	finder.loopMutex.Lock()
+	defer finder.loopMutex.Unlock()
+	return finder.loopCancel != nil
+}
+
+// String returns the description.
+func (finder *HTTPFinder) String() string {
+	return FinderHTTP
+}
+
+// sleepContext sleeps the specified duration, and returns false when the context is done.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// heartbeat registers the local node repeatedly before the registration expires until the context is done.
+func (finder *HTTPFinder) heartbeat(ctx context.Context) {
+	defer finder.loopDone.Done()
+
+	interval := registry.DefaultTTL / httpFinderHeartbeatsPerTTL
+	for {
+		registration, err := finder.register(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			log.Warnf(msgHTTPFinderRegistrationFailed, finder.url, err.Error())
+		case 0 < registration.TTL:
+			interval = time.Duration(registration.TTL*float64(time.Second)) / httpFinderHeartbeatsPerTTL
+		}
+		if !sleepContext(ctx, interval) {
+			return
+		}
+	}
+}
+
+// watch applies the registered nodes whenever the version is changed until the context is done.
+// The requests are long polling unless the wait is zero, and they are sent at the poll interval at most.
+func (finder *HTTPFinder) watch(ctx context.Context) {
+	defer finder.loopDone.Done()
+
+	var version uint64
+	isApplied := false
+	for {
+		_, pollInterval, longPollWait := finder.config()
+		start := time.Now()
+		list, err := finder.fetchNodes(ctx, version, longPollWait)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			log.Warnf(msgHTTPFinderWatchFailed, finder.url, err.Error())
+		case !isApplied || list.Version != version:
+			finder.applyNodeList(list)
+			version = list.Version
+			isApplied = true
+		}
+		if elapsed := time.Since(start); elapsed < pollInterval {
+			if !sleepContext(ctx, pollInterval-elapsed) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-finder/finder/node"
+	"github.com/cybergarage/go-finder/finder/registry"
+)
+
+const (
+	testHTTPFinderPollInterval = 10 * time.Millisecond
+)
+
+type testSearchListener struct {
+	sync.Mutex
+	nodes []Node
+}
+
+func (l *testSearchListener) FinderSearchResponseReceived(node *Node) {
+	l.Lock()
+	defer l.Unlock()
+	l.nodes = append(l.nodes, *node)
+}
+
+func (l *testSearchListener) Nodes() []Node {
+	l.Lock()
+	defer l.Unlock()
+	return l.nodes
+}
+
+func setupTestHTTPFinder(t *testing.T, registryURL string, cluster string, n int) *HTTPFinder {
+	t.Helper()
+	localNode := node.NewBaseNode().SetCluster(cluster).SetHost(fmt.Sprintf("org.cybergarage.http%03d", n)).SetAddress(net.ParseIP(fmt.Sprintf("192.168.100.%d", n))).SetRPCPort(8001)
+	localNode.SetPort(node.PortCarbon, 2003)
+	finder := NewHTTPFinderWithLocalNode(registryURL, localNode).(*HTTPFinder)
+	if err := finder.SetPollInterval(testHTTPFinderPollInterval); err != nil {
+		t.Fatal(err)
+	}
+	return finder
+}
+
+// waitTestHTTPFinderNodes waits until the specified finder has the specified number of the nodes.
+func waitTestHTTPFinderNodes(finder Finder, n int) int {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		nodes, _ := finder.GetAllNodes()
+		if len(nodes) == n || deadline.Before(time.Now()) {
+			return len(nodes)
+		}
+		time.Sleep(testHTTPFinderPollInterval)
+	}
+}
+
+func waitTestNotifiedNodes(listener *testNotifyListener, n int) int {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		nodes := listener.Nodes()
+		if len(nodes) == n || deadline.Before(time.Now()) {
+			return len(nodes)
+		}
+		time.Sleep(testHTTPFinderPollInterval)
+	}
+}
+
+func TestHTTPFinder(t *testing.T) {
+	server := httptest.NewServer(registry.NewHandler(registry.NewRegistry()))
+	defer server.Close()
+
+	finders := []*HTTPFinder{
+		setupTestHTTPFinder(t, server.URL, "cluster", 1),
+		setupTestHTTPFinder(t, server.URL, "cluster", 2),
+		setupTestHTTPFinder(t, server.URL, "other", 3),
+	}
+	if err := finders[1].SetLongPollWait(0); err != nil {
+		t.Error(err)
+		return
+	}
+
+	notifyListener := &testNotifyListener{}
+	finders[0].SetNotifyListener(notifyListener)
+
+	for _, finder := range finders {
+		if err := finder.Start(); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	defer finders[0].Stop()
+	defer finders[2].Stop()
+
+	// The registered nodes of the same cluster are found by the long polling and the polling
+
+	for _, finder := range finders[:2] {
+		if n := waitTestHTTPFinderNodes(finder, 1); n != 1 {
+			t.Errorf(testFinderNodeCountError, n, 1)
+		}
+	}
+	if len(notifyListener.Nodes()) == 0 {
+		t.Errorf(testFinderNodeCountError, len(notifyListener.Nodes()), 1)
+	}
+
+	nodes, _ := finders[0].GetAllNodes()
+	if 0 < len(nodes) {
+		if port, ok := nodes[0].Port(node.PortCarbon); !ok || port != 2003 {
+			t.Errorf("%d != %d", port, 2003)
+		}
+		if !node.Equal(nodes[0], finders[1].localNode) {
+			t.Errorf(testFinderMatchingError, finders[1].localNode.Host(), nodes[0].Host())
+		}
+	}
+
+	// The node is removed and notified when it is deregistered
+
+	notifiedCount := len(notifyListener.Nodes())
+	if err := finders[1].Stop(); err != nil {
+		t.Error(err)
+	}
+	if n := waitTestHTTPFinderNodes(finders[0], 0); n != 0 {
+		t.Errorf(testFinderNodeCountError, n, 0)
+	}
+	if n := waitTestNotifiedNodes(notifyListener, notifiedCount+1); n != notifiedCount+1 {
+		t.Errorf(testFinderNodeCountError, n, notifiedCount+1)
+	}
+}
+
+func TestHTTPFinderSearch(t *testing.T) {
+	handler := registry.NewHandler(registry.NewRegistry())
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	for n := 1; n <= 3; n++ {
+		registeredNode := &registry.Node{
+			Cluster:   "cluster",
+			Host:      fmt.Sprintf("org.cybergarage.http%03d", n),
+			Address:   fmt.Sprintf("192.168.100.%d", n),
+			RPCPort:   8001,
+			Condition: node.ConditionReady,
+		}
+		if n == 3 {
+			registeredNode.Condition = node.ConditionOutOfDate
+		}
+		if _, err := handler.Registry().Register(registeredNode); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	// The watch-only finder finds all ready nodes with the ready filter
+
+	finder := NewHTTPFinder(server.URL)
+	finder.(*HTTPFinder).SetConditionFilter(node.ConditionReady)
+	searchListener := &testSearchListener{}
+	finder.SetSearchListener(searchListener)
+	nodes, err := finder.SearchContext(context.Background(), nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(nodes) != 2 {
+		t.Errorf(testFinderNodeCountError, len(nodes), 2)
+	}
+	if n := len(searchListener.Nodes()); n != 3 {
+		t.Errorf(testFinderNodeCountError, n, 3)
+	}
+
+	// The search fails when the registry is not available
+
+	server.Close()
+	if _, err := finder.SearchContext(context.Background(), nil); err == nil {
+		t.Errorf("%s is available", finder.(*HTTPFinder).URL())
+	}
+}
@@ -0,0 +1,418 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cybergarage/go-finder/finder/node"
+	"github.com/cybergarage/go-logger/log"
+)
+
+const (
+	// DefaultKVLeaseTTL is the default time-to-live of the lease of the local node key.
+	DefaultKVLeaseTTL = 30 * time.Second
+	// DefaultKVRetryInterval is the default interval to retry watching the store.
+	DefaultKVRetryInterval = time.Second
+	// kvFinderKeepAlivesPerTTL is the number of the keep-alives in the time-to-live of the lease.
+	kvFinderKeepAlivesPerTTL = 3
+	// kvFinderKeySeparator is the separator of the key elements.
+	kvFinderKeySeparator = "/"
+)
+
+const (
+	errorKVFinderInvalidTTL      = "Invalid KV lease TTL : %s"
+	errorKVFinderInvalidInterval = "Invalid KV retry interval : %s"
+)
+
+const (
+	msgKVFinderRegistrationFailed = "Local node is not put to %s : %s"
+	msgKVFinderWatchFailed        = "Nodes are not watched in %s : %s"
+	msgKVFinderInvalidValue       = "Invalid node value of %s : %s"
+)
+
+// KVFinder represents a finder which puts the local node to a key-value store under "/<cluster>/<uuid>" with a lease,
+// and watches the keys of the cluster.
+type KVFinder struct {
+	*baseFinder
+	store         KVStore
+	cluster       string
+	localNode     node.Node
+	configMutex   sync.Mutex
+	keyPrefix     string
+	leaseTTL      time.Duration
+	retryInterval time.Duration
+	entriesMutex  sync.Mutex
+	entries       map[string]*node.Descriptor
+	loopMutex     sync.Mutex
+	loopCancel    context.CancelFunc
+	loopDone      sync.WaitGroup
+	*discoveryRunner
+}
+
+// NewKVFinderWithLocalNode returns a new finder of the specified store which puts the specified local node,
+// and the finder accepts only nodes of the same cluster as the local node.
+func NewKVFinderWithLocalNode(store KVStore, localNode node.Node) Finder {
+	finder := newKVFinder(store, localNode.Cluster())
+	finder.localNode = localNode
+	return finder
+}
+
+// NewKVFinder returns a new finder of the specified store which watches only the nodes of the specified cluster,
+// and the finder watches the nodes of all clusters when the cluster is empty.
+func NewKVFinder(store KVStore, cluster string) Finder {
+	return newKVFinder(store, cluster)
+}
+
+func newKVFinder(store KVStore, cluster string) *KVFinder {
+	finder := &KVFinder{
+		baseFinder:      newBaseFinder(),
+		store:           store,
+		cluster:         cluster,
+		localNode:       nil,
+		keyPrefix:       "",
+		leaseTTL:        DefaultKVLeaseTTL,
+		retryInterval:   DefaultKVRetryInterval,
+		entries:         map[string]*node.Descriptor{},
+		loopCancel:      nil,
+		discoveryRunner: newDiscoveryRunner(),
+	}
+	if cluster != "" {
+		finder.SetClusterFilter(cluster)
+	}
+	return finder
+}
+
+// hasLocalNode returns true when the finder has the local node, otherwise false.
+func (finder *KVFinder) hasLocalNode() bool {
+	return finder.localNode != nil && !reflect.ValueOf(finder.localNode).IsNil()
+}
+
+// Store returns the key-value store.
+func (finder *KVFinder) Store() KVStore {
+	return finder.store
+}
+
+// SetKeyPrefix sets the prefix of the keys such as "/finder" to share the store with other applications, and it must be set before Start.
+func (finder *KVFinder) SetKeyPrefix(prefix string) {
+	finder.configMutex.Lock()
+	defer finder.configMutex.Unlock()
+	finder.keyPrefix = strings.TrimSuffix(prefix, kvFinderKeySeparator)
+}
+
+// SetLeaseTTL sets the time-to-live of the lease of the local node key.
+func (finder *KVFinder) SetLeaseTTL(ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf(errorKVFinderInvalidTTL, ttl)
+	}
+	finder.configMutex.Lock()
+	defer finder.configMutex.Unlock()
+	finder.leaseTTL = ttl
+	return nil
+}
+
+// SetRetryInterval sets the interval to retry watching the store when the store is not available.
+func (finder *KVFinder) SetRetryInterval(interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf(errorKVFinderInvalidInterval, interval)
+	}
+	finder.configMutex.Lock()
+	defer finder.configMutex.Unlock()
+	finder.retryInterval = interval
+	return nil
+}
+
+// config returns the key prefix, the lease TTL and the retry interval.
+func (finder *KVFinder) config() (string, time.Duration, time.Duration) {
+	finder.configMutex.Lock()
+	defer finder.configMutex.Unlock()
+	return finder.keyPrefix, finder.leaseTTL, finder.retryInterval
+}
+
+// watchPrefix returns the prefix of the watched keys.
+func (finder *KVFinder) watchPrefix() string {
+	keyPrefix, _, _ := finder.config()
+	if finder.cluster == "" {
+		return keyPrefix + kvFinderKeySeparator
+	}
+	return keyPrefix + kvFinderKeySeparator + finder.cluster + kvFinderKeySeparator
+}
+
+// localKey returns the key of the local node.
+func (finder *KVFinder) localKey() string {
+	return finder.watchPrefix() + node.NewDescriptorWithNode(finder.localNode).ID()
+}
+
+// put puts the local node with a new lease.
+func (finder *KVFinder) put(ctx context.Context, n *node.Descriptor) (KVLease, error) {
+	value, err := json.Marshal(n)
+	if err != nil {
+		return 0, err
+	}
+	_, ttl, _ := finder.config()
+	return finder.store.Put(ctx, finder.localKey(), value, ttl)
+}
+
+// setEntries replaces the watched entries with the specified pairs, and applies them.
+func (finder *KVFinder) setEntries(pairs []*KVPair) []Node {
+	finder.entriesMutex.Lock()
+	defer finder.entriesMutex.Unlock()
+	finder.entries = map[string]*node.Descriptor{}
+	for _, pair := range pairs {
+		if n, ok := finder.decodeEntry(pair.Key, pair.Value); ok {
+			finder.entries[pair.Key] = n
+		}
+	}
+	return finder.applyEntries()
+}
+
+// updateEntry applies the specified event to the watched entries.
+func (finder *KVFinder) updateEntry(e *KVEvent) {
+	finder.entriesMutex.Lock()
+	defer finder.entriesMutex.Unlock()
+	switch e.Type {
+	case KVEventPut:
+		n, ok := finder.decodeEntry(e.Key, e.Value)
+		if !ok {
+			return
+		}
+		finder.entries[e.Key] = n
+	case KVEventDelete:
+		if _, ok := finder.entries[e.Key]; !ok {
+			return
+		}
+		delete(finder.entries, e.Key)
+	}
+	finder.applyEntries()
+}
+
+// decodeEntry returns the node of the specified value, and returns false when the value is invalid.
+func (finder *KVFinder) decodeEntry(key string, value []byte) (*node.Descriptor, bool) {
+	n := &node.Descriptor{}
+	if err := json.Unmarshal(value, n); err != nil {
+		log.Warnf(msgKVFinderInvalidValue, key, err.Error())
+		return nil, false
+	}
+	if err := n.Validate(); err != nil {
+		log.Warnf(msgKVFinderInvalidValue, key, err.Error())
+		return nil, false
+	}
+	return n, true
+}
+
+// applyEntries replaces the nodes with the watched entries except the local node by applyNodes, and returns the watched nodes.
+// The caller must hold the entries mutex.
+func (finder *KVFinder) applyEntries() []Node {
+	localKey := ""
+	if finder.hasLocalNode() {
+		localKey = finder.localKey()
+	}
+
+	keys := make([]string, 0, len(finder.entries))
+	for key := range finder.entries {
+		if key != localKey {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	nodes := make([]Node, 0, len(keys))
+	for _, key := range keys {
+		nodes = append(nodes, finder.entries[key].Node())
+	}
+
+	finder.applyNodes(nodes)
+
+	return nodes
+}
+
+// Search gets all nodes in the store.
+func (finder *KVFinder) Search() error {
+	_, err := finder.SearchContext(context.Background(), NewSearchOptions())
+	return err
+}
+
+// SearchContext gets all nodes in the store, posts them to the search listener, and returns all nodes.
+func (finder *KVFinder) SearchContext(ctx context.Context, opts *SearchOptions) ([]Node, error) {
+	if opts == nil {
+		opts = NewSearchOptions()
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	pairs, err := finder.store.Get(ctx, finder.watchPrefix())
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range finder.setEntries(pairs) {
+		if finder.IsClusterMember(n) {
+			finder.postSearchResponse(n)
+		}
+	}
+
+	return finder.GetAllNodes()
+}
+
+// Start puts the local node which is changed to the ready condition with a lease, and starts keeping the lease alive and watching the store.
+// The finder is started even if the store is not available, and the put is retried by the keep-alives.
+func (finder *KVFinder) Start() error {
+	finder.loopMutex.Lock()
+	if finder.loopCancel != nil {
+		finder.loopMutex.Unlock()
+		return nil
+	}
+	if finder.hasLocalNode() {
+		if err := node.Transit(finder.localNode, node.ConditionBootstrap, node.ConditionReady); err != nil {
+			finder.loopMutex.Unlock()
+			return err
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	finder.loopCancel = cancel
+	if finder.hasLocalNode() {
+		finder.loopDone.Add(1)
+		go finder.keepAlive(ctx)
+	}
+	finder.loopDone.Add(1)
+	go finder.watch(ctx)
+	finder.loopMutex.Unlock()
+
+	finder.startNodeSweeper()
+
+	finder.startDiscovery(finder)
+
+	return nil
+}
+
+// Stop stops watching the store, and deletes the local node key of the local node which is changed to the stop condition.
+func (finder *KVFinder) Stop() error {
+	finder.stopDiscovery()
+
+	finder.loopMutex.Lock()
+	cancel := finder.loopCancel
+	finder.loopCancel = nil
+	finder.loopMutex.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	finder.loopDone.Wait()
+
+	finder.stopNodeSweeper()
+
+	if !finder.hasLocalNode() {
+		return nil
+	}
+	if err := node.Transit(finder.localNode, node.ConditionStop); err != nil {
+		log.Errorf("%s", err.Error())
+	}
+	return finder.store.Delete(context.Background(), finder.localKey())
+}
+
+// IsRunning returns true when the finder is running, otherwise false.
+func (finder *KVFinder) IsRunning() bool {
+	finder.loopMutex.Lock()
+	defer finder.loopMutex.Unlock()
+	return finder.loopCancel != nil
+}
+
+// String returns the description.
+func (finder *KVFinder) String() string {
+	return FinderKV
+}
+
+// keepAlive keeps the lease of the local node key alive until the context is done,
+// and puts the local node again with a new lease when the lease has expired or the local node is changed.
+func (finder *KVFinder) keepAlive(ctx context.Context) {
+	defer finder.loopDone.Done()
+
+	var lease KVLease
+	var putNode *node.Descriptor
+	for {
+		_, ttl, _ := finder.config()
+		var err error
+		localNode := node.NewDescriptorWithNode(finder.localNode)
+		if putNode != nil && putNode.Equal(localNode) {
+			err = finder.store.KeepAlive(ctx, lease)
+		}
+		if putNode == nil || !putNode.Equal(localNode) || err != nil {
+			lease, err = finder.put(ctx, localNode)
+			putNode = nil
+			if err == nil {
+				putNode = localNode
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Warnf(msgKVFinderRegistrationFailed, finder.watchPrefix(), err.Error())
+		}
+		if !sleepContext(ctx, ttl/kvFinderKeepAlivesPerTTL) {
+			return
+		}
+	}
+}
+
+// watch applies the changes of the watched keys until the context is done,
+// and gets all keys again whenever the watch is started or canceled by the store.
+func (finder *KVFinder) watch(ctx context.Context) {
+	defer finder.loopDone.Done()
+
+	prefix := finder.watchPrefix()
+	for {
+		_, _, retryInterval := finder.config()
+		err := finder.watchOnce(ctx, prefix)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Warnf(msgKVFinderWatchFailed, prefix, err.Error())
+			if !sleepContext(ctx, retryInterval) {
+				return
+			}
+		}
+	}
+}
+
+// watchOnce gets all keys of the specified prefix, and applies the changes until the watch is closed.
+func (finder *KVFinder) watchOnce(ctx context.Context, prefix string) error {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events, err := finder.store.Watch(watchCtx, prefix)
+	if err != nil {
+		return err
+	}
+	pairs, err := finder.store.Get(watchCtx, prefix)
+	if err != nil {
+		return err
+	}
+	finder.setEntries(pairs)
+	for e := range events {
+		finder.updateEntry(e)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-finder/finder/node"
+)
+
+func setupTestKVFinder(t *testing.T, store KVStore, cluster string, n int) *KVFinder {
+	t.Helper()
+	localNode := node.NewBaseNode().SetCluster(cluster).SetHost(fmt.Sprintf("org.cybergarage.kv%03d", n)).SetAddress(net.ParseIP(fmt.Sprintf("192.168.100.%d", n))).SetRPCPort(8001)
+	localNode.SetPort(node.PortCarbon, 2003)
+	finder := NewKVFinderWithLocalNode(store, localNode).(*KVFinder)
+	finder.SetKeyPrefix("/finder/")
+	return finder
+}
+
+// waitTestKVFinderNodes waits until the specified finder has the specified number of the nodes.
+func waitTestKVFinderNodes(finder Finder, n int) int {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		nodes, _ := finder.GetAllNodes()
+		if len(nodes) == n || deadline.Before(time.Now()) {
+			return len(nodes)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestKVFinder(t *testing.T) {
+	store := NewMemoryKVStore()
+	defer store.Close()
+
+	finders := []*KVFinder{
+		setupTestKVFinder(t, store, "cluster", 1),
+		setupTestKVFinder(t, store, "cluster", 2),
+		setupTestKVFinder(t, store, "other", 3),
+	}
+
+	notifyListener := &testNotifyListener{}
+	finders[0].SetNotifyListener(notifyListener)
+
+	for _, finder := range finders {
+		if err := finder.Start(); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	defer finders[0].Stop()
+	defer finders[2].Stop()
+
+	// The local nodes are put under the prefix of the cluster
+
+	key := "/finder/cluster/" + node.NewDescriptorWithNode(finders[1].localNode).ID()
+	if finders[1].localKey() != key {
+		t.Errorf(testFinderMatchingError, key, finders[1].localKey())
+	}
+
+	// The nodes of the same cluster are found
+
+	for _, finder := range finders[:2] {
+		if n := waitTestKVFinderNodes(finder, 1); n != 1 {
+			t.Errorf(testFinderNodeCountError, n, 1)
+		}
+	}
+	if n := waitTestKVFinderNodes(finders[2], 0); n != 0 {
+		t.Errorf(testFinderNodeCountError, n, 0)
+	}
+	if len(notifyListener.Nodes()) == 0 {
+		t.Errorf(testFinderNodeCountError, len(notifyListener.Nodes()), 1)
+	}
+
+	nodes, _ := finders[0].GetAllNodes()
+	if 0 < len(nodes) {
+		if port, ok := nodes[0].Port(node.PortCarbon); !ok || port != 2003 {
+			t.Errorf("%d != %d", port, 2003)
+		}
+		if !node.Equal(nodes[0], finders[1].localNode) {
+			t.Errorf(testFinderMatchingError, finders[1].localNode.Host(), nodes[0].Host())
+		}
+	}
+
+	// The watch-only finder of no cluster finds the nodes of all clusters
+
+	watcher := NewKVFinder(store, "").(*KVFinder)
+	watcher.SetKeyPrefix("/finder")
+	nodes, err := watcher.SearchContext(context.Background(), nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(nodes) != 3 {
+		t.Errorf(testFinderNodeCountError, len(nodes), 3)
+	}
+
+	// The node is removed and notified when the key is deleted
+
+	notifiedCount := len(notifyListener.Nodes())
+	if err := finders[1].Stop(); err != nil {
+		t.Error(err)
+	}
+	if n := waitTestKVFinderNodes(finders[0], 0); n != 0 {
+		t.Errorf(testFinderNodeCountError, n, 0)
+	}
+	if n := waitTestNotifiedNodes(notifyListener, notifiedCount+1); n != notifiedCount+1 {
+		t.Errorf(testFinderNodeCountError, n, notifiedCount+1)
+	}
+}
+
+func TestKVFinderLease(t *testing.T) {
+	store := NewMemoryKVStore()
+	defer store.Close()
+
+	ttl := 100 * time.Millisecond
+	finders := []*KVFinder{
+		setupTestKVFinder(t, store, "cluster", 1),
+		setupTestKVFinder(t, store, "cluster", 2),
+	}
+	for _, finder := range finders {
+		if err := finder.SetLeaseTTL(ttl); err != nil {
+			t.Error(err)
+			return
+		}
+		if err := finder.Start(); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	defer finders[0].Stop()
+
+	// The node is kept while the lease is kept alive
+
+	time.Sleep(ttl * 4)
+	if n := waitTestKVFinderNodes(finders[0], 1); n != 1 {
+		t.Errorf(testFinderNodeCountError, n, 1)
+	}
+
+	// The node is removed when the lease expires without the keep-alives
+
+	finders[1].loopCancel()
+	finders[1].loopDone.Wait()
+	if n := waitTestKVFinderNodes(finders[0], 0); n != 0 {
+		t.Errorf(testFinderNodeCountError, n, 0)
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"time"
+
+	finder_dns "github.com/cybergarage/go-finder/finder/dns"
+	finder_mdns "github.com/cybergarage/go-finder/finder/mdns"
+	"github.com/cybergarage/go-finder/finder/node"
+	"github.com/cybergarage/go-logger/log"
+)
+
+const (
+	mdnsFinderSearchSleepSecond = 1
+)
+
+const (
+	msgMDNSFinderInvalidMessage   = "Invalid mDNS message from %s : %s"
+	msgMDNSFinderFoundNewNode     = "New finder node (%s:%d) is found"
+	msgMDNSFinderGoodbyeNode      = "Finder node (%s:%d) says goodbye"
+	msgMDNSFinderOutOfClusterNode = "Finder node (%s:%d) of cluster (%s) is ignored"
+)
+
+// MDNSFinder represents a finder which advertises and browses the finder nodes as the DNS-SD services on the multicast DNS.
+type MDNSFinder struct {
+	*baseFinder
+	localNode node.Node
+	transport finder_mdns.Transport
+	*discoveryRunner
+}
+
+// NewMDNSFinderWithTransport returns a new finder with the specified local node and transport.
+// The local node is advertised when it is not nil, and the finder accepts only nodes of the same cluster as the local node.
+func NewMDNSFinderWithTransport(localNode node.Node, transport finder_mdns.Transport) Finder {
+	finder := &MDNSFinder{
+		baseFinder:      newBaseFinder(),
+		localNode:       localNode,
+		transport:       transport,
+		discoveryRunner: newDiscoveryRunner(),
+	}
+	if finder.hasLocalNode() {
+		finder.SetClusterFilter(localNode.Cluster())
+	}
+	return finder
+}
+
+// NewMDNSFinderWithLocalNode returns a new finder of the multicast DNS with the specified node.
+func NewMDNSFinderWithLocalNode(localNode node.Node) Finder {
+	return NewMDNSFinderWithTransport(localNode, finder_mdns.NewMulticastTransport())
+}
+
+// NewMDNSFinder returns a new finder of the multicast DNS which browses only.
+func NewMDNSFinder() Finder {
+	return NewMDNSFinderWithLocalNode(nil)
+}
+
+func init() {
+	mustRegisterFinder(FinderMDNS, func(opts *FinderOptions) (Finder, error) {
+		return NewMDNSFinderWithLocalNode(opts.LocalNode), nil
+	})
+}
+
+// hasLocalNode returns true when the finder has the local node, otherwise false.
+func (finder *MDNSFinder) hasLocalNode() bool {
+	return finder.localNode != nil && !reflect.ValueOf(finder.localNode).IsNil()
+}
+
+// IsLocalNode returns true when the specified node is the local node, otherwise false.
+func (finder *MDNSFinder) IsLocalNode(candidateNode node.Node) bool {
+	if !finder.hasLocalNode() {
+		return false
+	}
+	return node.Equal(finder.localNode, candidateNode)
+}
+
+// sendMessage sends the specified message to the multicast group.
+func (finder *MDNSFinder) sendMessage(msg *finder_dns.Message) error {
+	b, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	return finder.transport.Send(b)
+}
+
+// Search searches all nodes.
+func (finder *MDNSFinder) Search() error {
+	opts := NewSearchOptions()
+	opts.Wait = time.Second * mdnsFinderSearchSleepSecond
+	_, err := finder.SearchContext(context.Background(), opts)
+	return err
+}
+
+// SearchContext browses the finder nodes with the specified options until the context is done, and returns the responding nodes.
+func (finder *MDNSFinder) SearchContext(ctx context.Context, opts *SearchOptions) ([]Node, error) {
+	if opts == nil {
+		opts = NewSearchOptions()
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	collector := finder.startSearchCollector(opts)
+	defer finder.stopSearchCollector(collector)
+
+	err := finder.sendMessage(finder_mdns.NewServiceQueryMessage())
+	if err != nil {
+		return nil, err
+	}
+
+	return collector.wait(ctx, opts)
+}
+
+// Start starts the finder, and advertises the local node which is changed to the ready condition through the bootstrap condition.
+func (finder *MDNSFinder) Start() error {
+	finder.transport.SetHandler(finder.messageReceived)
+	err := finder.transport.Start()
+	if err != nil {
+		return err
+	}
+	finder.startNodeSweeper()
+
+	if finder.hasLocalNode() {
+		if err := node.Transit(finder.localNode, node.ConditionBootstrap, node.ConditionReady); err != nil {
+			log.Errorf("%s", err.Error())
+		}
+		err := finder.sendMessage(finder_mdns.NewAnnouncementMessageWithNode(finder.localNode, finder_mdns.DefaultTTL))
+		if err != nil {
+			log.Errorf("%s", err.Error())
+		}
+	}
+
+	finder.startDiscovery(finder)
+
+	return nil
+}
+
+// Stop says goodbye of the local node which is changed to the stop condition, and stops the finder.
+func (finder *MDNSFinder) Stop() error {
+	finder.stopDiscovery()
+
+	if finder.hasLocalNode() {
+		if err := node.Transit(finder.localNode, node.ConditionStop); err != nil {
+			log.Errorf("%s", err.Error())
+		}
+	}
+
+	if finder.hasLocalNode() && finder.transport.IsRunning() {
+		err := finder.sendMessage(finder_mdns.NewGoodbyeMessageWithNode(finder.localNode))
+		if err != nil {
+			log.Errorf("%s", err.Error())
+		}
+	}
+
+	finder.stopNodeSweeper()
+	return finder.transport.Stop()
+}
+
+// IsRunning returns true when the finder is running, otherwise false.
+func (finder *MDNSFinder) IsRunning() bool {
+	return finder.transport.IsRunning()
+}
+
+// String returns the description.
+func (finder *MDNSFinder) String() string {
+	return FinderMDNS
+}
+
+// messageReceived answers the queries for the local node, and applies the advertised nodes.
+func (finder *MDNSFinder) messageReceived(b []byte, from net.Addr) {
+	msg, err := finder_dns.NewMessageWithBytes(b)
+	if err != nil {
+		log.Warnf(msgMDNSFinderInvalidMessage, from, err.Error())
+		return
+	}
+
+	if msg.IsQuery() {
+		if !finder.hasLocalNode() || !finder_mdns.IsServiceQuery(msg, finder.localNode) {
+			return
+		}
+		err := finder.sendMessage(finder_mdns.NewAnnouncementMessageWithNode(finder.localNode, finder_mdns.DefaultTTL))
+		if err != nil {
+			log.Errorf("%s", err.Error())
+		}
+		return
+	}
+
+	candidateNodes, err := finder_mdns.NewFinderNodesWithMessage(msg)
+	if err != nil {
+		log.Warnf(msgMDNSFinderInvalidMessage, from, err.Error())
+	}
+
+	for _, candidateNode := range candidateNodes {
+		finder.advertisementReceived(candidateNode)
+	}
+}
+
+// advertisementReceived adds, updates or removes the advertised node.
+// The advertised nodes are posted to the search listener, and the nodes saying goodbye are posted to the notify listener.
+func (finder *MDNSFinder) advertisementReceived(candidateNode Node) {
+	if finder.IsLocalNode(candidateNode) {
+		return
+	}
+
+	if !finder.IsClusterMember(candidateNode) {
+		log.Tracef(msgMDNSFinderOutOfClusterNode, candidateNode.Address(), candidateNode.RPCPort(), candidateNode.Cluster())
+		return
+	}
+
+	if candidateNode.Condition() == node.ConditionStop {
+		log.Tracef(msgMDNSFinderGoodbyeNode, candidateNode.Address(), candidateNode.RPCPort())
+		if !finder.HasNode(candidateNode) {
+			return
+		}
+		if err := finder.RemoveNode(candidateNode); err != nil {
+			log.Errorf("%s", err.Error())
+			return
+		}
+		finder.postNotification(candidateNode)
+		return
+	}
+
+	if !finder.updateNode(candidateNode) {
+		log.Infof(msgMDNSFinderFoundNewNode, candidateNode.Address(), candidateNode.RPCPort())
+		if err := finder.addNode(candidateNode); err != nil {
+			log.Errorf("%s", err.Error())
+			return
+		}
+	}
+
+	finder.postSearchResponse(candidateNode)
+}
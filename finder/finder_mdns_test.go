@@ -0,0 +1,142 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	finder_mdns "github.com/cybergarage/go-finder/finder/mdns"
+	"github.com/cybergarage/go-finder/finder/node"
+)
+
+func setupTestMDNSNode(cluster string, host string, addr string) *node.BaseNode {
+	return node.NewBaseNode().SetCluster(cluster).SetHost(host).SetAddress(net.ParseIP(addr)).SetRPCPort(8001)
+}
+
+func TestMDNSFinder(t *testing.T) {
+	network := finder_mdns.NewMemoryNetwork()
+
+	srcNode := setupTestMDNSNode("cluster", "org.cybergarage.finder001", "192.168.100.1")
+	srcNode.SetPort(node.PortCarbon, 2003).SetLabel("zone", "a")
+	srcFinder := NewMDNSFinderWithTransport(srcNode, network.NewTransport())
+
+	localNode := setupTestMDNSNode("cluster", "org.cybergarage.finder002", "192.168.100.2")
+	finder := NewMDNSFinderWithTransport(localNode, network.NewTransport()).(*MDNSFinder)
+
+	notifyListener := &testNotifyListener{}
+	finder.SetNotifyListener(notifyListener)
+
+	if err := finder.Start(); err != nil {
+		t.Error(err)
+		return
+	}
+	defer finder.Stop()
+
+	if err := srcFinder.Start(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Searching finds the advertised node
+
+	opts := NewSearchOptions()
+	opts.Wait = 5 * time.Second
+	opts.MaxResponses = 1
+	nodes, err := finder.SearchContext(context.Background(), opts)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(nodes) != 1 {
+		t.Errorf(testFinderNodeCountError, len(nodes), 1)
+		return
+	}
+	foundNode := nodes[0]
+	if !node.Equal(foundNode, srcNode) {
+		t.Errorf(testFinderMatchingError, srcNode.Host(), foundNode.Host())
+	}
+	if port, ok := foundNode.Port(node.PortCarbon); !ok || port != 2003 {
+		t.Errorf("%d != %d", port, 2003)
+	}
+	if value, ok := foundNode.Labels().Get("zone"); !ok || value != "a" {
+		t.Errorf("%s != %s", value, "a")
+	}
+	if foundNode.Condition() != node.ConditionReady {
+		t.Errorf("%s != %s", foundNode.Condition(), node.ConditionReady)
+	}
+	if !finder.HasNode(srcNode) {
+		t.Errorf("%s is not added", srcNode.Host())
+	}
+	if finder.HasNode(localNode) {
+		t.Errorf("%s : the local node is added", localNode.Host())
+	}
+
+	// The goodbye removes the node
+
+	if err := srcFinder.Stop(); err != nil {
+		t.Error(err)
+	}
+	if srcNode.Condition() != node.ConditionStop {
+		t.Errorf("%s != %s", srcNode.Condition(), node.ConditionStop)
+	}
+	if nodes := waitTestFinderNodeCount(finder, 0); len(nodes) != 0 {
+		t.Errorf(testFinderNodeCountError, len(nodes), 0)
+	}
+	if len(notifyListener.Nodes()) != 1 {
+		t.Errorf(testFinderNodeCountError, len(notifyListener.Nodes()), 1)
+	}
+}
+
+func TestMDNSFinderClusterFilter(t *testing.T) {
+	network := finder_mdns.NewMemoryNetwork()
+
+	srcFinders := []Finder{
+		NewMDNSFinderWithTransport(setupTestMDNSNode("production", "org.cybergarage.finder001", "192.168.100.1"), network.NewTransport()),
+		NewMDNSFinderWithTransport(setupTestMDNSNode("staging", "org.cybergarage.finder002", "192.168.100.2"), network.NewTransport()),
+	}
+	for _, srcFinder := range srcFinders {
+		if err := srcFinder.Start(); err != nil {
+			t.Error(err)
+			return
+		}
+		defer srcFinder.Stop()
+	}
+
+	finder := NewMDNSFinderWithTransport(setupTestMDNSNode("production", "org.cybergarage.finder000", "192.168.100.100"), network.NewTransport())
+	if err := finder.Start(); err != nil {
+		t.Error(err)
+		return
+	}
+	defer finder.Stop()
+
+	opts := NewSearchOptions()
+	opts.Wait = 500 * time.Millisecond
+	if _, err := finder.SearchContext(context.Background(), opts); err != nil {
+		t.Error(err)
+		return
+	}
+
+	clusters, err := finder.GetClusters()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(clusters) != 1 || clusters["production"] != 1 {
+		t.Errorf("%v", clusters)
+	}
+}
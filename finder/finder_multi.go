@@ -0,0 +1,388 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cybergarage/go-finder/finder/node"
+	"github.com/cybergarage/go-logger/log"
+)
+
+const (
+	errorMultiFinderNoFinders    = "Multi finder has no finders"
+	errorMultiFinderSourceFailed = "%s : %w"
+)
+
+// multiFinderReport represents a node reported by a source finder.
+type multiFinderReport struct {
+	source Finder
+	node   Node
+}
+
+// multiFinderNode represents a merged node with the source finders which reported the node.
+type multiFinderNode struct {
+	Node
+	reports []multiFinderReport
+}
+
+// hasSource returns true when the specified finder reported the node, otherwise false.
+func (mnode *multiFinderNode) hasSource(source Finder) bool {
+	for _, report := range mnode.reports {
+		if report.source == source {
+			return true
+		}
+	}
+	return false
+}
+
+// sources returns the source finders which reported the node.
+func (mnode *multiFinderNode) sources() []Finder {
+	sources := make([]Finder, len(mnode.reports))
+	for n, report := range mnode.reports {
+		sources[n] = report.source
+	}
+	return sources
+}
+
+// setSource records the specified node reported by the specified source finder.
+func (mnode *multiFinderNode) setSource(source Finder, sourceNode Node) {
+	mnode.Node = sourceNode
+	for n, report := range mnode.reports {
+		if report.source != source {
+			continue
+		}
+		mnode.reports[n].node = sourceNode
+		return
+	}
+	mnode.reports = append(mnode.reports, multiFinderReport{source: source, node: sourceNode})
+}
+
+// removeSource removes the specified source finder, and replaces the node with the node reported by a remaining source finder.
+func (mnode *multiFinderNode) removeSource(source Finder) {
+	for n, report := range mnode.reports {
+		if report.source != source {
+			continue
+		}
+		mnode.reports = append(mnode.reports[:n:n], mnode.reports[n+1:]...)
+		break
+	}
+	if 0 < len(mnode.reports) {
+		mnode.Node = mnode.reports[0].node
+	}
+}
+
+// MultiFinder represents a composite finder which merges the nodes of multiple finders.
+type MultiFinder struct {
+	*baseFinder
+	finders     []Finder
+	applyMutex  sync.Mutex
+	sourceMutex sync.RWMutex
+	sourceNodes []*multiFinderNode
+}
+
+// multiFinderSource represents a listener of a source finder of the multi finder.
+type multiFinderSource struct {
+	finder *MultiFinder
+	source Finder
+}
+
+// queryableNodeFilter is an interface for finders which return only the nodes accepted by the condition filter.
+type queryableNodeFilter interface {
+	IsQueryableNode(node Node) bool
+}
+
+// NewMultiFinder returns a new finder which merges the nodes of the specified finders.
+// The multi finder subscribes to the membership changes of the specified finders by event listeners, and keeps their search and notify listeners.
+// The multi finder merges only the nodes which are returned by the queries of the source finders.
+// The notify listener of the multi finder receives the merged nodes which are added or updated by the source finders,
+// and the search listener receives the merged nodes which respond to SearchContext.
+func NewMultiFinder(finders ...Finder) (Finder, error) {
+	if len(finders) == 0 {
+		return nil, errors.New(errorMultiFinderNoFinders)
+	}
+
+	finder := &MultiFinder{
+		baseFinder:  newBaseFinder(),
+		finders:     finders,
+		sourceNodes: []*multiFinderNode{},
+	}
+
+	listeners := []*multiFinderSource{}
+	unsubscribe := func() {
+		for _, listener := range listeners {
+			if err := listener.source.RemoveEventListener(listener); err != nil {
+				log.Errorf("%s", err.Error())
+			}
+		}
+	}
+
+	for _, source := range finders {
+		listener := &multiFinderSource{finder: finder, source: source}
+		if err := source.AddEventListener(listener); err != nil {
+			unsubscribe()
+			return nil, err
+		}
+		listeners = append(listeners, listener)
+		nodes, err := source.GetAllNodes()
+		if err != nil {
+			unsubscribe()
+			return nil, err
+		}
+		for _, sourceNode := range nodes {
+			finder.sourceNodeReported(source, sourceNode)
+		}
+	}
+
+	return finder, nil
+}
+
+// Finders returns the source finders.
+func (finder *MultiFinder) Finders() []Finder {
+	return finder.finders
+}
+
+// NodeSources returns the source finders which reported the specified node.
+func (finder *MultiFinder) NodeSources(targetNode Node) []Finder {
+	finder.sourceMutex.RLock()
+	defer finder.sourceMutex.RUnlock()
+	idx := finder.findSourceNodeIndex(targetNode)
+	if idx < 0 {
+		return []Finder{}
+	}
+	return finder.sourceNodes[idx].sources()
+}
+
+// findSourceNodeIndex returns the index of the specified merged node, or -1 if not found.
+// The caller must hold the source mutex.
+func (finder *MultiFinder) findSourceNodeIndex(targetNode Node) int {
+	for n, sourceNode := range finder.sourceNodes {
+		if node.Equal(targetNode, sourceNode.Node) {
+			return n
+		}
+	}
+	return -1
+}
+
+// sourceNodeReported records the specified node reported by the source, adds the node when the node is reported first, and updates the node otherwise.
+func (finder *MultiFinder) sourceNodeReported(source Finder, reportedNode Node) {
+	finder.applyMutex.Lock()
+	defer finder.applyMutex.Unlock()
+
+	finder.sourceMutex.Lock()
+	idx := finder.findSourceNodeIndex(reportedNode)
+	isNew := idx < 0
+	if isNew {
+		mnode := &multiFinderNode{reports: []multiFinderReport{}}
+		mnode.setSource(source, reportedNode)
+		finder.sourceNodes = append(finder.sourceNodes, mnode)
+	} else {
+		finder.sourceNodes[idx].setSource(source, reportedNode)
+	}
+	finder.sourceMutex.Unlock()
+
+	if isNew {
+		if err := finder.addNode(reportedNode); err != nil {
+			log.Errorf("%s", err.Error())
+			return
+		}
+		finder.postNotification(reportedNode)
+		return
+	}
+	if finder.updateNode(reportedNode) {
+		finder.postNotification(reportedNode)
+	}
+}
+
+// isQueryableSourceNode returns true when the specified node is returned by the queries of the source, otherwise false.
+func isQueryableSourceNode(source Finder, node Node) bool {
+	filter, ok := source.(queryableNodeFilter)
+	if !ok {
+		return true
+	}
+	return filter.IsQueryableNode(node)
+}
+
+// sourceNodeRemoved removes the specified source of the node, removes the node when no sources report the node,
+// and replaces the node with the node reported by a remaining source otherwise.
+func (finder *MultiFinder) sourceNodeRemoved(source Finder, removedNode Node) {
+	finder.applyMutex.Lock()
+	defer finder.applyMutex.Unlock()
+
+	finder.sourceMutex.Lock()
+	idx := finder.findSourceNodeIndex(removedNode)
+	if idx < 0 || !finder.sourceNodes[idx].hasSource(source) {
+		finder.sourceMutex.Unlock()
+		return
+	}
+	finder.sourceNodes[idx].removeSource(source)
+	remainingNode := finder.sourceNodes[idx].Node
+	isRemoved := len(finder.sourceNodes[idx].reports) == 0
+	if isRemoved {
+		finder.sourceNodes = append(finder.sourceNodes[:idx], finder.sourceNodes[idx+1:]...)
+	}
+	finder.sourceMutex.Unlock()
+
+	if !isRemoved {
+		finder.updateNode(remainingNode)
+		return
+	}
+	if err := finder.baseFinder.RemoveNode(removedNode); err != nil {
+		log.Errorf("%s", err.Error())
+	}
+}
+
+// FinderEventReceived applies the membership change of the source finder.
+func (listener *multiFinderSource) FinderEventReceived(e *Event) {
+	switch e.Type {
+	case NodeAdded:
+		if !isQueryableSourceNode(listener.source, e.Node) {
+			return
+		}
+		listener.finder.sourceNodeReported(listener.source, e.Node)
+	case NodeUpdated:
+		if !isQueryableSourceNode(listener.source, e.Node) {
+			listener.finder.sourceNodeRemoved(listener.source, e.Node)
+			return
+		}
+		listener.finder.sourceNodeReported(listener.source, e.Node)
+	case NodeRemoved:
+		listener.finder.sourceNodeRemoved(listener.source, e.Node)
+	}
+}
+
+// Search searches all nodes of all source finders.
+func (finder *MultiFinder) Search() error {
+	var errs []error
+	for _, source := range finder.finders {
+		if err := source.Search(); err != nil {
+			errs = append(errs, fmt.Errorf(errorMultiFinderSourceFailed, source, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SearchContext searches nodes of all source finders concurrently, and returns the merged responding nodes.
+func (finder *MultiFinder) SearchContext(ctx context.Context, opts *SearchOptions) ([]Node, error) {
+	type searchResult struct {
+		source Finder
+		nodes  []Node
+		err    error
+	}
+
+	results := make(chan searchResult, len(finder.finders))
+	for _, source := range finder.finders {
+		go func(source Finder) {
+			nodes, err := source.SearchContext(ctx, opts)
+			results <- searchResult{source: source, nodes: nodes, err: err}
+		}(source)
+	}
+
+	nodes := []Node{}
+	var errs []error
+	for range finder.finders {
+		result := <-results
+		if result.err != nil {
+			errs = append(errs, fmt.Errorf(errorMultiFinderSourceFailed, result.source, result.err))
+		}
+		for _, resultNode := range result.nodes {
+			isDuplicated := false
+			for _, addedNode := range nodes {
+				if node.Equal(resultNode, addedNode) {
+					isDuplicated = true
+					break
+				}
+			}
+			if !isDuplicated {
+				nodes = append(nodes, resultNode)
+				finder.postSearchResponse(resultNode)
+			}
+		}
+	}
+
+	return nodes, errors.Join(errs...)
+}
+
+// RemoveNode removes the specified node from all source finders.
+func (finder *MultiFinder) RemoveNode(targetNode Node) error {
+	sources := finder.NodeSources(targetNode)
+	if len(sources) == 0 {
+		return fmt.Errorf(errorFinderNodeNotFound, targetNode)
+	}
+	var errs []error
+	for _, source := range sources {
+		if err := source.RemoveNode(targetNode); err != nil {
+			errs = append(errs, fmt.Errorf(errorMultiFinderSourceFailed, source, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SetNodeTTL sets the time-to-live of found nodes to all source finders.
+func (finder *MultiFinder) SetNodeTTL(ttl time.Duration) error {
+	for _, source := range finder.finders {
+		if err := source.SetNodeTTL(ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start starts all source finders, and stops the started finders when a finder fails to start.
+func (finder *MultiFinder) Start() error {
+	for n, source := range finder.finders {
+		err := source.Start()
+		if err == nil {
+			continue
+		}
+		for i := n - 1; 0 <= i; i-- {
+			if stopErr := finder.finders[i].Stop(); stopErr != nil {
+				log.Errorf("%s", stopErr.Error())
+			}
+		}
+		return fmt.Errorf(errorMultiFinderSourceFailed, source, err)
+	}
+	return nil
+}
+
+// Stop stops all source finders.
+func (finder *MultiFinder) Stop() error {
+	var errs []error
+	for _, source := range finder.finders {
+		if err := source.Stop(); err != nil {
+			errs = append(errs, fmt.Errorf(errorMultiFinderSourceFailed, source, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// IsRunning returns true when all source finders are running, otherwise false.
+func (finder *MultiFinder) IsRunning() bool {
+	for _, source := range finder.finders {
+		if !source.IsRunning() {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the description.
+func (finder *MultiFinder) String() string {
+	return FinderMulti
+}
@@ -0,0 +1,317 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-finder/finder/node"
+)
+
+func TestMultiFinder(t *testing.T) {
+	nodes := setupTestHashRingNodes(4)
+	seedFinder := NewStaticFinderWithNodes(nodes[0:2])
+	sharedFinder := NewStaticFinderWithNodes(nodes[1:3])
+
+	finder, err := NewMultiFinder(seedFinder, sharedFinder)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	multiFinder, ok := finder.(*MultiFinder)
+	if !ok {
+		t.Errorf("%s : not a multi finder", finder)
+		return
+	}
+
+	listener := &testEventListener{}
+	if err := finder.AddEventListener(listener); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := finder.Start(); err != nil {
+		t.Error(err)
+		return
+	}
+	defer finder.Stop()
+
+	if !finder.IsRunning() {
+		t.Errorf("%s : not running", finder)
+	}
+
+	// De-duplicate the nodes reported by the multiple finders
+
+	mergedNodes, err := finder.GetAllNodes()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(mergedNodes) != 3 {
+		t.Errorf(testFinderNodeCountError, len(mergedNodes), 3)
+	}
+
+	sourceCounts := []int{1, 2, 1, 0}
+	for n, sourceCount := range sourceCounts {
+		sources := multiFinder.NodeSources(nodes[n])
+		if len(sources) != sourceCount {
+			t.Errorf("%s : %d != %d", nodes[n].Host(), len(sources), sourceCount)
+		}
+	}
+
+	searchedNodes, err := finder.SearchContext(context.Background(), NewSearchOptions())
+	if err != nil {
+		t.Error(err)
+	}
+	if len(searchedNodes) != 3 {
+		t.Errorf(testFinderNodeCountError, len(searchedNodes), 3)
+	}
+
+	if _, err := finder.GetNeighborhoodNode(nodes[0]); err != nil {
+		t.Error(err)
+	}
+
+	// Follow the membership changes of the source finders
+
+	if err := seedFinder.(testNodeMutator).addNode(nodes[3]); err != nil {
+		t.Error(err)
+	}
+	if err := seedFinder.RemoveNode(nodes[1]); err != nil {
+		t.Error(err)
+	}
+	if !multiFinder.HasNode(nodes[1]) {
+		t.Errorf("%s : removed while reported by another finder", nodes[1].Host())
+	}
+	if err := sharedFinder.RemoveNode(nodes[1]); err != nil {
+		t.Error(err)
+	}
+	if multiFinder.HasNode(nodes[1]) {
+		t.Errorf("%s : not removed", nodes[1].Host())
+	}
+
+	// Remove the node from all source finders
+
+	if err := finder.RemoveNode(nodes[2]); err != nil {
+		t.Error(err)
+	}
+	if sharedFinder.(*StaticFinder).HasNode(nodes[2]) {
+		t.Errorf("%s : not removed from the source", nodes[2].Host())
+	}
+	if err := finder.RemoveNode(nodes[2]); err == nil {
+		t.Errorf("%s : removed twice", nodes[2].Host())
+	}
+
+	expectedTypes := []EventType{NodeAdded, NodeRemoved, NodeRemoved}
+	events := listener.Events()
+	if len(events) != len(expectedTypes) {
+		t.Errorf(testFinderNodeCountError, len(events), len(expectedTypes))
+		return
+	}
+	for n, e := range events {
+		if e.Type != expectedTypes[n] {
+			t.Errorf("%s != %s", e.Type, expectedTypes[n])
+		}
+	}
+
+	mergedNodes, err = finder.GetAllNodes()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(mergedNodes) != 2 {
+		t.Errorf(testFinderNodeCountError, len(mergedNodes), 2)
+	}
+}
+
+func TestMultiFinderNotifyListener(t *testing.T) {
+	nodes := setupTestHashRingNodes(2)
+	staticFinder := NewStaticFinderWithNodes(nodes[0:1])
+
+	// The listeners of the source finders are kept
+
+	sourceListener := &testNotifyListener{}
+	if err := staticFinder.SetNotifyListener(sourceListener); err != nil {
+		t.Error(err)
+		return
+	}
+
+	finder, err := NewMultiFinder(staticFinder)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	listener := &testNotifyListener{}
+	if err := finder.SetNotifyListener(listener); err != nil {
+		t.Error(err)
+		return
+	}
+
+	staticFinder.(*StaticFinder).postNotification(nodes[0])
+	if len(sourceListener.Nodes()) != 1 {
+		t.Errorf(testFinderNodeCountError, len(sourceListener.Nodes()), 1)
+	}
+
+	// The nodes added by the source finders are notified
+
+	if err := staticFinder.(testNodeMutator).addNode(nodes[1]); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(listener.Nodes()) != 1 {
+		t.Errorf(testFinderNodeCountError, len(listener.Nodes()), 1)
+	}
+
+	searchListener := &testSearchListener{}
+	if err := finder.SetSearchListener(searchListener); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := finder.SearchContext(context.Background(), NewSearchOptions()); err != nil {
+		t.Error(err)
+	}
+	if len(searchListener.Nodes()) != 2 {
+		t.Errorf(testFinderNodeCountError, len(searchListener.Nodes()), 2)
+	}
+}
+
+func TestMultiFinderSourceNodes(t *testing.T) {
+	srcNode := setupTestHashRingNodes(1)[0].(*node.BaseNode)
+	firstFinder := NewStaticFinderWithNodes([]Node{})
+	secondFinder := NewStaticFinderWithNodes([]Node{})
+
+	firstNode := node.NewBaseNode().SetHost(srcNode.Host()).SetAddress(srcNode.Address())
+	firstNode.SetClock(1)
+	if err := firstFinder.(testNodeMutator).addNode(firstNode); err != nil {
+		t.Error(err)
+		return
+	}
+	secondNode := node.NewBaseNode().SetHost(srcNode.Host()).SetAddress(srcNode.Address())
+	secondNode.SetClock(2)
+	if err := secondFinder.(testNodeMutator).addNode(secondNode); err != nil {
+		t.Error(err)
+		return
+	}
+
+	finder, err := NewMultiFinder(firstFinder, secondFinder)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// The merged node is replaced with the node of the remaining source
+
+	if err := secondFinder.RemoveNode(srcNode); err != nil {
+		t.Error(err)
+		return
+	}
+	nodes, err := finder.GetAllNodes()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(nodes) != 1 {
+		t.Errorf(testFinderNodeCountError, len(nodes), 1)
+		return
+	}
+	if nodes[0].Clock() != firstNode.Clock() {
+		t.Errorf("%d != %d", nodes[0].Clock(), firstNode.Clock())
+	}
+}
+
+type testFailingFinder struct {
+	Finder
+}
+
+func (finder *testFailingFinder) GetAllNodes() ([]Node, error) {
+	return nil, fmt.Errorf("%s : failed", finder.Finder)
+}
+
+func TestMultiFinderSubscriptions(t *testing.T) {
+	staticFinder := NewStaticFinderWithNodes(setupTestHashRingNodes(1))
+	failingFinder := &testFailingFinder{Finder: NewStaticFinderWithNodes([]Node{})}
+
+	// The subscriptions are removed when the multi finder fails to be created
+
+	if _, err := NewMultiFinder(staticFinder, failingFinder); err == nil {
+		t.Errorf("created with a failing finder")
+	}
+	sources := []*StaticFinder{staticFinder.(*StaticFinder), failingFinder.Finder.(*StaticFinder)}
+	for _, source := range sources {
+		if len(source.eventListeners) != 0 {
+			t.Errorf(testFinderNodeCountError, len(source.eventListeners), 0)
+		}
+	}
+}
+
+func TestMultiFinderErrors(t *testing.T) {
+	if _, err := NewMultiFinder(); err == nil {
+		t.Errorf("created without finders")
+	}
+
+	finder, err := NewMultiFinder(NewStaticFinderWithNodes(setupTestHashRingNodes(2)))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err := finder.SetNodeTTL(-time.Second); err == nil {
+		t.Errorf("%s : set an invalid TTL", finder)
+	}
+}
+
+func TestMultiFinderConditionFilter(t *testing.T) {
+	echonetFinder := NewEchonetFinder().(*EchonetFinder)
+	echonetFinder.SetConditionFilter(node.ConditionReady)
+
+	finder, err := NewMultiFinder(echonetFinder)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	srcNode := setupTestHashRingNodes(1)[0].(*node.BaseNode)
+	if err := echonetFinder.addNode(srcNode); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Only the ready nodes of the source finders are merged
+
+	conds := []struct {
+		cond  node.Condition
+		count int
+	}{
+		{node.ConditionInitial, 0},
+		{node.ConditionReady, 1},
+		{node.ConditionOutOfDate, 0},
+		{node.ConditionReady, 1},
+	}
+	for _, c := range conds {
+		updatedNode := node.NewBaseNode().SetHost(srcNode.Host()).SetAddress(srcNode.Address())
+		updatedNode.SetCondition(c.cond)
+		echonetFinder.updateNode(updatedNode)
+		nodes, err := finder.GetAllNodes()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(nodes) != c.count {
+			t.Errorf("%s : "+testFinderNodeCountError, c.cond, len(nodes), c.count)
+		}
+	}
+}
@@ -14,6 +14,10 @@
 
 package finder
 
+import (
+	"context"
+)
+
 // SharedFinder represents a simple finder.
 type SharedFinder struct {
 	*baseFinder
@@ -28,12 +32,27 @@ func NewSharedFinder() Finder {
 	return sharedFinder
 }
 
+func init() {
+	mustRegisterFinder(FinderShared, func(opts *FinderOptions) (Finder, error) {
+		return NewSharedFinder(), nil
+	})
+}
+
 // SearchAll searches all nodes.
 func (finder *SharedFinder) Search() error {
 	return nil
 }
 
+// SearchContext returns all nodes because the nodes are never changed by searching.
+func (finder *SharedFinder) SearchContext(ctx context.Context, opts *SearchOptions) ([]Node, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return finder.GetAllNodes()
+}
+
 // Start starts the finder.
+// The node TTL is not applied because the nodes are never refreshed by searching.
 func (finder *SharedFinder) Start() error {
 	return nil
 }
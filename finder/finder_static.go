@@ -15,6 +15,8 @@
 package finder
 
 import (
+	"context"
+
 	"github.com/cybergarage/go-logger/log"
 )
 
@@ -32,19 +34,34 @@ func NewStaticFinderWithNodes(nodes []Node) Finder {
 	for _, node := range nodes {
 		err := finder.addNode(node)
 		if err != nil {
-			log.Errorf(err.Error())
+			log.Errorf("%s", err.Error())
 		}
 	}
 
 	return finder
 }
 
+func init() {
+	mustRegisterFinder(FinderStatic, func(opts *FinderOptions) (Finder, error) {
+		return NewStaticFinderWithConfig(opts.Config), nil
+	})
+}
+
 // SearchAll searches all nodes.
 func (finder *StaticFinder) Search() error {
 	return nil
 }
 
+// SearchContext returns all nodes because the nodes are never changed by searching.
+func (finder *StaticFinder) SearchContext(ctx context.Context, opts *SearchOptions) ([]Node, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return finder.GetAllNodes()
+}
+
 // Start starts the finder.
+// The node TTL is not applied because the nodes are never refreshed by searching.
 func (finder *StaticFinder) Start() error {
 	return nil
 }
@@ -0,0 +1,191 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cybergarage/go-finder/finder/node"
+	"github.com/cybergarage/go-logger/log"
+)
+
+const (
+	// DefaultStaticFileWatchInterval is the default interval of polling the configuration file.
+	DefaultStaticFileWatchInterval = time.Second
+)
+
+const (
+	errorStaticFileInvalidWatchInterval = "Invalid watch interval : %s"
+	errorStaticFileUnknownFormat        = "Unknown configuration file format : %s"
+	msgStaticFileReloadFailed           = "Failed to reload %s, keeping the last good config : %s"
+)
+
+// configLoader loads and validates the specified configuration file.
+type configLoader func(filename string) (Config, error)
+
+// staticFileLoaders is the configuration loaders of the file formats.
+var staticFileLoaders = map[string]configLoader{
+	FinderStaticToml: loadTOMLConfig,
+	FinderStaticJson: loadJSONConfig,
+	FinderStaticYaml: loadYAMLConfig,
+}
+
+// staticFileExtensions is the file formats of the file extensions.
+var staticFileExtensions = map[string]string{
+	".toml": FinderStaticToml,
+	".tml":  FinderStaticToml,
+	".conf": FinderStaticToml,
+	".json": FinderStaticJson,
+	".yaml": FinderStaticYaml,
+	".yml":  FinderStaticYaml,
+}
+
+// StaticFileFinder represents a static finder which reloads the nodes when the configuration file is changed.
+type StaticFileFinder struct {
+	*StaticFinder
+	format        string
+	filename      string
+	reloadMutex   sync.Mutex
+	watchMutex    sync.Mutex
+	watchInterval time.Duration
+	watcher       *fileWatcher
+}
+
+// StaticTOMLFinder represents a static finder of the TOML configuration file.
+type StaticTOMLFinder = StaticFileFinder
+
+// NewStaticFinderWithConfig returns a new static finder with specified nodes.
+func NewStaticFinderWithConfig(config FinderConfig) Finder {
+	return NewStaticFinderWithNodes(newConfigNodes(config))
+}
+
+// newConfigNodes returns the nodes of the specified configuration.
+func newConfigNodes(config FinderConfig) []Node {
+	nodes := []Node{}
+	for _, host := range config.Hosts {
+		node := node.NewBaseNode()
+		node.SetHost(host)
+		nodes = append(nodes, node)
+	}
+	for _, nodeConf := range config.Nodes {
+		nodes = append(nodes, nodeConf.newNode())
+	}
+	return nodes
+}
+
+// NewStaticFinderWithFile returns a new static finder with the nodes of the specified configuration file.
+// The file format is detected by the file extension, .toml, .tml, .conf, .json, .yaml or .yml.
+func NewStaticFinderWithFile(filename string) (Finder, error) {
+	format, ok := staticFileExtensions[strings.ToLower(filepath.Ext(filename))]
+	if !ok {
+		return nil, fmt.Errorf(errorStaticFileUnknownFormat, filename)
+	}
+	return newStaticFileFinder(format, filename)
+}
+
+// newStaticFileFinder returns a new static finder with the nodes of the specified configuration file in the format.
+// The finder watches the file while running, and applies the changed nodes.
+func newStaticFileFinder(format string, filename string) (Finder, error) {
+	conf := Config{}
+	if filename != "" {
+		log.Tracef("%s Config file path: %s", format, filename)
+		var err error
+		conf, err = staticFileLoaders[format](filename)
+		if err != nil {
+			return nil, err
+		}
+		log.Tracef("Got config: %s", filename)
+	}
+	finder := &StaticFileFinder{
+		StaticFinder:  NewStaticFinderWithNodes(newConfigNodes(conf.Finder)).(*StaticFinder),
+		format:        format,
+		filename:      filename,
+		watchInterval: DefaultStaticFileWatchInterval,
+		watcher:       nil,
+	}
+	return finder, nil
+}
+
+// SetWatchInterval sets the interval of polling the configuration file, and zero disables watching the file.
+// The interval is applied when the finder is started.
+func (finder *StaticFileFinder) SetWatchInterval(interval time.Duration) error {
+	if interval < 0 {
+		return fmt.Errorf(errorStaticFileInvalidWatchInterval, interval)
+	}
+	finder.watchMutex.Lock()
+	defer finder.watchMutex.Unlock()
+	finder.watchInterval = interval
+	return nil
+}
+
+// Reload reads the configuration file again, and replaces the nodes with the new nodes.
+// The current nodes are kept when the file could not be loaded.
+func (finder *StaticFileFinder) Reload() error {
+	if finder.filename == "" {
+		return nil
+	}
+
+	finder.reloadMutex.Lock()
+	defer finder.reloadMutex.Unlock()
+
+	conf, err := staticFileLoaders[finder.format](finder.filename)
+	if err != nil {
+		log.Warnf(msgStaticFileReloadFailed, finder.filename, err.Error())
+		return err
+	}
+	finder.setNodes(newConfigNodes(conf.Finder))
+
+	return nil
+}
+
+// Start starts the finder, and starts watching the configuration file.
+func (finder *StaticFileFinder) Start() error {
+	err := finder.StaticFinder.Start()
+	if err != nil {
+		return err
+	}
+
+	finder.watchMutex.Lock()
+	defer finder.watchMutex.Unlock()
+	if finder.watcher != nil || finder.filename == "" || finder.watchInterval <= 0 {
+		return nil
+	}
+	finder.watcher = startFileWatcher(finder.filename, finder.watchInterval, func() {
+		finder.Reload()
+	})
+
+	return nil
+}
+
+// Stop stops watching the configuration file, and stops the finder.
+func (finder *StaticFileFinder) Stop() error {
+	finder.watchMutex.Lock()
+	if finder.watcher != nil {
+		finder.watcher.stop()
+		finder.watcher = nil
+	}
+	finder.watchMutex.Unlock()
+
+	return finder.StaticFinder.Stop()
+}
+
+// String returns the description.
+func (finder *StaticFileFinder) String() string {
+	return finder.format
+}
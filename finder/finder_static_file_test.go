@@ -0,0 +1,333 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+func testFinderNodeSet(t *testing.T, finder Finder) []string {
+	t.Helper()
+	nodes, err := finder.GetAllNodes()
+	if err != nil {
+		t.Error(err)
+		return nil
+	}
+	nodeSet := make([]string, len(nodes))
+	for n, node := range nodes {
+		nodeSet[n] = fmt.Sprintf("%s/%s/%s/%d", node.Cluster(), node.Host(), node.Address(), node.RPCPort())
+	}
+	sort.Strings(nodeSet)
+	return nodeSet
+}
+
+func TestStaticFileFinderFormats(t *testing.T) {
+	filenames := []string{
+		"finder_config_nodes_test.conf",
+		"finder_config_nodes_test.json",
+		"finder_config_nodes_test.yaml",
+	}
+	loaders := []configLoader{
+		loadTOMLConfig,
+		loadJSONConfig,
+		loadYAMLConfig,
+	}
+	formats := []string{
+		FinderStaticToml,
+		FinderStaticJson,
+		FinderStaticYaml,
+	}
+
+	var firstConf Config
+	var firstNodeSet []string
+	for n, filename := range filenames {
+		conf, err := loaders[n](filename)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		finder, err := NewStaticFinderWithFile(filename)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if finder.String() != formats[n] {
+			t.Errorf("%s != %s", finder.String(), formats[n])
+		}
+		nodeSet := testFinderNodeSet(t, finder)
+		if len(nodeSet) != 3 {
+			t.Errorf(testFinderNodeCountError, len(nodeSet), 3)
+		}
+
+		if n == 0 {
+			firstConf = conf
+			firstNodeSet = nodeSet
+			continue
+		}
+		if !reflect.DeepEqual(conf, firstConf) {
+			t.Errorf("%s : %v != %v", filename, conf, firstConf)
+		}
+		if !reflect.DeepEqual(nodeSet, firstNodeSet) {
+			t.Errorf("%s : %v != %v", filename, nodeSet, firstNodeSet)
+		}
+	}
+}
+
+func TestStaticFileFinderRoundTrip(t *testing.T) {
+	conf := Config{
+		Finder: FinderConfig{
+			Hosts: []string{"org.cybergarage.finder000"},
+			Nodes: []NodeConfig{
+				{
+					Cluster:    "test cluster",
+					Name:       "org.cybergarage.finder001",
+					Address:    "192.168.100.1",
+					RPCPort:    8001,
+					CarbonPort: 2003,
+					RenderPort: 8080,
+					Labels:     map[string]string{"zone": "a", "role": "storage"},
+				},
+				{
+					Name:    "org.cybergarage.finder002",
+					Address: "192.168.100.2",
+				},
+				{
+					Address: "192.168.100.3",
+					RPCPort: 8001,
+				},
+			},
+		},
+	}
+
+	var tomlData bytes.Buffer
+	if err := toml.NewEncoder(&tomlData).Encode(conf); err != nil {
+		t.Error(err)
+		return
+	}
+	jsonData, err := json.Marshal(conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	yamlData, err := yaml.Marshal(conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	testCases := []struct {
+		filename string
+		data     []byte
+		loader   configLoader
+	}{
+		{"finder.toml", tomlData.Bytes(), loadTOMLConfig},
+		{"finder.json", jsonData, loadJSONConfig},
+		{"finder.yml", yamlData, loadYAMLConfig},
+	}
+
+	dir := t.TempDir()
+	var firstNodeSet []string
+	for n, testCase := range testCases {
+		filename := filepath.Join(dir, testCase.filename)
+		if err := os.WriteFile(filename, testCase.data, 0o600); err != nil {
+			t.Error(err)
+			continue
+		}
+
+		loadedConf, err := testCase.loader(filename)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !reflect.DeepEqual(loadedConf, conf) {
+			t.Errorf("%s : %v != %v", filename, loadedConf, conf)
+		}
+
+		finder, err := NewStaticFinderWithFile(filename)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		nodeSet := testFinderNodeSet(t, finder)
+		if len(nodeSet) != 4 {
+			t.Errorf(testFinderNodeCountError, len(nodeSet), 4)
+		}
+		if n == 0 {
+			firstNodeSet = nodeSet
+			continue
+		}
+		if !reflect.DeepEqual(nodeSet, firstNodeSet) {
+			t.Errorf("%s : %v != %v", filename, nodeSet, firstNodeSet)
+		}
+	}
+
+	// The unknown keys in the finder section are rejected in all formats, and the other sections are ignored
+
+	unknownKeyCases := []struct {
+		filename string
+		conf     string
+		line     int
+	}{
+		{
+			filename: "finder_unknown.toml",
+			conf:     "[server]\ncluster = \"test cluster\"\n\n[finder]\nhosts = [\"org.cybergarage.finder000\"]\n\n[[finder.nodes]]\nname = \"org.cybergarage.finder001\"\nrpc_prot = 8001\n",
+			line:     9,
+		},
+		{
+			filename: "finder_unknown.json",
+			conf:     "{\n  \"server\": {\"cluster\": \"test cluster\"},\n  \"finder\": {\n    \"hosts\": [\"org.cybergarage.finder000\"],\n    \"nodes\": [\n      {\n        \"name\": \"org.cybergarage.finder001\",\n        \"rpc_prot\": 8001\n      }\n    ]\n  }\n}\n",
+			line:     8,
+		},
+		{
+			filename: "finder_unknown.yaml",
+			conf:     "server:\n  cluster: test cluster\nfinder:\n  hosts:\n    - org.cybergarage.finder000\n  nodes:\n    - name: org.cybergarage.finder001\n      rpc_prot: 8001\n",
+			line:     8,
+		},
+	}
+	for _, testCase := range unknownKeyCases {
+		filename := filepath.Join(dir, testCase.filename)
+		if err := os.WriteFile(filename, []byte(testCase.conf), 0o600); err != nil {
+			t.Error(err)
+			continue
+		}
+		_, err := NewStaticFinderWithFile(filename)
+		var keyErr *ConfigKeyError
+		if !errors.As(err, &keyErr) {
+			t.Errorf("%s : %v is not a config key error", filename, err)
+			continue
+		}
+		if keyErr.Key != "finder.nodes.rpc_prot" || keyErr.Line != testCase.line {
+			t.Errorf("%s : %s:%d != %s:%d", filename, keyErr.Key, keyErr.Line, "finder.nodes.rpc_prot", testCase.line)
+		}
+
+		if err := os.WriteFile(filename, []byte(strings.ReplaceAll(testCase.conf, "rpc_prot", "rpc_port")), 0o600); err != nil {
+			t.Error(err)
+			continue
+		}
+		finder, err := NewStaticFinderWithFile(filename)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if nodeSet := testFinderNodeSet(t, finder); len(nodeSet) != 2 {
+			t.Errorf(testFinderNodeCountError, len(nodeSet), 2)
+		}
+	}
+}
+
+func TestStaticFileFinderUnknownFormat(t *testing.T) {
+	_, err := NewStaticFinderWithFile("finder.ini")
+	if err == nil {
+		t.Errorf("%s : loaded an unknown format", "finder.ini")
+	}
+}
+
+func TestStaticFileFinderValidation(t *testing.T) {
+	testCases := []struct {
+		filename string
+		conf     string
+		line     int
+	}{
+		{
+			filename: "finder.json",
+			conf:     `{"finder": {"nodes": [{"name": "org.cybergarage.finder001"}, {"rpc_port": 8001}]}}`,
+			line:     1,
+		},
+		{
+			filename: "finder_lines.json",
+			conf: `{
+  "finder": {
+    "hosts": ["org.cybergarage.finder001"],
+    "nodes": [
+      {"name": "org.cybergarage.finder001"},
+      {
+        "name": "org.cybergarage.finder002",
+        "address": "192.168.100.256"
+      }
+    ]
+  }
+}`,
+			line: 6,
+		},
+		{
+			filename: "finder.yaml",
+			conf: `
+finder:
+  nodes:
+    - name: org.cybergarage.finder001
+    - name: org.cybergarage.finder002
+      address: 192.168.100.256
+`,
+			line: 5,
+		},
+	}
+
+	dir := t.TempDir()
+	for _, testCase := range testCases {
+		filename := filepath.Join(dir, testCase.filename)
+		if err := os.WriteFile(filename, []byte(testCase.conf), 0o600); err != nil {
+			t.Error(err)
+			continue
+		}
+
+		_, err := NewStaticFinderWithFile(filename)
+		var nodeErr *NodeConfigError
+		if !errors.As(err, &nodeErr) {
+			t.Errorf("%s : %v is not a node config error", filename, err)
+			continue
+		}
+		if nodeErr.Index != 1 {
+			t.Errorf("%s : %d != %d", err, nodeErr.Index, 1)
+		}
+		if nodeErr.Line != testCase.line {
+			t.Errorf("%s : %d != %d", err, nodeErr.Line, testCase.line)
+		}
+		if !strings.HasPrefix(err.Error(), fmt.Sprintf("%s:%d: ", filename, testCase.line)) {
+			t.Errorf("%s", err)
+		}
+	}
+
+	// Syntax and type errors have line numbers in all formats
+
+	typeErrorConfs := map[string]string{
+		"finder_type.json":   "{\n  \"finder\": {\n    \"nodes\": [\n      {\"rpc_port\": \"8001\"}\n    ]\n  }\n}",
+		"finder_syntax.json": "{\n  \"finder\": {\n    \"nodes\": [\n      {\"rpc_port\" 8001}\n    ]\n  }\n}",
+		"finder_type.yaml":   "finder:\n  nodes:\n    - name: org.cybergarage.finder001\n      rpc_port: port\n",
+	}
+	for name, conf := range typeErrorConfs {
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, []byte(conf), 0o600); err != nil {
+			t.Error(err)
+			continue
+		}
+		_, err := NewStaticFinderWithFile(filename)
+		if err == nil || !strings.Contains(err.Error(), "line 4") {
+			t.Errorf("%s : %v has no line number", name, err)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	errorJSONConfigLine = "line %d: %w"
+	// jsonUnknownFieldPrefix is the prefix of the errors of the unknown fields which are returned by the decoder disallowing them.
+	jsonUnknownFieldPrefix = "json: unknown field "
+)
+
+// NewStaticFinderWithJSON returns a new static finder with the nodes of the specified JSON configuration file.
+// The finder watches the file while running, and applies the changed nodes.
+func NewStaticFinderWithJSON(filename string) (Finder, error) {
+	return newStaticFileFinder(FinderStaticJson, filename)
+}
+
+func init() {
+	mustRegisterFinder(FinderStaticJson, newStaticFileFinderFactory(FinderStaticJson))
+}
+
+// loadJSONConfig loads and validates the specified JSON configuration file.
+func loadJSONConfig(filename string) (Config, error) {
+	conf := Config{}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return conf, err
+	}
+
+	err = json.Unmarshal(data, &conf)
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return conf, fmt.Errorf(errorJSONConfigLine, jsonOffsetLine(data, syntaxErr.Offset), err)
+		}
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return conf, fmt.Errorf(errorJSONConfigLine, jsonOffsetLine(data, typeErr.Offset), err)
+		}
+		return conf, err
+	}
+
+	err = checkJSONFinderKeys(filename, data)
+	if err != nil {
+		return conf, err
+	}
+
+	err = conf.Finder.Validate()
+	if err != nil {
+		var nodeErr *NodeConfigError
+		if errors.As(err, &nodeErr) {
+			nodeErr.Filename = filename
+			nodeErr.Line = jsonNodeLine(data, nodeErr.Index)
+		}
+		return conf, err
+	}
+
+	return conf, nil
+}
+
+// checkJSONFinderKeys decodes the finder object of the specified data disallowing the unknown fields, and returns a ConfigKeyError
+// when the object has an unknown key. The other top-level keys are not checked as the TOML loader does.
+func checkJSONFinderKeys(filename string, data []byte) error {
+	root := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return err
+	}
+	for key, value := range root {
+		if !strings.EqualFold(key, "finder") {
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewReader(value))
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(&FinderConfig{})
+		if err == nil {
+			continue
+		}
+		name, ok := jsonUnknownField(err)
+		if !ok {
+			return err
+		}
+		path, line := jsonKeyLine(data, name)
+		return &ConfigKeyError{Filename: filename, Line: line, Key: path}
+	}
+	return nil
+}
+
+// jsonUnknownField returns the field name of the specified error of an unknown field, and false when the error is not of an unknown field.
+func jsonUnknownField(err error) (string, bool) {
+	quoted, ok := strings.CutPrefix(err.Error(), jsonUnknownFieldPrefix)
+	if !ok {
+		return "", false
+	}
+	name, err := strconv.Unquote(quoted)
+	if err != nil {
+		return "", false
+	}
+	return name, true
+}
+
+// jsonKeyLine returns the dotted path and the line number of the first key of the specified name in the finder object,
+// or the path in the finder object and zero when the key is not found.
+func jsonKeyLine(data []byte, name string) (string, int) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	path, offset, ok := jsonFindKey(decoder, []string{}, name)
+	if !ok {
+		return "finder." + name, 0
+	}
+	return strings.Join(path, "."), jsonOffsetLine(data, offset)
+}
+
+// jsonFindKey reads the value which starts at the current token, and returns the path and the offset of the first key of the specified name
+// in the finder object. The elements of the arrays have the same path as the arrays like the TOML keys.
+func jsonFindKey(decoder *json.Decoder, path []string, name string) ([]string, int64, bool) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, 0, false
+	}
+	switch token {
+	case json.Delim('{'):
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, 0, false
+			}
+			key, _ := token.(string)
+			keyPath := append(path[:len(path):len(path)], key)
+			if key == name && 1 < len(keyPath) && strings.EqualFold(keyPath[0], "finder") {
+				return keyPath, decoder.InputOffset(), true
+			}
+			if keyPath, offset, ok := jsonFindKey(decoder, keyPath, name); ok {
+				return keyPath, offset, true
+			}
+		}
+	case json.Delim('['):
+		for decoder.More() {
+			if keyPath, offset, ok := jsonFindKey(decoder, path, name); ok {
+				return keyPath, offset, true
+			}
+		}
+	default:
+		return nil, 0, false
+	}
+	// The end of the object or the array is read
+	decoder.Token()
+	return nil, 0, false
+}
+
+// jsonOffsetLine returns the line number of the specified byte offset.
+func jsonOffsetLine(data []byte, offset int64) int {
+	if offset < 0 {
+		return 0
+	}
+	if int64(len(data)) < offset {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// jsonObjectValue advances the decoder to the value of the specified key in the object which starts at the current token,
+// and returns false when the key is not found. The key is matched case-insensitively as json.Unmarshal does.
+func jsonObjectValue(decoder *json.Decoder, key string) bool {
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return false
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		if name, ok := token.(string); ok && strings.EqualFold(name, key) {
+			return true
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return false
+		}
+	}
+	return false
+}
+
+// jsonNodeLine returns the line number of the specified index of the nodes, or zero when the node is not found.
+func jsonNodeLine(data []byte, idx int) int {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if !jsonObjectValue(decoder, "finder") || !jsonObjectValue(decoder, "nodes") {
+		return 0
+	}
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return 0
+	}
+	for n := 0; decoder.More(); n++ {
+		if n == idx {
+			offset := decoder.InputOffset()
+			for offset < int64(len(data)) && strings.ContainsRune(" \t\r\n,", rune(data[offset])) {
+				offset++
+			}
+			return jsonOffsetLine(data, offset)
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return 0
+		}
+	}
+	return 0
+}
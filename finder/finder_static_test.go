@@ -16,6 +16,7 @@ package finder
 
 import (
 	"testing"
+	"time"
 )
 
 func TestStaticFinder(t *testing.T) {
@@ -39,3 +40,110 @@ func TestStaticFinder(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestStaticFinderExpandGraphiteTarget(t *testing.T) {
+	nodes := setupTestHashRingNodes(4)
+	finder := NewStaticFinderWithNodes(nodes)
+
+	testCases := []struct {
+		target  string
+		targets map[int]string
+	}{
+		{
+			target: "{org.cybergarage.ring001,org.cybergarage.ring002}.cpu.*",
+			targets: map[int]string{
+				1: "org.cybergarage.ring001.cpu.*",
+				2: "org.cybergarage.ring002.cpu.*",
+			},
+		},
+		{
+			target: "{org.cybergarage.ring001.cpu,org.cybergarage.ring001.mem,org.cybergarage.ring002.cpu}.*",
+			targets: map[int]string{
+				1: "org.cybergarage.ring001{.cpu.*,.mem.*}",
+				2: "org.cybergarage.ring002.cpu.*",
+			},
+		},
+		{
+			target: "org.cybergarage.{ring001,ring002}.cpu.*",
+			targets: map[int]string{
+				1: "org.cybergarage.ring001.cpu.*",
+				2: "org.cybergarage.ring002.cpu.*",
+			},
+		},
+		{
+			target: "org.cybergarage.*.cpu.{user,system}",
+			targets: map[int]string{
+				0: "org.cybergarage.ring000.cpu.{user,system}",
+				1: "org.cybergarage.ring001.cpu.{user,system}",
+				2: "org.cybergarage.ring002.cpu.{user,system}",
+				3: "org.cybergarage.ring003.cpu.{user,system}",
+			},
+		},
+		{
+			target: "org.cybergarage.ring00[!0-2].mem",
+			targets: map[int]string{
+				3: "org.cybergarage.ring003.mem",
+			},
+		},
+		{
+			target: "192.168.100.{1,2}.disk",
+			targets: map[int]string{
+				1: "192.168.100.1.disk",
+				2: "192.168.100.2.disk",
+			},
+		},
+		{
+			target:  "org.cybergarage.ring009.cpu",
+			targets: map[int]string{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		targets, err := finder.ExpandGraphiteTarget(testCase.target)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if len(targets) != len(testCase.targets) {
+			t.Errorf(testFinderMatchingCountError, testCase.target, len(targets), len(testCase.targets))
+			continue
+		}
+		for n, expected := range testCase.targets {
+			if target := targets[nodes[n]]; target != expected {
+				t.Errorf("%s : %s != %s", testCase.target, target, expected)
+			}
+		}
+	}
+
+	if _, err := finder.ExpandGraphiteTarget("{org.cybergarage.ring001"); err == nil {
+		t.Errorf("expanded an invalid target")
+	}
+}
+
+func TestStaticFinderNodeTTL(t *testing.T) {
+	nodes := setupTestFinderNodes()
+	finder := NewStaticFinderWithNodes(nodes)
+
+	// The static nodes never expire because they are never refreshed
+
+	if err := finder.SetNodeTTL(10 * time.Millisecond); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := finder.Start(); err != nil {
+		t.Error(err)
+		return
+	}
+	defer finder.Stop()
+
+	time.Sleep(100 * time.Millisecond)
+
+	foundNodes, err := finder.GetAllNodes()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(foundNodes) != len(nodes) {
+		t.Errorf(testFinderNodeCountError, len(foundNodes), len(nodes))
+	}
+}
@@ -15,42 +15,97 @@
 package finder
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+
 	"github.com/BurntSushi/toml"
-	"github.com/cybergarage/go-finder/finder/node"
-	"github.com/cybergarage/go-logger/log"
 )
 
-// StaticFinder represents a simple static finder.
-type StaticTOMLFinder struct {
-	*StaticFinder
+var tomlNodeTableHeaderRegexp = regexp.MustCompile(`(?i)^\s*\[\[\s*finder\s*\.\s*nodes\s*\]\]`)
+
+// NewStaticFinderWithTOML returns a new static finder with the nodes of the specified TOML configuration file.
+// The finder watches the file while running, and applies the changed nodes.
+func NewStaticFinderWithTOML(filename string) (Finder, error) {
+	return newStaticFileFinder(FinderStaticToml, filename)
 }
 
-// NewStaticFinderWithConfig returns a new static finder with specified nodes.
-func NewStaticFinderWithConfig(config FinderConfig) Finder {
-	nodes := []Node{}
-	for _, host := range config.Hosts {
-		node := node.NewBaseNode()
-		node.SetHost(host)
-		nodes = append(nodes, node)
-	}
-	return NewStaticFinderWithNodes(nodes)
+func init() {
+	mustRegisterFinder(FinderStaticToml, newStaticFileFinderFactory(FinderStaticToml))
 }
 
-// NewStaticFinderWithTOML returns a new static finder with specified nodes.
-func NewStaticFinderWithTOML(filename string) (Finder, error) {
+// loadTOMLConfig loads and validates the specified TOML configuration file.
+func loadTOMLConfig(filename string) (Config, error) {
 	conf := Config{}
-	if filename != "" {
-		log.Tracef("TOML Config file path: %s", filename)
-		_, err := toml.DecodeFile(filename, &conf)
-		if err != nil {
-			return nil, err
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return conf, err
+	}
+
+	md, err := toml.Decode(string(data), &conf)
+	if err != nil {
+		return conf, err
+	}
+
+	for _, key := range md.Undecoded() {
+		if strings.EqualFold(key[0], "finder") {
+			return conf, &ConfigKeyError{Filename: filename, Line: tomlKeyLine(data, key), Key: key.String()}
 		}
-		log.Tracef("Got config: %s", filename)
 	}
-	return NewStaticFinderWithConfig(conf.Finder), nil
+
+	err = conf.Finder.Validate()
+	if err != nil {
+		var nodeErr *NodeConfigError
+		if errors.As(err, &nodeErr) {
+			nodeErr.Filename = filename
+			nodeErr.Line = tomlNodeTableLine(data, nodeErr.Index)
+		}
+		return conf, err
+	}
+
+	return conf, nil
 }
 
-// String returns the description.
-func (finder *StaticTOMLFinder) String() string {
-	return FinderStaticToml
+// tomlNodeTableLine returns the line number of the specified index of the node tables, or zero when the table is not found.
+func tomlNodeTableLine(data []byte, idx int) int {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	tableIdx := 0
+	for scanner.Scan() {
+		line++
+		if !tomlNodeTableHeaderRegexp.MatchString(scanner.Text()) {
+			continue
+		}
+		if tableIdx == idx {
+			return line
+		}
+		tableIdx++
+	}
+	return 0
+}
+
+// tomlKeyLine returns the line number of the first key/value pair of the last piece of the specified key, or zero when the pair is not found.
+func tomlKeyLine(data []byte, key toml.Key) int {
+	name := key[len(key)-1]
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		pair := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(pair, "[") {
+			continue
+		}
+		idx := strings.Index(pair, "=")
+		if idx < 0 {
+			continue
+		}
+		if strings.Trim(strings.TrimSpace(pair[:idx]), `"'`) == name {
+			return line
+		}
+	}
+	return 0
 }
@@ -15,7 +15,15 @@
 package finder
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 )
 
 const (
@@ -45,3 +53,406 @@ func TestStaticTOMLFinder(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+const (
+	finderConfigNodesTestFilename = "finder_config_nodes_test.conf"
+)
+
+func TestStaticTOMLFinderWithNodes(t *testing.T) {
+	finder, err := NewStaticFinderWithTOML(finderConfigNodesTestFilename)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = finderTest(t, finder)
+	if err != nil {
+		t.Error(err)
+	}
+
+	nodes, err := finder.GetAllNodes()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for n, node := range nodes {
+		if node.Cluster() != "test cluster" {
+			t.Errorf("%s != %s", node.Cluster(), "test cluster")
+		}
+		addr := net.IPv4(192, 168, 100, byte(n+1))
+		if !node.Address().Equal(addr) {
+			t.Errorf("%s != %s", node.Address(), addr)
+		}
+		if node.RPCPort() != 8001 {
+			t.Errorf("%d != %d", node.RPCPort(), 8001)
+		}
+	}
+}
+
+func TestStaticTOMLFinderWithHostsAndNodes(t *testing.T) {
+	conf := `
+[finder]
+hosts = ["org.cybergarage.finder001"]
+
+[[finder.nodes]]
+name = "org.cybergarage.finder002"
+address = "192.168.100.2"
+`
+	filename := filepath.Join(t.TempDir(), "finder.conf")
+	if err := os.WriteFile(filename, []byte(conf), 0o600); err != nil {
+		t.Error(err)
+		return
+	}
+
+	finder, err := NewStaticFinderWithTOML(filename)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	nodes, err := finder.GetAllNodes()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(nodes) != 2 {
+		t.Errorf(testFinderNodeCountError, len(nodes), 2)
+	}
+}
+
+func TestStaticTOMLFinderWithPorts(t *testing.T) {
+	conf := `
+[[finder.nodes]]
+name = "org.cybergarage.finder001"
+address = "192.168.100.1"
+rpc_port = 8001
+carbon_port = 2003
+
+  [finder.nodes.ports]
+  render = 8080
+  pickle = 2004
+`
+	filename := filepath.Join(t.TempDir(), "finder.conf")
+	if err := os.WriteFile(filename, []byte(conf), 0o600); err != nil {
+		t.Error(err)
+		return
+	}
+
+	finder, err := NewStaticFinderWithTOML(filename)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	nodes, err := finder.GetAllNodes()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(nodes) != 1 {
+		t.Errorf(testFinderNodeCountError, len(nodes), 1)
+		return
+	}
+	ports := nodes[0].Ports()
+	if ports.String() != "carbon=2003,pickle=2004,render=8080" {
+		t.Errorf("%s != %s", ports.String(), "carbon=2003,pickle=2004,render=8080")
+	}
+
+	targets := []string{
+		"org.cybergarage.finder001:8001",
+		"org.cybergarage.finder001:2003",
+		"192.168.100.1:2004",
+		"192.168.100.1:8080/render",
+	}
+	for _, target := range targets {
+		nodes, err := finder.GetPrefixNodes(target)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if len(nodes) != 1 {
+			t.Errorf(testFinderMatchingCountError, target, len(nodes), 1)
+		}
+	}
+
+	re := regexp.MustCompile(`^192\.168\.100\.1:8080$`)
+	nodes, err = finder.GetRegexpNodes(re)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(nodes) != 1 {
+		t.Errorf(testFinderMatchingCountError, re, len(nodes), 1)
+	}
+}
+
+func TestStaticTOMLFinderValidation(t *testing.T) {
+	testCases := []struct {
+		conf string
+		line int
+	}{
+		{
+			conf: `
+[[finder.nodes]]
+name = "org.cybergarage.finder001"
+
+[[finder.nodes]]
+rpc_port = 8001
+`,
+			line: 5,
+		},
+		{
+			conf: `
+[[finder.nodes]]
+name = "org.cybergarage.finder001"
+address = "192.168.100.256"
+`,
+			line: 2,
+		},
+		{
+			conf: `
+[finder]
+hosts = ["org.cybergarage.finder001"]
+
+[[finder.nodes]]
+name = "org.cybergarage.finder001"
+
+  [[ finder.nodes ]]
+  name = "org.cybergarage.finder002"
+  carbon_port = 65536
+`,
+			line: 8,
+		},
+		{
+			conf: `
+[[finder.nodes]]
+name = "org.cybergarage.finder001"
+rpc_port = 8001
+
+[[finder.nodes]]
+name = "org.cybergarage.finder001"
+rpc_port = 8001
+`,
+			line: 6,
+		},
+		{
+			conf: `
+[[finder.nodes]]
+name = "org.cybergarage.finder001"
+carbon_port = 2003
+ports = { carbon = 2004 }
+`,
+			line: 2,
+		},
+		{
+			conf: `
+[[finder.nodes]]
+name = "org.cybergarage.finder001"
+ports = { "-render" = 8080 }
+`,
+			line: 2,
+		},
+	}
+
+	dir := t.TempDir()
+	for n, testCase := range testCases {
+		filename := filepath.Join(dir, fmt.Sprintf("finder%d.conf", n))
+		if err := os.WriteFile(filename, []byte(testCase.conf), 0o600); err != nil {
+			t.Error(err)
+			continue
+		}
+
+		_, err := NewStaticFinderWithTOML(filename)
+		var nodeErr *NodeConfigError
+		if !errors.As(err, &nodeErr) {
+			t.Errorf("[%d] %v is not a node config error", n, err)
+			continue
+		}
+		if nodeErr.Line != testCase.line {
+			t.Errorf("[%d] %s : %d != %d", n, err, nodeErr.Line, testCase.line)
+		}
+		if !strings.HasPrefix(err.Error(), fmt.Sprintf("%s:%d: ", filename, testCase.line)) {
+			t.Errorf("[%d] %s", n, err)
+		}
+	}
+
+	// Syntax and type errors have line numbers of the TOML parser
+
+	filename := filepath.Join(dir, "finder_type.conf")
+	conf := `
+[[finder.nodes]]
+name = "org.cybergarage.finder001"
+rpc_port = "8001"
+`
+	if err := os.WriteFile(filename, []byte(conf), 0o600); err != nil {
+		t.Error(err)
+		return
+	}
+	_, err := NewStaticFinderWithTOML(filename)
+	if err == nil || !strings.Contains(err.Error(), "line 4") {
+		t.Errorf("%v has no line number", err)
+	}
+}
+
+func TestStaticTOMLFinderUnknownKey(t *testing.T) {
+	conf := `
+[Server]
+Cluster = "test cluster"
+
+[[finder.nodes]]
+name = "org.cybergarage.finder001"
+rpc_prot = 8001
+`
+	filename := filepath.Join(t.TempDir(), "finder.conf")
+	if err := os.WriteFile(filename, []byte(conf), 0o600); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// The misspelled key of the finder is reported, and the keys of other sections are ignored
+
+	_, err := NewStaticFinderWithTOML(filename)
+	var keyErr *ConfigKeyError
+	if !errors.As(err, &keyErr) {
+		t.Errorf("%v is not a config key error", err)
+		return
+	}
+	if keyErr.Key != "finder.nodes.rpc_prot" {
+		t.Errorf(testFinderMatchingError, "finder.nodes.rpc_prot", keyErr.Key)
+	}
+	if !strings.HasPrefix(err.Error(), fmt.Sprintf("%s:%d: ", filename, 7)) {
+		t.Errorf("%s has no line number", err)
+	}
+}
+
+func writeTestTOMLConfig(t *testing.T, filename string, conf string) {
+	t.Helper()
+	tmpFilename := filename + ".tmp"
+	if err := os.WriteFile(tmpFilename, []byte(conf), 0o600); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := os.Rename(tmpFilename, filename); err != nil {
+		t.Error(err)
+	}
+}
+
+func waitTestFinderNodeCount(finder Finder, count int) []Node {
+	timeout := time.Now().Add(5 * time.Second)
+	for {
+		nodes, _ := finder.GetAllNodes()
+		if len(nodes) == count || timeout.Before(time.Now()) {
+			return nodes
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStaticTOMLFinderReload(t *testing.T) {
+	confs := []string{
+		`
+[[finder.nodes]]
+name = "org.cybergarage.finder001"
+address = "192.168.100.1"
+
+[[finder.nodes]]
+name = "org.cybergarage.finder002"
+address = "192.168.100.2"
+`,
+		`
+[[finder.nodes]]
+name = "org.cybergarage.finder002"
+address = "192.168.100.2"
+
+[[finder.nodes]]
+name = "org.cybergarage.finder003"
+address = "192.168.100.3"
+
+[[finder.nodes]]
+name = "org.cybergarage.finder004"
+address = "192.168.100.4"
+`,
+		`
+[[finder.nodes]]
+name = "org.cybergarage.finder005"
+address = "192.168.100.256"
+`,
+	}
+
+	for _, interval := range []time.Duration{DefaultStaticFileWatchInterval, 0} {
+		filename := filepath.Join(t.TempDir(), "finder.conf")
+		writeTestTOMLConfig(t, filename, confs[0])
+
+		finder, err := NewStaticFinderWithTOML(filename)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		tomlFinder, ok := finder.(*StaticTOMLFinder)
+		if !ok {
+			t.Errorf("%s : not a TOML finder", finder)
+			return
+		}
+		if err := tomlFinder.SetWatchInterval(interval); err != nil {
+			t.Error(err)
+			return
+		}
+
+		listener := &testEventListener{}
+		if err := finder.AddEventListener(listener); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if err := finder.Start(); err != nil {
+			t.Error(err)
+			return
+		}
+
+		// Apply the changed nodes
+
+		writeTestTOMLConfig(t, filename, confs[1])
+		if interval <= 0 {
+			if err := tomlFinder.Reload(); err != nil {
+				t.Error(err)
+			}
+		}
+		nodes := waitTestFinderNodeCount(finder, 3)
+		if len(nodes) != 3 {
+			t.Errorf(testFinderNodeCountError, len(nodes), 3)
+		}
+
+		for timeout := time.Now().Add(5 * time.Second); len(listener.Events()) < 3 && time.Now().Before(timeout); {
+			time.Sleep(10 * time.Millisecond)
+		}
+		events := listener.Events()
+		eventCounts := map[EventType]int{}
+		for _, e := range events {
+			eventCounts[e.Type]++
+		}
+		if eventCounts[NodeRemoved] != 1 || eventCounts[NodeAdded] != 2 || len(events) != 3 {
+			t.Errorf("%v", eventCounts)
+		}
+
+		// Keep the last good config
+
+		writeTestTOMLConfig(t, filename, confs[2])
+		if err := tomlFinder.Reload(); err == nil {
+			t.Errorf("%s : reloaded an invalid config", filename)
+		}
+		nodes, err = finder.GetAllNodes()
+		if err != nil {
+			t.Error(err)
+		}
+		if len(nodes) != 3 {
+			t.Errorf(testFinderNodeCountError, len(nodes), 3)
+		}
+		if len(listener.Events()) != len(events) {
+			t.Errorf("%d != %d", len(listener.Events()), len(events))
+		}
+
+		if err := finder.Stop(); err != nil {
+			t.Error(err)
+		}
+	}
+}
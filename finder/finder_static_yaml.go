@@ -0,0 +1,161 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var yamlUnknownFieldRegexp = regexp.MustCompile(`^line (\d+): field (.+) not found in type `)
+
+// yamlConfig represents a YAML configuration file which has the other top-level keys than the finder key,
+// so that only the unknown keys in the finder mapping are rejected as the TOML loader does.
+type yamlConfig struct {
+	Finder FinderConfig   `yaml:"finder"`
+	Others map[string]any `yaml:",inline"`
+}
+
+// NewStaticFinderWithYAML returns a new static finder with the nodes of the specified YAML configuration file.
+// The finder watches the file while running, and applies the changed nodes.
+func NewStaticFinderWithYAML(filename string) (Finder, error) {
+	return newStaticFileFinder(FinderStaticYaml, filename)
+}
+
+func init() {
+	mustRegisterFinder(FinderStaticYaml, newStaticFileFinderFactory(FinderStaticYaml))
+}
+
+// loadYAMLConfig loads and validates the specified YAML configuration file.
+func loadYAMLConfig(filename string) (Config, error) {
+	conf := Config{}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return conf, err
+	}
+
+	root := yaml.Node{}
+	err = yaml.Unmarshal(data, &root)
+	if err != nil {
+		return conf, err
+	}
+
+	err = root.Decode(&conf)
+	if err != nil {
+		return conf, err
+	}
+
+	err = checkYAMLFinderKeys(filename, data, &root)
+	if err != nil {
+		return conf, err
+	}
+
+	err = conf.Finder.Validate()
+	if err != nil {
+		var nodeErr *NodeConfigError
+		if errors.As(err, &nodeErr) {
+			nodeErr.Filename = filename
+			nodeErr.Line = yamlNodeLine(&root, nodeErr.Index)
+		}
+		return conf, err
+	}
+
+	return conf, nil
+}
+
+// checkYAMLFinderKeys decodes the specified data with the known fields, and returns a ConfigKeyError when the finder mapping has an unknown key.
+func checkYAMLFinderKeys(filename string, data []byte, root *yaml.Node) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err := decoder.Decode(&yamlConfig{})
+	if err == nil || errors.Is(err, io.EOF) {
+		return nil
+	}
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return err
+	}
+	for _, msg := range typeErr.Errors {
+		match := yamlUnknownFieldRegexp.FindStringSubmatch(msg)
+		if match == nil {
+			continue
+		}
+		line, _ := strconv.Atoi(match[1])
+		path := yamlKeyPath(root, []string{}, match[2], line)
+		if path == nil {
+			path = []string{"finder", match[2]}
+		}
+		return &ConfigKeyError{Filename: filename, Line: line, Key: strings.Join(path, ".")}
+	}
+	return err
+}
+
+// yamlKeyPath returns the path of the key of the specified name at the specified line, or nil when the key is not found.
+// The elements of the sequences have the same path as the sequences like the TOML keys.
+func yamlKeyPath(yamlNode *yaml.Node, path []string, name string, line int) []string {
+	switch yamlNode.Kind {
+	case yaml.MappingNode:
+		for n := 0; n+1 < len(yamlNode.Content); n += 2 {
+			key := yamlNode.Content[n]
+			keyPath := append(path[:len(path):len(path)], key.Value)
+			if key.Value == name && key.Line == line {
+				return keyPath
+			}
+			if keyPath := yamlKeyPath(yamlNode.Content[n+1], keyPath, name, line); keyPath != nil {
+				return keyPath
+			}
+		}
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, content := range yamlNode.Content {
+			if keyPath := yamlKeyPath(content, path, name, line); keyPath != nil {
+				return keyPath
+			}
+		}
+	}
+	return nil
+}
+
+// yamlMappingValue returns the value of the specified key in the mapping, or nil when the key is not found.
+func yamlMappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for n := 0; n+1 < len(mapping.Content); n += 2 {
+		if mapping.Content[n].Value == key {
+			return mapping.Content[n+1]
+		}
+	}
+	return nil
+}
+
+// yamlNodeLine returns the line number of the specified index of the nodes, or zero when the node is not found.
+func yamlNodeLine(root *yaml.Node, idx int) int {
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		return 0
+	}
+	nodes := yamlMappingValue(yamlMappingValue(root.Content[0], "finder"), "nodes")
+	if nodes == nil || nodes.Kind != yaml.SequenceNode || len(nodes.Content) <= idx {
+		return 0
+	}
+	return nodes.Content[idx].Line
+}
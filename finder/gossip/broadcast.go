@@ -0,0 +1,88 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gossip
+
+import (
+	"math"
+	"sort"
+)
+
+// broadcast represents a membership update which is disseminated by piggybacking.
+type broadcast struct {
+	member    *Member
+	transmits int
+}
+
+// broadcastQueue represents the queue of the membership updates, and the caller must guard the queue.
+type broadcastQueue struct {
+	broadcasts []*broadcast
+}
+
+// newBroadcastQueue returns a new empty queue.
+func newBroadcastQueue() *broadcastQueue {
+	return &broadcastQueue{
+		broadcasts: []*broadcast{},
+	}
+}
+
+// retransmitLimit returns the number of times an update is piggybacked in the membership of the specified number of members.
+func retransmitLimit(multiplier int, members int) int {
+	scale := int(math.Ceil(math.Log10(float64(members + 1))))
+	if scale < 1 {
+		scale = 1
+	}
+	return multiplier * scale
+}
+
+// push queues a copy of the specified member, and replaces the queued update of the same member.
+func (queue *broadcastQueue) push(member *Member) {
+	for _, b := range queue.broadcasts {
+		if b.member.Address == member.Address {
+			b.member = member.Copy()
+			b.transmits = 0
+			return
+		}
+	}
+	queue.broadcasts = append(queue.broadcasts, &broadcast{member: member.Copy(), transmits: 0})
+}
+
+// pop returns the specified max number of the updates which are transmitted fewest times,
+// and removes the updates which are transmitted the specified limit times.
+func (queue *broadcastQueue) pop(max int, limit int) []*Member {
+	sort.SliceStable(queue.broadcasts, func(i, j int) bool {
+		return queue.broadcasts[i].transmits < queue.broadcasts[j].transmits
+	})
+	members := []*Member{}
+	for _, b := range queue.broadcasts {
+		if max <= len(members) {
+			break
+		}
+		members = append(members, b.member.Copy())
+		b.transmits++
+	}
+	broadcasts := queue.broadcasts[:0]
+	for _, b := range queue.broadcasts {
+		if b.transmits < limit {
+			broadcasts = append(broadcasts, b)
+		}
+	}
+	queue.broadcasts = broadcasts
+	return members
+}
+
+// len returns the number of the queued updates.
+func (queue *broadcastQueue) len() int {
+	return len(queue.broadcasts)
+}
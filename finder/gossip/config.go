@@ -0,0 +1,82 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gossip
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	errorConfigInvalidDuration = "invalid %s : %s"
+	errorConfigInvalidNumber   = "invalid %s : %d"
+)
+
+// Config represents parameters of the SWIM protocol.
+type Config struct {
+	// ProtocolPeriod is the interval to probe a member, and the probed member is suspected when it is not acked in the period.
+	ProtocolPeriod time.Duration
+	// PingTimeout is the timeout of the direct ping before the indirect pings are requested.
+	PingTimeout time.Duration
+	// IndirectChecks is the number of the members which are requested to ping the probed member indirectly.
+	IndirectChecks int
+	// SuspicionTimeout is the timeout until a suspected member is confirmed as dead unless the member refutes the suspicion.
+	SuspicionTimeout time.Duration
+	// ReclaimTimeout is the timeout until a dead or left member is removed from the members, and the member is not disseminated anymore.
+	ReclaimTimeout time.Duration
+	// RetransmitMultiplier is the multiplier of the number of times an update is piggybacked, and the number is scaled by log10 of the members.
+	RetransmitMultiplier int
+	// PiggybackMax is the max number of the updates which are piggybacked on a message.
+	PiggybackMax int
+}
+
+// NewDefaultConfig returns a new default configuration.
+func NewDefaultConfig() *Config {
+	return &Config{
+		ProtocolPeriod:       DefaultProtocolPeriod,
+		PingTimeout:          DefaultPingTimeout,
+		IndirectChecks:       DefaultIndirectChecks,
+		SuspicionTimeout:     DefaultSuspicionTimeout,
+		ReclaimTimeout:       DefaultReclaimTimeout,
+		RetransmitMultiplier: DefaultRetransmitMultiplier,
+		PiggybackMax:         DefaultPiggybackMax,
+	}
+}
+
+// Validate returns an error when the configuration is invalid.
+func (config *Config) Validate() error {
+	if config.ProtocolPeriod <= 0 {
+		return fmt.Errorf(errorConfigInvalidDuration, "protocol period", config.ProtocolPeriod)
+	}
+	if config.PingTimeout <= 0 || config.ProtocolPeriod <= config.PingTimeout {
+		return fmt.Errorf(errorConfigInvalidDuration, "ping timeout", config.PingTimeout)
+	}
+	if config.IndirectChecks < 0 {
+		return fmt.Errorf(errorConfigInvalidNumber, "indirect checks", config.IndirectChecks)
+	}
+	if config.SuspicionTimeout <= 0 {
+		return fmt.Errorf(errorConfigInvalidDuration, "suspicion timeout", config.SuspicionTimeout)
+	}
+	if config.ReclaimTimeout <= 0 {
+		return fmt.Errorf(errorConfigInvalidDuration, "reclaim timeout", config.ReclaimTimeout)
+	}
+	if config.RetransmitMultiplier <= 0 {
+		return fmt.Errorf(errorConfigInvalidNumber, "retransmit multiplier", config.RetransmitMultiplier)
+	}
+	if config.PiggybackMax <= 0 {
+		return fmt.Errorf(errorConfigInvalidNumber, "piggyback max", config.PiggybackMax)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gossip
+
+import (
+	"time"
+)
+
+const (
+	// DefaultPort is the default UDP port of the gossip messages.
+	DefaultPort = 7946
+	// MessageMaxSize is the max size of the gossip messages.
+	MessageMaxSize = 65507
+)
+
+const (
+	// DefaultProtocolPeriod is the default interval to probe a member.
+	DefaultProtocolPeriod = time.Second
+	// DefaultPingTimeout is the default timeout of the direct pings before the indirect pings are requested.
+	DefaultPingTimeout = 300 * time.Millisecond
+	// DefaultIndirectChecks is the default number of the members which are requested to ping the probed member indirectly.
+	DefaultIndirectChecks = 3
+	// DefaultSuspicionTimeout is the default timeout until a suspected member is confirmed as dead.
+	DefaultSuspicionTimeout = 5 * time.Second
+	// DefaultReclaimTimeout is the default timeout until a dead or left member is removed from the members.
+	DefaultReclaimTimeout = 30 * time.Second
+	// DefaultRetransmitMultiplier is the default multiplier of the number of times an update is piggybacked.
+	DefaultRetransmitMultiplier = 4
+	// DefaultPiggybackMax is the default max number of the updates which are piggybacked on a message.
+	DefaultPiggybackMax = 16
+)
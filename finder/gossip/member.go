@@ -0,0 +1,148 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gossip
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/cybergarage/go-finder/finder/node"
+)
+
+// State represents a membership state of the SWIM protocol.
+type State uint8
+
+const (
+	// StateAlive is the state of the members which respond to the probes.
+	StateAlive State = 0
+	// StateSuspect is the state of the members which do not respond to the probes until the suspicion is confirmed or refuted.
+	StateSuspect State = 1
+	// StateDead is the state of the members whose suspicion is confirmed.
+	StateDead State = 2
+	// StateLeft is the state of the members which leave the membership gracefully.
+	StateLeft State = 3
+)
+
+// String returns the state name.
+func (state State) String() string {
+	switch state {
+	case StateAlive:
+		return "Alive"
+	case StateSuspect:
+		return "Suspect"
+	case StateDead:
+		return "Dead"
+	case StateLeft:
+		return "Left"
+	}
+	return fmt.Sprintf("0x%02X", uint(state))
+}
+
+const (
+	// LabelState is the label of the member nodes whose value is the member state such as "Suspect",
+	// so the suspected members can be excluded by the label selectors such as "gossip.state!=Suspect".
+	LabelState = "gossip.state"
+)
+
+// Member represents a member of the gossip membership, and the address is the member identity.
+type Member struct {
+	Address     string         `json:"address"`
+	Incarnation uint32         `json:"incarnation"`
+	State       State          `json:"state"`
+	Cluster     string         `json:"cluster,omitempty"`
+	Host        string         `json:"host,omitempty"`
+	NodeAddress string         `json:"node_address,omitempty"`
+	RPCPort     uint           `json:"rpc_port,omitempty"`
+	Ports       node.Ports     `json:"ports,omitempty"`
+	Labels      node.Labels    `json:"labels,omitempty"`
+	Condition   node.Condition `json:"condition,omitempty"`
+	Clock       node.Clock     `json:"clock,omitempty"`
+}
+
+// NewMemberWithNode returns a new alive member of the specified gossip address and node.
+func NewMemberWithNode(addr string, srcNode node.Node) *Member {
+	member := &Member{
+		Address:     addr,
+		Incarnation: 0,
+		State:       StateAlive,
+		Cluster:     srcNode.Cluster(),
+		Host:        srcNode.Host(),
+		NodeAddress: "",
+		RPCPort:     srcNode.RPCPort(),
+		Ports:       srcNode.Ports().Copy(),
+		Labels:      srcNode.Labels().Copy(),
+		Condition:   srcNode.Condition(),
+		Clock:       srcNode.Clock(),
+	}
+	if ip := srcNode.Address(); ip != nil {
+		member.NodeAddress = ip.String()
+	}
+	return member
+}
+
+// IsActive returns true when the member is alive or suspected, otherwise false.
+func (member *Member) IsActive() bool {
+	return member.State == StateAlive || member.State == StateSuspect
+}
+
+// Copy returns a deep copy of the member.
+func (member *Member) Copy() *Member {
+	copied := *member
+	copied.Ports = member.Ports.Copy()
+	copied.Labels = member.Labels.Copy()
+	return &copied
+}
+
+// NodeEqual returns true when the member has the same node as the other member, otherwise false.
+func (member *Member) NodeEqual(other *Member) bool {
+	if member.Cluster != other.Cluster || member.Host != other.Host || member.NodeAddress != other.NodeAddress || member.RPCPort != other.RPCPort {
+		return false
+	}
+	if member.Condition != other.Condition || member.Clock != other.Clock {
+		return false
+	}
+	return node.PortsEqual(member.Ports, other.Ports) && node.LabelsEqual(member.Labels, other.Labels)
+}
+
+// Node returns a new node of the member, and the condition of the node is driven by the member state.
+// The alive and suspected members have the advertised condition, the dead members are out of date and the left members are stopped.
+// The member state is set to the LabelState label of the node.
+func (member *Member) Node() *node.BaseNode {
+	memberNode := node.NewBaseNode().
+		SetCluster(member.Cluster).
+		SetHost(member.Host).
+		SetRPCPort(member.RPCPort).
+		SetPorts(member.Ports).
+		SetLabels(member.Labels).
+		SetLabel(LabelState, member.State.String())
+	if ip := net.ParseIP(member.NodeAddress); ip != nil {
+		memberNode.SetAddress(ip)
+	}
+	memberNode.SetClock(member.Clock)
+	switch member.State {
+	case StateDead:
+		memberNode.SetCondition(node.ConditionOutOfDate)
+	case StateLeft:
+		memberNode.SetCondition(node.ConditionStop)
+	default:
+		memberNode.SetCondition(member.Condition)
+	}
+	return memberNode
+}
+
+// String returns the description.
+func (member *Member) String() string {
+	return fmt.Sprintf("%s (%s, %d)", member.Address, member.State, member.Incarnation)
+}
@@ -0,0 +1,641 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gossip
+
+import (
+	"errors"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cybergarage/go-finder/finder/node"
+	"github.com/cybergarage/go-logger/log"
+)
+
+const (
+	errorMembershipNotRunning = "membership is not running"
+)
+
+const (
+	msgMembershipInvalidMessage = "Invalid gossip message from %s : %s"
+	msgMembershipSuspected      = "Member %s is suspected"
+	msgMembershipDead           = "Member %s is dead"
+	msgMembershipRefuted        = "Suspicion of the local member is refuted (%d)"
+	msgMembershipReclaimed      = "Member %s is reclaimed"
+)
+
+// MembershipListener represents a listener of the membership changes.
+type MembershipListener interface {
+	// MemberChanged is called when the state or the node of the member other than the local member is changed.
+	MemberChanged(member *Member)
+	// MembersSynced is called with the active members which a member answered to the sync.
+	MembersSynced(members []*Member)
+	// MemberReclaimed is called when the dead or left member is removed from the members after the reclaim timeout.
+	MemberReclaimed(member *Member)
+}
+
+// probe represents the running probe of a protocol period.
+type probe struct {
+	target     string
+	seqNo      uint32
+	start      time.Time
+	isIndirect bool
+}
+
+// relay represents an indirect ping which is requested by the origin member.
+type relay struct {
+	origin string
+	seqNo  uint32
+	start  time.Time
+}
+
+// outgoing represents a message which is sent after the membership is unlocked.
+type outgoing struct {
+	msg *Message
+	to  string
+}
+
+// Membership represents a membership of the SWIM protocol which probes the members with the direct and indirect pings,
+// suspects the members which are not acked, and disseminates the updates by piggybacking them on the protocol messages.
+// The protocol has no timers, and it is driven by Tick so that it is deterministic with the in-memory transport.
+type Membership struct {
+	mutex       sync.Mutex
+	config      *Config
+	localNode   node.Node
+	transport   Transport
+	listener    MembershipListener
+	seeds       []string
+	local       *Member
+	members     map[string]*Member
+	suspectedAt map[string]time.Time
+	inactiveAt  map[string]time.Time
+	broadcasts  *broadcastQueue
+	probe       *probe
+	probeOrder  []string
+	relays      map[uint32]*relay
+	seqNo       uint32
+	now         time.Time
+	nextProbe   time.Time
+	rand        *rand.Rand
+	isRunning   bool
+}
+
+// NewMembership returns a new membership of the specified local node on the transport.
+func NewMembership(localNode node.Node, transport Transport, config *Config) *Membership {
+	now := time.Now()
+	return &Membership{
+		config:      config,
+		localNode:   localNode,
+		transport:   transport,
+		listener:    nil,
+		seeds:       []string{},
+		local:       nil,
+		members:     map[string]*Member{},
+		suspectedAt: map[string]time.Time{},
+		inactiveAt:  map[string]time.Time{},
+		broadcasts:  newBroadcastQueue(),
+		probe:       nil,
+		probeOrder:  []string{},
+		relays:      map[uint32]*relay{},
+		seqNo:       0,
+		now:         now,
+		nextProbe:   now,
+		rand:        rand.New(rand.NewPCG(uint64(now.UnixNano()), 0)),
+		isRunning:   false,
+	}
+}
+
+// SetConfig sets the parameters of the protocol.
+func (membership *Membership) SetConfig(config *Config) {
+	membership.mutex.Lock()
+	defer membership.mutex.Unlock()
+	membership.config = config
+}
+
+// Config returns the parameters of the protocol.
+func (membership *Membership) Config() *Config {
+	membership.mutex.Lock()
+	defer membership.mutex.Unlock()
+	return membership.config
+}
+
+// SetListener sets the listener of the membership changes.
+func (membership *Membership) SetListener(l MembershipListener) {
+	membership.mutex.Lock()
+	defer membership.mutex.Unlock()
+	membership.listener = l
+}
+
+// SetSeeds sets the gossip addresses which the membership joins to.
+func (membership *Membership) SetSeeds(seeds ...string) {
+	membership.mutex.Lock()
+	defer membership.mutex.Unlock()
+	membership.seeds = append([]string{}, seeds...)
+}
+
+// Seeds returns the gossip addresses which the membership joins to.
+func (membership *Membership) Seeds() []string {
+	membership.mutex.Lock()
+	defer membership.mutex.Unlock()
+	return append([]string{}, membership.seeds...)
+}
+
+// Address returns the gossip address of the local member.
+func (membership *Membership) Address() string {
+	return membership.transport.Address()
+}
+
+// LocalMember returns a copy of the local member, or nil when the membership is not started.
+func (membership *Membership) LocalMember() *Member {
+	membership.mutex.Lock()
+	defer membership.mutex.Unlock()
+	if membership.local == nil {
+		return nil
+	}
+	return membership.local.Copy()
+}
+
+// Member returns a copy of the member of the specified gossip address.
+func (membership *Membership) Member(addr string) (*Member, bool) {
+	membership.mutex.Lock()
+	defer membership.mutex.Unlock()
+	member, ok := membership.members[addr]
+	if !ok {
+		return nil, false
+	}
+	return member.Copy(), true
+}
+
+// Members returns copies of all members except the local member in the address order.
+func (membership *Membership) Members() []*Member {
+	membership.mutex.Lock()
+	defer membership.mutex.Unlock()
+	members := make([]*Member, 0, len(membership.members))
+	for _, member := range membership.members {
+		members = append(members, member.Copy())
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Address < members[j].Address
+	})
+	return members
+}
+
+// Start starts the transport, and joins to the seeds.
+func (membership *Membership) Start() error {
+	membership.transport.SetHandler(membership.messageReceived)
+	if err := membership.transport.Start(); err != nil {
+		return err
+	}
+
+	membership.mutex.Lock()
+	if membership.local == nil {
+		membership.local = NewMemberWithNode(membership.transport.Address(), membership.localNode)
+	} else {
+		membership.local.Incarnation++
+		membership.local.State = StateAlive
+	}
+	membership.broadcasts.push(membership.local)
+	membership.isRunning = true
+	outgoings := membership.joinMessages()
+	membership.mutex.Unlock()
+
+	membership.send(outgoings)
+	return nil
+}
+
+// Stop tells the active members that the local member leaves, and stops the transport.
+func (membership *Membership) Stop() error {
+	membership.mutex.Lock()
+	if !membership.isRunning {
+		membership.mutex.Unlock()
+		return nil
+	}
+	membership.isRunning = false
+	membership.local.Incarnation++
+	membership.local.State = StateLeft
+	outgoings := []*outgoing{}
+	for _, member := range membership.members {
+		if !member.IsActive() {
+			continue
+		}
+		msg := NewMessage(MessagePing, membership.local.Address)
+		msg.Target = member.Address
+		msg.Updates = []*Member{membership.local.Copy()}
+		outgoings = append(outgoings, &outgoing{msg: msg, to: member.Address})
+	}
+	membership.probe = nil
+	membership.mutex.Unlock()
+
+	membership.send(outgoings)
+	return membership.transport.Stop()
+}
+
+// IsRunning returns true when the membership is running, otherwise false.
+func (membership *Membership) IsRunning() bool {
+	membership.mutex.Lock()
+	defer membership.mutex.Unlock()
+	return membership.isRunning
+}
+
+// Sync pushes all members to the seeds and the active members, and the answered members are posted to the listener.
+func (membership *Membership) Sync() error {
+	membership.mutex.Lock()
+	if !membership.isRunning {
+		membership.mutex.Unlock()
+		return errors.New(errorMembershipNotRunning)
+	}
+	outgoings := membership.joinMessages()
+	for _, member := range membership.members {
+		if !member.IsActive() || membership.isSeed(member.Address) {
+			continue
+		}
+		outgoings = append(outgoings, &outgoing{msg: membership.syncMessage(MessageSync), to: member.Address})
+	}
+	membership.mutex.Unlock()
+
+	membership.send(outgoings)
+	return nil
+}
+
+// Tick advances the protocol to the specified time. The running probe is escalated to the indirect pings after the ping timeout,
+// and the probed member is suspected when it is not acked in the protocol period. The suspected members are confirmed as dead after
+// the suspicion timeout, and the dead or left members are reclaimed after the reclaim timeout. The next member is probed
+// in the round-robin order at every protocol period.
+func (membership *Membership) Tick(now time.Time) {
+	membership.mutex.Lock()
+	if !membership.isRunning {
+		membership.mutex.Unlock()
+		return
+	}
+	membership.now = now
+	outgoings := []*outgoing{}
+	changedMembers := []*Member{}
+
+	membership.updateLocalMember()
+
+	if p := membership.probe; p != nil {
+		if !p.isIndirect && !now.Before(p.start.Add(membership.config.PingTimeout)) {
+			p.isIndirect = true
+			outgoings = append(outgoings, membership.pingReqMessages(p)...)
+		}
+		if !now.Before(p.start.Add(membership.config.ProtocolPeriod)) {
+			membership.probe = nil
+			if member, ok := membership.members[p.target]; ok && member.State == StateAlive {
+				log.Infof(msgMembershipSuspected, member)
+				suspected := member.Copy()
+				suspected.State = StateSuspect
+				if membership.mergeMember(suspected) {
+					changedMembers = append(changedMembers, membership.members[p.target].Copy())
+				}
+			}
+		}
+	}
+
+	for addr, suspectedAt := range membership.suspectedAt {
+		if now.Before(suspectedAt.Add(membership.config.SuspicionTimeout)) {
+			continue
+		}
+		dead := membership.members[addr].Copy()
+		dead.State = StateDead
+		log.Infof(msgMembershipDead, dead)
+		if membership.mergeMember(dead) {
+			changedMembers = append(changedMembers, membership.members[addr].Copy())
+		}
+	}
+
+	reclaimedMembers := []*Member{}
+	for addr, inactiveAt := range membership.inactiveAt {
+		if now.Before(inactiveAt.Add(membership.config.ReclaimTimeout)) {
+			continue
+		}
+		reclaimed := membership.members[addr]
+		log.Infof(msgMembershipReclaimed, reclaimed)
+		delete(membership.members, addr)
+		delete(membership.inactiveAt, addr)
+		reclaimedMembers = append(reclaimedMembers, reclaimed.Copy())
+	}
+
+	for seqNo, r := range membership.relays {
+		if !now.Before(r.start.Add(membership.config.ProtocolPeriod)) {
+			delete(membership.relays, seqNo)
+		}
+	}
+
+	if membership.probe == nil && !now.Before(membership.nextProbe) {
+		membership.nextProbe = now.Add(membership.config.ProtocolPeriod)
+		if target := membership.nextProbeTarget(); target != "" {
+			membership.probe = &probe{target: target, seqNo: membership.nextSeqNo(), start: now, isIndirect: false}
+			msg := membership.newMessage(MessagePing, target)
+			msg.SeqNo = membership.probe.seqNo
+			msg.Target = target
+			outgoings = append(outgoings, &outgoing{msg: msg, to: target})
+		} else {
+			outgoings = append(outgoings, membership.joinMessages()...)
+		}
+	}
+
+	listener := membership.listener
+	membership.mutex.Unlock()
+
+	membership.send(outgoings)
+	membership.postChangedMembers(listener, changedMembers)
+	membership.postReclaimedMembers(listener, reclaimedMembers)
+}
+
+// updateLocalMember disseminates the local node with the next incarnation when the status, ports or labels of the local node are changed.
+// The host, address and RPC port are the node identity, so they are not refreshed. The clock is advanced continuously,
+// so it is carried by the local member without the next incarnation.
+func (membership *Membership) updateLocalMember() {
+	clock := membership.localNode.Clock()
+	current := membership.local.Copy()
+	current.Condition = membership.localNode.Condition()
+	current.Ports = membership.localNode.Ports().Copy()
+	current.Labels = membership.localNode.Labels().Copy()
+	if current.NodeEqual(membership.local) {
+		membership.local.Clock = clock
+		return
+	}
+	current.Clock = clock
+	current.Incarnation++
+	membership.local = current
+	membership.broadcasts.push(current)
+}
+
+// nextProbeTarget returns the next active member in the round-robin order which is shuffled at every round, or an empty string when no member is active.
+func (membership *Membership) nextProbeTarget() string {
+	for round := 0; round < 2; round++ {
+		for 0 < len(membership.probeOrder) {
+			addr := membership.probeOrder[0]
+			membership.probeOrder = membership.probeOrder[1:]
+			if member, ok := membership.members[addr]; ok && member.IsActive() {
+				return addr
+			}
+		}
+		for addr, member := range membership.members {
+			if member.IsActive() {
+				membership.probeOrder = append(membership.probeOrder, addr)
+			}
+		}
+		sort.Strings(membership.probeOrder)
+		membership.rand.Shuffle(len(membership.probeOrder), func(i, j int) {
+			membership.probeOrder[i], membership.probeOrder[j] = membership.probeOrder[j], membership.probeOrder[i]
+		})
+	}
+	return ""
+}
+
+// pingReqMessages returns the requests to ping the probed member indirectly which are sent to the random active members.
+func (membership *Membership) pingReqMessages(p *probe) []*outgoing {
+	candidates := []string{}
+	for addr, member := range membership.members {
+		if addr != p.target && member.State == StateAlive {
+			candidates = append(candidates, addr)
+		}
+	}
+	sort.Strings(candidates)
+	membership.rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	if membership.config.IndirectChecks < len(candidates) {
+		candidates = candidates[:membership.config.IndirectChecks]
+	}
+	outgoings := []*outgoing{}
+	for _, addr := range candidates {
+		msg := membership.newMessage(MessagePingReq, addr)
+		msg.SeqNo = p.seqNo
+		msg.Target = p.target
+		outgoings = append(outgoings, &outgoing{msg: msg, to: addr})
+	}
+	return outgoings
+}
+
+// joinMessages returns the syncs to the seeds except the local member.
+func (membership *Membership) joinMessages() []*outgoing {
+	outgoings := []*outgoing{}
+	for _, seed := range membership.seeds {
+		if seed == membership.local.Address {
+			continue
+		}
+		outgoings = append(outgoings, &outgoing{msg: membership.syncMessage(MessageSync), to: seed})
+	}
+	return outgoings
+}
+
+// isSeed returns true when the specified address is a seed, otherwise false.
+func (membership *Membership) isSeed(addr string) bool {
+	for _, seed := range membership.seeds {
+		if seed == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// nextSeqNo returns a new sequence number.
+func (membership *Membership) nextSeqNo() uint32 {
+	membership.seqNo++
+	return membership.seqNo
+}
+
+// newMessage returns a new message to the specified member with the piggybacked updates.
+// The local member is always piggybacked so that the destination member refreshes a stale view of the local member,
+// and the suspicion or death of the destination member is piggybacked so that the member can refute it.
+func (membership *Membership) newMessage(msgType MessageType, to string) *Message {
+	msg := NewMessage(msgType, membership.local.Address)
+	msg.Updates = append(msg.Updates, membership.local.Copy())
+	max := membership.config.PiggybackMax - 1
+	if member, ok := membership.members[to]; ok && (member.State == StateSuspect || member.State == StateDead) {
+		msg.Updates = append(msg.Updates, member.Copy())
+		max--
+	}
+	limit := retransmitLimit(membership.config.RetransmitMultiplier, len(membership.members)+1)
+	msg.Updates = append(msg.Updates, membership.broadcasts.pop(max, limit)...)
+	return msg
+}
+
+// syncMessage returns a new sync message which has the local member and all members.
+func (membership *Membership) syncMessage(msgType MessageType) *Message {
+	msg := NewMessage(msgType, membership.local.Address)
+	msg.Updates = append(msg.Updates, membership.local.Copy())
+	for _, member := range membership.members {
+		msg.Updates = append(msg.Updates, member.Copy())
+	}
+	return msg
+}
+
+// send sends the specified messages, and the membership must be unlocked.
+func (membership *Membership) send(outgoings []*outgoing) {
+	for _, o := range outgoings {
+		b, err := o.msg.Bytes()
+		if err != nil {
+			log.Errorf("%s", err.Error())
+			continue
+		}
+		if err := membership.transport.Send(b, o.to); err != nil {
+			log.Errorf("%s", err.Error())
+		}
+	}
+}
+
+// postChangedMembers posts the specified changed members to the listener.
+func (membership *Membership) postChangedMembers(listener MembershipListener, members []*Member) {
+	if listener == nil {
+		return
+	}
+	for _, member := range members {
+		listener.MemberChanged(member)
+	}
+}
+
+// postReclaimedMembers posts the specified reclaimed members to the listener.
+func (membership *Membership) postReclaimedMembers(listener MembershipListener, members []*Member) {
+	if listener == nil {
+		return
+	}
+	for _, member := range members {
+		listener.MemberReclaimed(member)
+	}
+}
+
+// mergeMember applies the specified update with the SWIM precedence, and returns true when the member is changed.
+// An update of a newer incarnation overrides the member, and an update of the same incarnation overrides it only when
+// the state is stronger in the order of alive, suspect, dead and left. The suspicion or death of the local member is refuted
+// by disseminating the local member with a newer incarnation.
+func (membership *Membership) mergeMember(update *Member) bool {
+	if update.Address == "" {
+		return false
+	}
+
+	if update.Address == membership.local.Address {
+		if (update.State == StateSuspect || update.State == StateDead) && membership.local.State == StateAlive {
+			// The stale suspicion or death is also refuted again because the refutation may not reach the sender.
+			if membership.local.Incarnation <= update.Incarnation {
+				membership.local.Incarnation = update.Incarnation + 1
+				log.Infof(msgMembershipRefuted, membership.local.Incarnation)
+			}
+			membership.broadcasts.push(membership.local)
+		}
+		return false
+	}
+
+	member, ok := membership.members[update.Address]
+	switch {
+	case !ok:
+		if !update.IsActive() {
+			return false
+		}
+	case update.Incarnation < member.Incarnation:
+		return false
+	case update.Incarnation == member.Incarnation:
+		if update.State <= member.State {
+			return false
+		}
+	}
+
+	merged := update.Copy()
+	if ok && !merged.IsActive() {
+		// The dead and left updates may not have the node, so the node is kept.
+		merged = member.Copy()
+		merged.Incarnation = update.Incarnation
+		merged.State = update.State
+	}
+	membership.members[merged.Address] = merged
+	if merged.State == StateSuspect {
+		if _, ok := membership.suspectedAt[merged.Address]; !ok {
+			membership.suspectedAt[merged.Address] = membership.now
+		}
+	} else {
+		delete(membership.suspectedAt, merged.Address)
+	}
+	if merged.IsActive() {
+		delete(membership.inactiveAt, merged.Address)
+	} else if _, ok := membership.inactiveAt[merged.Address]; !ok {
+		membership.inactiveAt[merged.Address] = membership.now
+	}
+	membership.broadcasts.push(merged)
+	return true
+}
+
+// messageReceived merges the piggybacked updates, and answers the specified message.
+func (membership *Membership) messageReceived(b []byte, from string) {
+	msg, err := NewMessageWithBytes(b)
+	if err != nil {
+		log.Warnf(msgMembershipInvalidMessage, from, err.Error())
+		return
+	}
+
+	membership.mutex.Lock()
+	if !membership.isRunning {
+		membership.mutex.Unlock()
+		return
+	}
+
+	changedMembers := []*Member{}
+	for _, update := range msg.Updates {
+		if membership.mergeMember(update) {
+			changedMembers = append(changedMembers, membership.members[update.Address].Copy())
+		}
+	}
+
+	outgoings := []*outgoing{}
+	syncedMembers := []*Member{}
+	switch msg.Type {
+	case MessagePing:
+		if msg.Target != "" && msg.Target != membership.local.Address {
+			break
+		}
+		ack := membership.newMessage(MessageAck, msg.From)
+		ack.SeqNo = msg.SeqNo
+		ack.Target = membership.local.Address
+		outgoings = append(outgoings, &outgoing{msg: ack, to: msg.From})
+	case MessagePingReq:
+		seqNo := membership.nextSeqNo()
+		membership.relays[seqNo] = &relay{origin: msg.From, seqNo: msg.SeqNo, start: membership.now}
+		ping := membership.newMessage(MessagePing, msg.Target)
+		ping.SeqNo = seqNo
+		ping.Target = msg.Target
+		outgoings = append(outgoings, &outgoing{msg: ping, to: msg.Target})
+	case MessageAck:
+		if p := membership.probe; p != nil && p.seqNo == msg.SeqNo {
+			membership.probe = nil
+			break
+		}
+		if r, ok := membership.relays[msg.SeqNo]; ok {
+			delete(membership.relays, msg.SeqNo)
+			ack := membership.newMessage(MessageAck, r.origin)
+			ack.SeqNo = r.seqNo
+			ack.Target = msg.Target
+			outgoings = append(outgoings, &outgoing{msg: ack, to: r.origin})
+		}
+	case MessageSync:
+		outgoings = append(outgoings, &outgoing{msg: membership.syncMessage(MessageSyncAck), to: msg.From})
+	case MessageSyncAck:
+		for _, update := range msg.Updates {
+			if member, ok := membership.members[update.Address]; ok && member.IsActive() {
+				syncedMembers = append(syncedMembers, member.Copy())
+			}
+		}
+	}
+
+	listener := membership.listener
+	membership.mutex.Unlock()
+
+	membership.send(outgoings)
+	membership.postChangedMembers(listener, changedMembers)
+	if listener != nil && 0 < len(syncedMembers) {
+		listener.MembersSynced(syncedMembers)
+	}
+}
@@ -0,0 +1,362 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gossip
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-finder/finder/node"
+)
+
+const (
+	testTickStep = 100 * time.Millisecond
+)
+
+type testMembershipListener struct {
+	mutex     sync.Mutex
+	changed   []*Member
+	synced    []*Member
+	reclaimed []*Member
+}
+
+func (l *testMembershipListener) MemberChanged(member *Member) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.changed = append(l.changed, member)
+}
+
+func (l *testMembershipListener) MembersSynced(members []*Member) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.synced = append(l.synced, members...)
+}
+
+func (l *testMembershipListener) MemberReclaimed(member *Member) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.reclaimed = append(l.reclaimed, member)
+}
+
+func (l *testMembershipListener) IsReclaimed(addr string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, member := range l.reclaimed {
+		if member.Address == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *testMembershipListener) States(addr string) []State {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	states := []State{}
+	for _, member := range l.changed {
+		if member.Address == addr {
+			states = append(states, member.State)
+		}
+	}
+	return states
+}
+
+type testCluster struct {
+	network     *MemoryNetwork
+	memberships []*Membership
+	listeners   []*testMembershipListener
+	now         time.Time
+}
+
+func newTestCluster(t *testing.T, n int) *testCluster {
+	t.Helper()
+	cluster := &testCluster{
+		network:     NewMemoryNetwork(),
+		memberships: []*Membership{},
+		listeners:   []*testMembershipListener{},
+		now:         time.Now(),
+	}
+	seed := ""
+	for i := 0; i < n; i++ {
+		localNode := node.NewBaseNode().SetCluster("cluster").SetHost(fmt.Sprintf("org.cybergarage.gossip%03d", i+1)).SetAddress(net.ParseIP(fmt.Sprintf("192.168.100.%d", i+1))).SetRPCPort(8001)
+		localNode.SetCondition(node.ConditionReady)
+		membership := NewMembership(localNode, cluster.network.NewTransport(), NewDefaultConfig())
+		if seed == "" {
+			seed = membership.Address()
+		}
+		membership.SetSeeds(seed)
+		listener := &testMembershipListener{}
+		membership.SetListener(listener)
+		if err := membership.Start(); err != nil {
+			t.Fatal(err)
+		}
+		cluster.memberships = append(cluster.memberships, membership)
+		cluster.listeners = append(cluster.listeners, listener)
+	}
+	return cluster
+}
+
+// tick drives all memberships until the specified duration is elapsed.
+func (cluster *testCluster) tick(d time.Duration) {
+	for end := cluster.now.Add(d); cluster.now.Before(end); {
+		cluster.now = cluster.now.Add(testTickStep)
+		for _, membership := range cluster.memberships {
+			membership.Tick(cluster.now)
+		}
+	}
+}
+
+func (cluster *testCluster) state(t *testing.T, i int, j int) State {
+	t.Helper()
+	member, ok := cluster.memberships[i].Member(cluster.memberships[j].Address())
+	if !ok {
+		t.Fatalf("%s is not found in %s", cluster.memberships[j].Address(), cluster.memberships[i].Address())
+	}
+	return member.State
+}
+
+func (cluster *testCluster) isolate(i int, reachable bool) {
+	for j := range cluster.memberships {
+		if i != j {
+			cluster.network.SetReachable(cluster.memberships[i].Address(), cluster.memberships[j].Address(), reachable)
+		}
+	}
+}
+
+func TestMembershipJoin(t *testing.T) {
+	cluster := newTestCluster(t, 4)
+
+	// The members which joined to the seed are disseminated to the others
+
+	cluster.tick(5 * time.Second)
+
+	for i, membership := range cluster.memberships {
+		members := membership.Members()
+		if len(members) != len(cluster.memberships)-1 {
+			t.Errorf("%s : %v", membership.Address(), members)
+			continue
+		}
+		for j := range cluster.memberships {
+			if i != j && cluster.state(t, i, j) != StateAlive {
+				t.Errorf("%s : %s", membership.Address(), cluster.state(t, i, j))
+			}
+		}
+	}
+
+	if len(cluster.listeners[1].synced) == 0 {
+		t.Errorf("no members are synced")
+	}
+}
+
+func TestMembershipFailureDetection(t *testing.T) {
+	cluster := newTestCluster(t, 3)
+	cluster.tick(5 * time.Second)
+
+	// The unreachable member is suspected, and confirmed as dead after the suspicion timeout
+
+	cluster.isolate(2, false)
+	cluster.tick(4 * time.Second)
+	for i := 0; i < 2; i++ {
+		if state := cluster.state(t, i, 2); state != StateSuspect {
+			t.Errorf("%s != %s", state, StateSuspect)
+		}
+	}
+
+	cluster.tick(DefaultSuspicionTimeout + 2*time.Second)
+	for i := 0; i < 2; i++ {
+		if state := cluster.state(t, i, 2); state != StateDead {
+			t.Errorf("%s != %s", state, StateDead)
+		}
+		states := cluster.listeners[i].States(cluster.memberships[2].Address())
+		if len(states) < 2 || states[len(states)-2] != StateSuspect || states[len(states)-1] != StateDead {
+			t.Errorf("%v", states)
+		}
+	}
+
+	member, _ := cluster.memberships[0].Member(cluster.memberships[2].Address())
+	if cond := member.Node().Condition(); cond != node.ConditionOutOfDate {
+		t.Errorf("%s != %s", cond, node.ConditionOutOfDate)
+	}
+
+	// The dead member refutes the death with a newer incarnation when it is reachable again
+
+	cluster.isolate(2, true)
+	cluster.tick(10 * time.Second)
+	for i := 0; i < 2; i++ {
+		if state := cluster.state(t, i, 2); state != StateAlive {
+			t.Errorf("%s != %s", state, StateAlive)
+		}
+	}
+	if member, _ := cluster.memberships[0].Member(cluster.memberships[2].Address()); member.Incarnation == 0 {
+		t.Errorf("%s is not refuted", member)
+	}
+}
+
+func TestMembershipIndirectPing(t *testing.T) {
+	cluster := newTestCluster(t, 3)
+	cluster.tick(5 * time.Second)
+
+	// The member which is reachable only indirectly is not suspected
+
+	cluster.network.SetReachable(cluster.memberships[0].Address(), cluster.memberships[2].Address(), false)
+	cluster.tick(10 * time.Second)
+
+	for i, j := range []int{2, 0} {
+		if state := cluster.state(t, i*2, j); state != StateAlive {
+			t.Errorf("%s != %s", state, StateAlive)
+		}
+	}
+	if states := cluster.listeners[0].States(cluster.memberships[2].Address()); 1 < len(states) {
+		t.Errorf("%v", states)
+	}
+}
+
+func TestMembershipLeave(t *testing.T) {
+	cluster := newTestCluster(t, 3)
+	cluster.tick(5 * time.Second)
+
+	if err := cluster.memberships[2].Stop(); err != nil {
+		t.Error(err)
+		return
+	}
+	for i := 0; i < 2; i++ {
+		if state := cluster.state(t, i, 2); state != StateLeft {
+			t.Errorf("%s != %s", state, StateLeft)
+		}
+		states := cluster.listeners[i].States(cluster.memberships[2].Address())
+		if len(states) == 0 || states[len(states)-1] != StateLeft {
+			t.Errorf("%v", states)
+		}
+	}
+
+	// The left member is not probed nor suspected
+
+	leftAddr := cluster.memberships[2].Address()
+	cluster.memberships = cluster.memberships[:2]
+	cluster.tick(DefaultSuspicionTimeout + 2*time.Second)
+	for i := 0; i < 2; i++ {
+		if member, ok := cluster.memberships[i].Member(leftAddr); !ok || member.State != StateLeft {
+			t.Errorf("%s != %s", member, StateLeft)
+		}
+	}
+}
+
+func TestMembershipReclaim(t *testing.T) {
+	cluster := newTestCluster(t, 3)
+	cluster.tick(5 * time.Second)
+
+	// The dead member is removed from the members after the reclaim timeout
+
+	deadAddr := cluster.memberships[2].Address()
+	cluster.isolate(2, false)
+	cluster.tick(DefaultSuspicionTimeout + 6*time.Second)
+	for i := 0; i < 2; i++ {
+		if state := cluster.state(t, i, 2); state != StateDead {
+			t.Errorf("%s != %s", state, StateDead)
+		}
+	}
+
+	cluster.tick(DefaultReclaimTimeout)
+	for i := 0; i < 2; i++ {
+		if member, ok := cluster.memberships[i].Member(deadAddr); ok {
+			t.Errorf("%s is not reclaimed", member)
+		}
+		if members := cluster.memberships[i].Members(); len(members) != 1 {
+			t.Errorf("%v", members)
+		}
+		if !cluster.listeners[i].IsReclaimed(deadAddr) {
+			t.Errorf("%s is not posted", deadAddr)
+		}
+	}
+
+	// The reclaimed member joins again when it is reachable again
+
+	cluster.isolate(2, true)
+	cluster.tick(10 * time.Second)
+	for i := 0; i < 2; i++ {
+		if state := cluster.state(t, i, 2); state != StateAlive {
+			t.Errorf("%s != %s", state, StateAlive)
+		}
+	}
+
+	// The left member is also removed from the members after the reclaim timeout
+
+	if err := cluster.memberships[2].Stop(); err != nil {
+		t.Error(err)
+		return
+	}
+	cluster.memberships = cluster.memberships[:2]
+	cluster.tick(DefaultReclaimTimeout + time.Second)
+	for i := 0; i < 2; i++ {
+		if member, ok := cluster.memberships[i].Member(deadAddr); ok {
+			t.Errorf("%s is not reclaimed", member)
+		}
+	}
+}
+
+func TestMembershipLocalNodeUpdate(t *testing.T) {
+	network := NewMemoryNetwork()
+	localNode := node.NewBaseNode().SetCluster("cluster").SetHost("org.cybergarage.gossip001").SetAddress(net.ParseIP("192.168.100.1")).SetRPCPort(8001)
+	localNode.SetCondition(node.ConditionBootstrap)
+	membership := NewMembership(localNode, network.NewTransport(), NewDefaultConfig())
+	if err := membership.Start(); err != nil {
+		t.Error(err)
+		return
+	}
+	defer membership.Stop()
+
+	localNode.SetCondition(node.ConditionReady)
+	membership.Tick(time.Now())
+
+	local := membership.LocalMember()
+	if local.Incarnation != 1 || local.Condition != node.ConditionReady {
+		t.Errorf("%s : %s", local, local.Condition)
+	}
+
+	// The advanced clock is carried without the next incarnation
+
+	localNode.UpdateClock()
+	membership.Tick(time.Now())
+
+	local = membership.LocalMember()
+	if local.Incarnation != 1 || local.Clock != localNode.Clock() {
+		t.Errorf("%s : %d", local, local.Clock)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	invalidConfigs := []func(*Config){
+		func(config *Config) { config.ProtocolPeriod = 0 },
+		func(config *Config) { config.PingTimeout = config.ProtocolPeriod },
+		func(config *Config) { config.IndirectChecks = -1 },
+		func(config *Config) { config.SuspicionTimeout = 0 },
+		func(config *Config) { config.ReclaimTimeout = 0 },
+		func(config *Config) { config.RetransmitMultiplier = 0 },
+		func(config *Config) { config.PiggybackMax = 0 },
+	}
+	if err := NewDefaultConfig().Validate(); err != nil {
+		t.Error(err)
+	}
+	for n, invalid := range invalidConfigs {
+		config := NewDefaultConfig()
+		invalid(config)
+		if err := config.Validate(); err == nil {
+			t.Errorf("config (%d) is valid", n)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gossip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// MessageType represents a type of the gossip messages.
+type MessageType uint8
+
+const (
+	// MessagePing is a direct probe which is answered with an ack.
+	MessagePing MessageType = 1
+	// MessagePingReq is a request to probe the target member indirectly.
+	MessagePingReq MessageType = 2
+	// MessageAck is an answer of a ping.
+	MessageAck MessageType = 3
+	// MessageSync pushes all members to a member, and is answered with a sync ack.
+	MessageSync MessageType = 4
+	// MessageSyncAck is an answer of a sync which has all members of the answering member.
+	MessageSyncAck MessageType = 5
+)
+
+const (
+	errorMessageInvalidType = "invalid message type (%d)"
+	errorMessageNoSender    = "message has no sender"
+)
+
+// Message represents a gossip message, and the membership updates are piggybacked on all messages.
+type Message struct {
+	Type MessageType `json:"type"`
+	// SeqNo is the sequence number which the ack is matched with the ping by.
+	SeqNo uint32 `json:"seq,omitempty"`
+	// From is the gossip address of the sender.
+	From string `json:"from"`
+	// Target is the gossip address of the probed member.
+	Target  string    `json:"target,omitempty"`
+	Updates []*Member `json:"updates,omitempty"`
+}
+
+// NewMessage returns a new message of the specified type.
+func NewMessage(msgType MessageType, from string) *Message {
+	return &Message{
+		Type:    msgType,
+		SeqNo:   0,
+		From:    from,
+		Target:  "",
+		Updates: []*Member{},
+	}
+}
+
+// NewMessageWithBytes returns a new message of the specified bytes.
+func NewMessageWithBytes(b []byte) (*Message, error) {
+	msg := &Message{}
+	if err := json.Unmarshal(b, msg); err != nil {
+		return nil, err
+	}
+	if msg.Type < MessagePing || MessageSyncAck < msg.Type {
+		return nil, fmt.Errorf(errorMessageInvalidType, msg.Type)
+	}
+	if msg.From == "" {
+		return nil, errors.New(errorMessageNoSender)
+	}
+	return msg, nil
+}
+
+// Bytes returns the encoded bytes of the message.
+func (msg *Message) Bytes() ([]byte, error) {
+	return json.Marshal(msg)
+}
@@ -0,0 +1,90 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gossip
+
+import (
+	"net"
+	"testing"
+
+	"github.com/cybergarage/go-finder/finder/node"
+)
+
+func TestMessage(t *testing.T) {
+	srcNode := node.NewBaseNode().SetCluster("cluster").SetHost("org.cybergarage.gossip001").SetAddress(net.ParseIP("192.168.100.1")).SetRPCPort(8001)
+	srcNode.SetPort(node.PortCarbon, 2003).SetLabel("zone", "a")
+	srcNode.SetCondition(node.ConditionReady)
+
+	msg := NewMessage(MessagePingReq, "memory:1")
+	msg.SeqNo = 10
+	msg.Target = "memory:2"
+	msg.Updates = append(msg.Updates, NewMemberWithNode("memory:3", srcNode))
+
+	b, err := msg.Bytes()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	parsedMsg, err := NewMessageWithBytes(b)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if parsedMsg.Type != msg.Type || parsedMsg.SeqNo != msg.SeqNo || parsedMsg.From != msg.From || parsedMsg.Target != msg.Target {
+		t.Errorf("%v != %v", parsedMsg, msg)
+	}
+	if len(parsedMsg.Updates) != 1 || !parsedMsg.Updates[0].NodeEqual(msg.Updates[0]) {
+		t.Errorf("%v != %v", parsedMsg.Updates, msg.Updates)
+		return
+	}
+
+	parsedNode := parsedMsg.Updates[0].Node()
+	if !node.Equal(parsedNode, srcNode) || parsedNode.Condition() != node.ConditionReady {
+		t.Errorf("%v != %v", parsedNode, srcNode)
+	}
+	if port, ok := parsedNode.Port(node.PortCarbon); !ok || port != 2003 {
+		t.Errorf("%d != %d", port, 2003)
+	}
+
+	invalidMsgs := []string{
+		"",
+		`{"type":0,"from":"memory:1"}`,
+		`{"type":1}`,
+	}
+	for _, invalidMsg := range invalidMsgs {
+		if _, err := NewMessageWithBytes([]byte(invalidMsg)); err == nil {
+			t.Errorf("%q is parsed", invalidMsg)
+		}
+	}
+}
+
+func TestMemberNodeCondition(t *testing.T) {
+	member := NewMemberWithNode("memory:1", node.NewBaseNode().SetHost("org.cybergarage.gossip001").SetAddress(net.ParseIP("192.168.100.1")))
+	member.Condition = node.ConditionReady
+	conds := map[State]node.Condition{
+		StateAlive:   node.ConditionReady,
+		StateSuspect: node.ConditionReady,
+		StateDead:    node.ConditionOutOfDate,
+		StateLeft:    node.ConditionStop,
+	}
+	for state, cond := range conds {
+		member.State = state
+		if member.Node().Condition() != cond {
+			t.Errorf("%s : %s != %s", state, member.Node().Condition(), cond)
+		}
+		if label, ok := member.Node().Label(LabelState); !ok || label != state.String() {
+			t.Errorf("%s : %s != %s", state, label, state)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gossip
+
+import (
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/cybergarage/go-logger/log"
+)
+
+const (
+	errorTransportNotRunning = "transport is not running"
+)
+
+// TransportHandler is called when a message is received from the specified gossip address.
+type TransportHandler func(b []byte, from string)
+
+// Transport represents an abstract unicast transport of the gossip messages.
+type Transport interface {
+	// Address returns the gossip address which the other members send the messages to.
+	Address() string
+	// SetHandler sets the handler of the received messages.
+	SetHandler(handler TransportHandler)
+	// Send sends the specified message to the specified gossip address.
+	Send(b []byte, to string) error
+	// Start starts the transport.
+	Start() error
+	// Stop stops the transport.
+	Stop() error
+	// IsRunning returns true when the transport is running, otherwise false.
+	IsRunning() bool
+}
+
+// UDPTransport represents a transport of the UDP unicast.
+type UDPTransport struct {
+	mutex   sync.Mutex
+	addr    string
+	conn    *net.UDPConn
+	handler TransportHandler
+	done    chan struct{}
+}
+
+// NewUDPTransport returns a new UDP transport which listens on the specified address such as "192.168.100.1:7946".
+func NewUDPTransport(addr string) *UDPTransport {
+	return &UDPTransport{
+		addr:    addr,
+		conn:    nil,
+		handler: nil,
+		done:    nil,
+	}
+}
+
+// Address returns the listening address, and the port is resolved after the transport is started when the port is zero.
+func (transport *UDPTransport) Address() string {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	return transport.addr
+}
+
+// SetHandler sets the handler of the received messages.
+func (transport *UDPTransport) SetHandler(handler TransportHandler) {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	transport.handler = handler
+}
+
+// Start starts receiving messages.
+func (transport *UDPTransport) Start() error {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	if transport.conn != nil {
+		return nil
+	}
+	udpAddr, err := net.ResolveUDPAddr("udp", transport.addr)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return err
+	}
+	if localAddr, ok := conn.LocalAddr().(*net.UDPAddr); ok && udpAddr.Port == 0 {
+		transport.addr = (&net.UDPAddr{IP: udpAddr.IP, Port: localAddr.Port}).String()
+	}
+	transport.conn = conn
+	transport.done = make(chan struct{})
+	go transport.serve(conn, transport.done)
+	return nil
+}
+
+// serve receives messages until the connection is closed.
+func (transport *UDPTransport) serve(conn *net.UDPConn, done chan struct{}) {
+	defer close(done)
+	buf := make([]byte, MessageMaxSize)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Errorf("%s", err.Error())
+			}
+			return
+		}
+		transport.mutex.Lock()
+		handler := transport.handler
+		transport.mutex.Unlock()
+		if handler == nil {
+			continue
+		}
+		handler(append([]byte{}, buf[:n]...), from.String())
+	}
+}
+
+// Send sends the specified message to the specified address.
+func (transport *UDPTransport) Send(b []byte, to string) error {
+	transport.mutex.Lock()
+	conn := transport.conn
+	transport.mutex.Unlock()
+	if conn == nil {
+		return errors.New(errorTransportNotRunning)
+	}
+	toAddr, err := net.ResolveUDPAddr("udp", to)
+	if err != nil {
+		return err
+	}
+	_, err = conn.WriteToUDP(b, toAddr)
+	return err
+}
+
+// Stop stops receiving messages and waits until the receiver is stopped.
+func (transport *UDPTransport) Stop() error {
+	transport.mutex.Lock()
+	conn := transport.conn
+	done := transport.done
+	transport.conn = nil
+	transport.done = nil
+	transport.mutex.Unlock()
+	if conn == nil {
+		return nil
+	}
+	err := conn.Close()
+	<-done
+	return err
+}
+
+// IsRunning returns true when the transport is running, otherwise false.
+func (transport *UDPTransport) IsRunning() bool {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	return transport.conn != nil
+}
+
+// String returns the description.
+func (transport *UDPTransport) String() string {
+	return transport.Address()
+}
@@ -0,0 +1,151 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gossip
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	memoryTransportAddress = "memory:%d"
+)
+
+// MemoryNetwork represents an in-process network which delivers the messages synchronously,
+// so the protocol is deterministic when it is driven by the ticks.
+type MemoryNetwork struct {
+	mutex       sync.Mutex
+	transports  map[string]*MemoryTransport
+	unreachable map[[2]string]bool
+}
+
+// MemoryTransport represents a transport of the in-process network.
+type MemoryTransport struct {
+	network   *MemoryNetwork
+	addr      string
+	mutex     sync.Mutex
+	handler   TransportHandler
+	isRunning bool
+}
+
+// NewMemoryNetwork returns a new in-process network.
+func NewMemoryNetwork() *MemoryNetwork {
+	return &MemoryNetwork{
+		transports:  map[string]*MemoryTransport{},
+		unreachable: map[[2]string]bool{},
+	}
+}
+
+// NewTransport returns a new transport on the network which has a unique address.
+func (network *MemoryNetwork) NewTransport() *MemoryTransport {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+	transport := &MemoryTransport{
+		network:   network,
+		addr:      fmt.Sprintf(memoryTransportAddress, len(network.transports)+1),
+		handler:   nil,
+		isRunning: false,
+	}
+	network.transports[transport.addr] = transport
+	return transport
+}
+
+// SetReachable sets whether the messages between the specified addresses are delivered in both directions, and all addresses are reachable by default.
+func (network *MemoryNetwork) SetReachable(addr string, other string, reachable bool) {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+	for _, link := range [][2]string{{addr, other}, {other, addr}} {
+		if reachable {
+			delete(network.unreachable, link)
+		} else {
+			network.unreachable[link] = true
+		}
+	}
+}
+
+// transport returns the transport of the specified address, or nil when the address is not reachable from the sender.
+func (network *MemoryNetwork) transport(from string, to string) *MemoryTransport {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+	if network.unreachable[[2]string{from, to}] {
+		return nil
+	}
+	return network.transports[to]
+}
+
+// Address returns the address on the network.
+func (transport *MemoryTransport) Address() string {
+	return transport.addr
+}
+
+// SetHandler sets the handler of the received messages.
+func (transport *MemoryTransport) SetHandler(handler TransportHandler) {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	transport.handler = handler
+}
+
+// Start starts delivering the received messages to the handler.
+func (transport *MemoryTransport) Start() error {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	transport.isRunning = true
+	return nil
+}
+
+// Send delivers the specified message to the handler of the specified address before returning,
+// and the message is dropped silently like UDP when the address is not running or not reachable.
+func (transport *MemoryTransport) Send(b []byte, to string) error {
+	if !transport.IsRunning() {
+		return errors.New(errorTransportNotRunning)
+	}
+
+	peer := transport.network.transport(transport.addr, to)
+	if peer == nil {
+		return nil
+	}
+
+	peer.mutex.Lock()
+	handler := peer.handler
+	isRunning := peer.isRunning
+	peer.mutex.Unlock()
+	if !isRunning || handler == nil {
+		return nil
+	}
+
+	handler(append([]byte{}, b...), transport.addr)
+	return nil
+}
+
+// Stop stops delivering the messages.
+func (transport *MemoryTransport) Stop() error {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	transport.isRunning = false
+	return nil
+}
+
+// IsRunning returns true when the transport is running, otherwise false.
+func (transport *MemoryTransport) IsRunning() bool {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	return transport.isRunning
+}
+
+// String returns the description.
+func (transport *MemoryTransport) String() string {
+	return transport.addr
+}
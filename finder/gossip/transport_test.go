@@ -0,0 +1,105 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gossip
+
+import (
+	"testing"
+	"time"
+)
+
+func waitTestTransportMessage(ch chan string) string {
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(5 * time.Second):
+		return ""
+	}
+}
+
+func TestMemoryTransport(t *testing.T) {
+	network := NewMemoryNetwork()
+
+	transports := []*MemoryTransport{network.NewTransport(), network.NewTransport()}
+	received := []string{}
+	for _, transport := range transports {
+		transport.SetHandler(func(b []byte, from string) {
+			received = append(received, from+" "+string(b))
+		})
+		if err := transport.Send([]byte("x"), transports[0].Address()); err == nil {
+			t.Errorf("%s : sent before started", transport)
+		}
+		if err := transport.Start(); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	// Messages are delivered before Send returns
+
+	if err := transports[0].Send([]byte("hello"), transports[1].Address()); err != nil {
+		t.Error(err)
+	}
+	if len(received) != 1 || received[0] != transports[0].Address()+" hello" {
+		t.Errorf("%v", received)
+	}
+
+	// Messages between the unreachable addresses are dropped
+
+	network.SetReachable(transports[0].Address(), transports[1].Address(), false)
+	if err := transports[1].Send([]byte("hello"), transports[0].Address()); err != nil {
+		t.Error(err)
+	}
+	if len(received) != 1 {
+		t.Errorf("%v", received)
+	}
+
+	network.SetReachable(transports[0].Address(), transports[1].Address(), true)
+	if err := transports[1].Send([]byte("hello"), transports[0].Address()); err != nil {
+		t.Error(err)
+	}
+	if len(received) != 2 {
+		t.Errorf("%v", received)
+	}
+
+	for _, transport := range transports {
+		if err := transport.Stop(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestUDPTransport(t *testing.T) {
+	transports := []*UDPTransport{NewUDPTransport("127.0.0.1:0"), NewUDPTransport("127.0.0.1:0")}
+	chs := make([]chan string, len(transports))
+	for n, transport := range transports {
+		ch := make(chan string, 1)
+		chs[n] = ch
+		transport.SetHandler(func(b []byte, from string) {
+			ch <- string(b)
+		})
+		if err := transport.Start(); err != nil {
+			t.Error(err)
+			return
+		}
+		defer transport.Stop()
+	}
+
+	if err := transports[0].Send([]byte("hello"), transports[1].Address()); err != nil {
+		t.Error(err)
+	}
+	if msg := waitTestTransportMessage(chs[1]); msg != "hello" {
+		t.Errorf("%s != %s", msg, "hello")
+	}
+}
@@ -0,0 +1,345 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// See : https://graphite.readthedocs.io/en/latest/render_api.html#paths-and-wildcards
+
+const (
+	graphitePathSeparator = '.'
+	graphiteAnyString     = `[^.]*`
+	graphiteAnyCharacter  = `[^.]`
+	// graphiteValueListExpansionMax is the max number of the patterns which the value lists are expanded to.
+	graphiteValueListExpansionMax = 1024
+)
+
+const (
+	errorGraphiteUnclosedCharacterList = "Unclosed character list at %d in %q"
+	errorGraphiteUnclosedValueList     = "Unclosed value list at %d in %q"
+	errorGraphiteTrailingEscape        = "Trailing escape in %q"
+)
+
+// graphitePattern represents a compiled Graphite path pattern.
+type graphitePattern struct {
+	expr       string
+	components []string
+	regexps    []*regexp.Regexp
+	pathRegexp *regexp.Regexp
+	// alternatives is the patterns which the value lists are expanded to when a value has the path separator.
+	alternatives []*graphitePattern
+}
+
+// graphiteParser represents a parser of Graphite path patterns.
+type graphiteParser struct {
+	expr           []rune
+	pos            int
+	hasDottedValue bool
+}
+
+// compileGraphitePattern compiles the specified Graphite path pattern.
+// Wildcards (*), single characters (?), character lists ([a-z], [!a-z]) and value lists ({a,b,c}) are supported,
+// and the wildcards never match the path separator (.). A backslash escapes the following character.
+func compileGraphitePattern(expr string) (*graphitePattern, error) {
+	parser := &graphiteParser{expr: []rune(expr), pos: 0}
+
+	pattern := &graphitePattern{
+		expr:       expr,
+		components: []string{},
+		regexps:    []*regexp.Regexp{},
+	}
+
+	sources := []string{}
+	for {
+		start := parser.pos
+		source, err := parser.parseSequence(false)
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile("^" + source + "$")
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+		pattern.components = append(pattern.components, string(parser.expr[start:parser.pos]))
+		pattern.regexps = append(pattern.regexps, re)
+		if parser.isEnd() {
+			break
+		}
+		parser.pos++ // Skip the path separator
+	}
+
+	var err error
+	pattern.pathRegexp, err = regexp.Compile("^" + strings.Join(sources, regexp.QuoteMeta(string(graphitePathSeparator))) + "$")
+	if err != nil {
+		return nil, err
+	}
+
+	// Node names have the path separators, so the value lists such as {host1.domain,host2.domain} are expanded
+	// to match the node names with the leading components of each expanded pattern.
+
+	if parser.hasDottedValue {
+		for _, altExpr := range expandGraphiteValueLists(expr) {
+			alt, err := compileGraphitePattern(altExpr)
+			if err != nil {
+				return nil, err
+			}
+			pattern.alternatives = append(pattern.alternatives, alt)
+		}
+	}
+
+	return pattern, nil
+}
+
+// expandGraphiteValueLists returns the patterns which all value lists of the specified pattern are expanded to,
+// or nil when the number of the patterns exceeds graphiteValueListExpansionMax.
+func expandGraphiteValueLists(expr string) []string {
+	start, end, values := findGraphiteValueList(expr)
+	if start < 0 {
+		return []string{expr}
+	}
+	exprs := []string{}
+	for _, value := range values {
+		valueExprs := expandGraphiteValueLists(expr[:start] + value + expr[end:])
+		if valueExprs == nil {
+			return nil
+		}
+		exprs = append(exprs, valueExprs...)
+		if graphiteValueListExpansionMax < len(exprs) {
+			return nil
+		}
+	}
+	return exprs
+}
+
+// findGraphiteValueList returns the start and end positions and the values of the first value list in the specified valid pattern,
+// or -1 as the start position when the pattern has no value lists.
+func findGraphiteValueList(expr string) (int, int, []string) {
+	start := -1
+	depth := 0
+	valueStart := 0
+	values := []string{}
+	for n := 0; n < len(expr); n++ {
+		switch expr[n] {
+		case '\\':
+			n++
+		case '[':
+			n++
+			if n < len(expr) && expr[n] == '!' {
+				n++
+			}
+			if n < len(expr) && expr[n] == '\\' {
+				n++
+			}
+			// A closing bracket just after the opening bracket is a member of the list
+			for n++; n < len(expr) && expr[n] != ']'; n++ {
+				if expr[n] == '\\' {
+					n++
+				}
+			}
+		case '{':
+			if depth == 0 {
+				start = n
+				valueStart = n + 1
+			}
+			depth++
+		case ',':
+			if depth == 1 {
+				values = append(values, expr[valueStart:n])
+				valueStart = n + 1
+			}
+		case '}':
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth == 0 {
+				values = append(values, expr[valueStart:n])
+				return start, n + 1, values
+			}
+		}
+	}
+	return -1, -1, nil
+}
+
+// isEnd returns true when the parser reaches the end of the expression, otherwise false.
+func (parser *graphiteParser) isEnd() bool {
+	return len(parser.expr) <= parser.pos
+}
+
+// parseSequence parses the expression until the path separator, or the value separator when the sequence is in a value list.
+func (parser *graphiteParser) parseSequence(isValue bool) (string, error) {
+	var source strings.Builder
+	for !parser.isEnd() {
+		c := parser.expr[parser.pos]
+		switch {
+		case c == graphitePathSeparator && !isValue:
+			return source.String(), nil
+		case (c == ',' || c == '}') && isValue:
+			return source.String(), nil
+		case c == graphitePathSeparator && isValue:
+			parser.hasDottedValue = true
+			source.WriteString(regexp.QuoteMeta(string(c)))
+		case c == '*':
+			source.WriteString(graphiteAnyString)
+		case c == '?':
+			source.WriteString(graphiteAnyCharacter)
+		case c == '[':
+			class, err := parser.parseCharacterList()
+			if err != nil {
+				return "", err
+			}
+			source.WriteString(class)
+			continue
+		case c == '{':
+			values, err := parser.parseValueList()
+			if err != nil {
+				return "", err
+			}
+			source.WriteString(values)
+			continue
+		case c == '\\':
+			parser.pos++
+			if parser.isEnd() {
+				return "", fmt.Errorf(errorGraphiteTrailingEscape, string(parser.expr))
+			}
+			source.WriteString(regexp.QuoteMeta(string(parser.expr[parser.pos])))
+		default:
+			source.WriteString(regexp.QuoteMeta(string(c)))
+		}
+		parser.pos++
+	}
+	return source.String(), nil
+}
+
+// parseCharacterList parses a character list such as [a-z] or [!0-9] which matches a single character except the path separator.
+// A closing bracket just after the opening bracket is a member of the list.
+func (parser *graphiteParser) parseCharacterList() (string, error) {
+	start := parser.pos
+	parser.pos++ // Skip [
+
+	var class strings.Builder
+	class.WriteString("[")
+	if !parser.isEnd() && parser.expr[parser.pos] == '!' {
+		class.WriteString("^.")
+		parser.pos++
+	}
+
+	isFirst := true
+	for !parser.isEnd() {
+		c := parser.expr[parser.pos]
+		parser.pos++
+		if c == ']' && !isFirst {
+			class.WriteString("]")
+			return class.String(), nil
+		}
+		isFirst = false
+		if c == '\\' && !parser.isEnd() {
+			c = parser.expr[parser.pos]
+			parser.pos++
+		}
+		switch c {
+		case '\\', '[', ']', '^':
+			class.WriteString(`\` + string(c))
+		default:
+			class.WriteRune(c)
+		}
+	}
+
+	return "", fmt.Errorf(errorGraphiteUnclosedCharacterList, start, string(parser.expr))
+}
+
+// parseValueList parses a value list such as {a,b,c} whose values may have wildcards and nested value lists.
+func (parser *graphiteParser) parseValueList() (string, error) {
+	start := parser.pos
+	parser.pos++ // Skip {
+
+	values := []string{}
+	for !parser.isEnd() {
+		value, err := parser.parseSequence(true)
+		if err != nil {
+			return "", err
+		}
+		values = append(values, value)
+		if parser.isEnd() {
+			break
+		}
+		c := parser.expr[parser.pos]
+		parser.pos++
+		if c == '}' {
+			return "(?:" + strings.Join(values, "|") + ")", nil
+		}
+	}
+
+	return "", fmt.Errorf(errorGraphiteUnclosedValueList, start, string(parser.expr))
+}
+
+// MatchString reports whether the pattern matches the whole specified path.
+func (pattern *graphitePattern) MatchString(path string) bool {
+	return pattern.pathRegexp.MatchString(path)
+}
+
+// matchPrefix reports whether the leading components of the pattern match all components of the specified path,
+// and returns the number of the matched components.
+func (pattern *graphitePattern) matchPrefix(path string) (int, bool) {
+	components := strings.Split(path, string(graphitePathSeparator))
+	if len(pattern.regexps) < len(components) {
+		return 0, false
+	}
+	for n, component := range components {
+		if !pattern.regexps[n].MatchString(component) {
+			return 0, false
+		}
+	}
+	return len(components), true
+}
+
+// expandPrefix replaces the leading components of the pattern matching the specified path with the path.
+// When only the expanded value lists match the path, the remaining components are merged into a value list.
+func (pattern *graphitePattern) expandPrefix(path string) (string, bool) {
+	n, ok := pattern.matchPrefix(path)
+	if ok {
+		components := append([]string{path}, pattern.components[n:]...)
+		return strings.Join(components, string(graphitePathSeparator)), true
+	}
+
+	suffixes := []string{}
+	for _, alt := range pattern.alternatives {
+		n, ok := alt.matchPrefix(path)
+		if !ok {
+			continue
+		}
+		suffix := ""
+		if n < len(alt.components) {
+			suffix = string(graphitePathSeparator) + strings.Join(alt.components[n:], string(graphitePathSeparator))
+		}
+		if !slices.Contains(suffixes, suffix) {
+			suffixes = append(suffixes, suffix)
+		}
+	}
+
+	switch len(suffixes) {
+	case 0:
+		return "", false
+	case 1:
+		return path + suffixes[0], true
+	}
+	return path + "{" + strings.Join(suffixes, ",") + "}", true
+}
@@ -0,0 +1,208 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGraphitePatternMatch(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		// Wildcards
+		{"*", "a", true},
+		{"*", "", true},
+		{"*", "a.b", false},
+		{"a.*", "a.b", true},
+		{"a.*", "a", false},
+		{"a.*", "a.b.c", false},
+		{"*.b", "a.b", true},
+		{"*.b", "x.y.b", false},
+		{"a*", "a", true},
+		{"a*", "abc", true},
+		{"a*", "ba", false},
+		{"*a*", "xay", true},
+		{"*a*", "xy", false},
+		{"a.*.c", "a.b.c", true},
+		{"a.*.c", "a.b.d.c", false},
+		{"*.*", "a.b", true},
+		{"*.*", "a", false},
+		// Single characters
+		{"?", "a", true},
+		{"?", "", false},
+		{"?", "ab", false},
+		{"?", ".", false},
+		{"a?c", "abc", true},
+		{"a?c", "a.c", false},
+		{"a??", "abc", true},
+		{"a??", "ab", false},
+		// Character lists
+		{"[abc]", "a", true},
+		{"[abc]", "d", false},
+		{"[abc]", "ab", false},
+		{"[a-c]x", "bx", true},
+		{"[a-c]x", "dx", false},
+		{"[0-9][0-9]", "42", true},
+		{"[0-9][0-9]", "4a", false},
+		{"[a-cx-z]", "y", true},
+		{"[a-cx-z]", "m", false},
+		{"[!a-c]", "d", true},
+		{"[!a-c]", "a", false},
+		{"[!a-c]", ".", false},
+		{"[]a]", "]", true},
+		{"[]a]", "a", true},
+		{"[a-]", "-", true},
+		{"[^a]", "^", true},
+		{"[^a]", "b", false},
+		{"[\\]]", "]", true},
+		// Value lists
+		{"{a,b}", "a", true},
+		{"{a,b}", "b", true},
+		{"{a,b}", "c", false},
+		{"{a,b}", "ab", false},
+		{"{a,b}.c", "b.c", true},
+		{"{a,b}.c", "b.d", false},
+		{"x{a,b*}", "xbzz", true},
+		{"x{a,b*}", "xb.z", false},
+		{"{a,{b,c}d}", "cd", true},
+		{"{a,{b,c}d}", "c", false},
+		{"{,a}x", "x", true},
+		{"{,a}x", "ax", true},
+		{"{a.b,c}.d", "a.b.d", true},
+		{"{a.b,c}.d", "c.d", true},
+		{"{web,db}[0-9]?", "db12", true},
+		{"{web,db}[0-9]?", "web1", false},
+		// Combinations
+		{"servers.{web,db}[0-9]?.cpu.*", "servers.web01.cpu.user", true},
+		{"servers.{web,db}[0-9]?.cpu.*", "servers.db12.cpu.idle", true},
+		{"servers.{web,db}[0-9]?.cpu.*", "servers.web1.cpu.user", false},
+		{"servers.{web,db}[0-9]?.cpu.*", "servers.app01.cpu.user", false},
+		{"servers.*.{cpu,mem}.*", "servers.web01.mem.free", true},
+		{"servers.*.{cpu,mem}.*", "servers.web01.disk.free", false},
+		// Literals
+		{"a.b", "a.b", true},
+		{"a.b", "axb", false},
+		{"a+b", "a+b", true},
+		{"a+b", "aab", false},
+		{"a(b)", "a(b)", true},
+		{"a|b", "a|b", true},
+		{"a|b", "a", false},
+		{"a,b", "a,b", true},
+		{"a}b", "a}b", true},
+		{"a$", "a$", true},
+		{"a\\*", "a*", true},
+		{"a\\*", "ab", false},
+		{"a\\{b\\}", "a{b}", true},
+	}
+
+	for _, testCase := range testCases {
+		pattern, err := compileGraphitePattern(testCase.pattern)
+		if err != nil {
+			t.Errorf("%s : %s", testCase.pattern, err)
+			continue
+		}
+		if pattern.MatchString(testCase.path) != testCase.match {
+			t.Errorf(testFinderMatchingError, testCase.pattern, testCase.path)
+		}
+	}
+}
+
+func TestGraphitePatternErrors(t *testing.T) {
+	patterns := []string{
+		"[abc",
+		"[",
+		"[]",
+		"[!",
+		"{a,b",
+		"{",
+		"{a,{b,c}",
+		"{a,[b}",
+		"a\\",
+		"[z-a]",
+	}
+
+	for _, pattern := range patterns {
+		if _, err := compileGraphitePattern(pattern); err == nil {
+			t.Errorf("%s : compiled", pattern)
+		}
+	}
+}
+
+func TestGraphitePatternExpand(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		path    string
+		expand  string
+	}{
+		{"*", "node01", "node01"},
+		{"*.cpu.*", "node01", "node01.cpu.*"},
+		{"node0[1-2].{cpu,mem}", "node02", "node02.{cpu,mem}"},
+		{"{node01,node02}.cpu", "node02", "node02.cpu"},
+		{"{node01,node02}.cpu", "node03", ""},
+		{"org.cybergarage.*.cpu", "org.cybergarage.finder001", "org.cybergarage.finder001.cpu"},
+		{"org.*.finder00?.cpu", "org.cybergarage.finder001", "org.cybergarage.finder001.cpu"},
+		{"*.cpu", "org.cybergarage.finder001", ""},
+		{"*.*.*", "192.168.100.1", ""},
+		{"*.*.*.*.cpu", "192.168.100.1", "192.168.100.1.cpu"},
+		{"{org.cybergarage.finder001,org.cybergarage.finder002}.cpu", "org.cybergarage.finder002", "org.cybergarage.finder002.cpu"},
+		{"{org.cybergarage.finder001,org.cybergarage.finder002}.cpu", "org.cybergarage.finder003", ""},
+		{"org.{cybergarage.finder00[1-3],example.node}.*", "org.cybergarage.finder002", "org.cybergarage.finder002.*"},
+		{"{node01.cpu,node01.mem}.*", "node01", "node01{.cpu.*,.mem.*}"},
+		{"{node01,node01.cpu}", "node01", "node01"},
+		{"{[!a]b.c,x}.d", "zb.c", "zb.c.d"},
+	}
+
+	for _, testCase := range testCases {
+		pattern, err := compileGraphitePattern(testCase.pattern)
+		if err != nil {
+			t.Errorf("%s : %s", testCase.pattern, err)
+			continue
+		}
+		expand, ok := pattern.expandPrefix(testCase.path)
+		if ok != (testCase.expand != "") || expand != testCase.expand {
+			t.Errorf("%s (%s) : %s != %s", testCase.pattern, testCase.path, expand, testCase.expand)
+		}
+	}
+}
+
+func TestGraphiteValueListExpansion(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		exprs   []string
+	}{
+		{"a", []string{"a"}},
+		{"{a,b}", []string{"a", "b"}},
+		{"{a,b}.{c,d}", []string{"a.c", "a.d", "b.c", "b.d"}},
+		{"{a,{b,c}d}", []string{"a", "bd", "cd"}},
+		{"x{,y}", []string{"x", "xy"}},
+		{"[{]{a,b}", []string{"[{]a", "[{]b"}},
+		{"\\{a,b\\}", []string{"\\{a,b\\}"}},
+	}
+
+	for _, testCase := range testCases {
+		exprs := expandGraphiteValueLists(testCase.pattern)
+		if strings.Join(exprs, " ") != strings.Join(testCase.exprs, " ") {
+			t.Errorf("%s : %v != %v", testCase.pattern, exprs, testCase.exprs)
+		}
+	}
+
+	if exprs := expandGraphiteValueLists(strings.Repeat("{a,b}", 11)); exprs != nil {
+		t.Errorf(testFinderNodeCountError, len(exprs), 0)
+	}
+}
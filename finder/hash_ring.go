@@ -0,0 +1,141 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+const (
+	hashRingVirtualNodeCount = 64
+)
+
+// hashRingPoint represents a virtual node on the hash ring.
+type hashRingPoint struct {
+	hash    uint64
+	nodeKey string
+}
+
+// hashRing represents a consistent hash ring keyed on the node identity keys.
+type hashRing struct {
+	points []hashRingPoint
+	nodes  map[string]Node
+}
+
+// newHashRing returns a new empty hash ring.
+func newHashRing() *hashRing {
+	ring := &hashRing{
+		points: make([]hashRingPoint, 0),
+		nodes:  map[string]Node{},
+	}
+	return ring
+}
+
+// hashRingNodeKey returns the identity key of the specified node which distinguishes the same nodes as node.Equal.
+func hashRingNodeKey(n Node) string {
+	addr := ""
+	if ip := n.Address(); ip != nil {
+		addr = ip.String()
+	}
+	return fmt.Sprintf("%q%q%q%d", n.Cluster(), n.Host(), addr, n.RPCPort())
+}
+
+// hashRingKey returns the hash value of the specified key.
+func hashRingKey(key string) uint64 {
+	h := sha256.Sum256([]byte(key))
+	return binary.BigEndian.Uint64(h[:8])
+}
+
+// hashRingVirtualKey returns the key of the specified virtual node.
+func hashRingVirtualKey(nodeKey string, n int) string {
+	return fmt.Sprintf("%s#%d", nodeKey, n)
+}
+
+// search returns the index of the first point whose hash is greater than or equal to the specified hash.
+func (ring *hashRing) search(hash uint64) int {
+	return sort.Search(len(ring.points), func(n int) bool {
+		return hash <= ring.points[n].hash
+	})
+}
+
+// Add adds the specified node with the identity key into the ring.
+func (ring *hashRing) Add(nodeKey string, node Node) {
+	if _, ok := ring.nodes[nodeKey]; ok {
+		ring.nodes[nodeKey] = node
+		return
+	}
+	ring.nodes[nodeKey] = node
+
+	for n := 0; n < hashRingVirtualNodeCount; n++ {
+		point := hashRingPoint{
+			hash:    hashRingKey(hashRingVirtualKey(nodeKey, n)),
+			nodeKey: nodeKey,
+		}
+		idx := ring.search(point.hash)
+		ring.points = append(ring.points, hashRingPoint{})
+		copy(ring.points[idx+1:], ring.points[idx:])
+		ring.points[idx] = point
+	}
+}
+
+// Remove removes the specified node identity key from the ring.
+func (ring *hashRing) Remove(nodeKey string) {
+	if _, ok := ring.nodes[nodeKey]; !ok {
+		return
+	}
+	delete(ring.nodes, nodeKey)
+
+	points := ring.points[:0]
+	for _, point := range ring.points {
+		if point.nodeKey == nodeKey {
+			continue
+		}
+		points = append(points, point)
+	}
+	ring.points = points
+}
+
+// Len returns the number of nodes in the ring.
+func (ring *hashRing) Len() int {
+	return len(ring.nodes)
+}
+
+// Successors returns up to the specified number of distinct nodes following the specified identity key clockwise on the ring.
+// The node of the specified identity key itself is never included.
+func (ring *hashRing) Successors(nodeKey string, count int) []Node {
+	nodes := make([]Node, 0)
+	if len(ring.points) == 0 || count <= 0 {
+		return nodes
+	}
+
+	seen := map[string]bool{nodeKey: true}
+	start := ring.search(hashRingKey(nodeKey))
+	for n := 0; n < len(ring.points); n++ {
+		point := ring.points[(start+n)%len(ring.points)]
+		if seen[point.nodeKey] {
+			continue
+		}
+		seen[point.nodeKey] = true
+		nodes = append(nodes, ring.nodes[point.nodeKey])
+		if count <= len(nodes) {
+			break
+		}
+	}
+
+	return nodes
+}
@@ -0,0 +1,192 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/cybergarage/go-finder/finder/node"
+)
+
+const (
+	testHashRingNodeCount = 16
+)
+
+func setupTestHashRingNodes(count int) []Node {
+	nodes := make([]Node, count)
+	for n := 0; n < count; n++ {
+		node := node.NewBaseNode()
+		node.SetHost(fmt.Sprintf("org.cybergarage.ring%03d", n))
+		node.SetAddress(net.IPv4(192, 168, 100, byte(n)))
+		nodes[n] = node
+	}
+	return nodes
+}
+
+func getTestNeighborhoodNodes(t *testing.T, finder Finder, nodes []Node) map[string]string {
+	t.Helper()
+	neighbors := map[string]string{}
+	for _, node := range nodes {
+		neighbor, err := finder.GetNeighborhoodNode(node)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		neighbors[node.UUID()] = neighbor.UUID()
+	}
+	return neighbors
+}
+
+func TestGetNeighborhoodNode(t *testing.T) {
+	nodes := setupTestHashRingNodes(testHashRingNodeCount)
+	finder := NewStaticFinderWithNodes(nodes)
+
+	// Deterministic and never the node itself
+
+	neighbors := getTestNeighborhoodNodes(t, finder, nodes)
+	for _, node := range nodes {
+		if neighbors[node.UUID()] == node.UUID() {
+			t.Errorf("%s is the neighborhood node of itself", node.Host())
+		}
+	}
+
+	otherFinder := NewStaticFinderWithNodes(nodes)
+	otherNeighbors := getTestNeighborhoodNodes(t, otherFinder, nodes)
+	for uuid, neighbor := range neighbors {
+		if otherNeighbors[uuid] != neighbor {
+			t.Errorf(testFinderMatchingError, neighbor, otherNeighbors[uuid])
+		}
+	}
+
+	// Adding a node only changes the neighborhood nodes to the added node
+
+	addedNode := setupTestHashRingNodes(testHashRingNodeCount + 1)[testHashRingNodeCount]
+	err := finder.(testNodeMutator).addNode(addedNode)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	addedNeighbors := getTestNeighborhoodNodes(t, finder, nodes)
+	for uuid, neighbor := range neighbors {
+		if addedNeighbors[uuid] != neighbor && addedNeighbors[uuid] != addedNode.UUID() {
+			t.Errorf(testFinderMatchingError, neighbor, addedNeighbors[uuid])
+		}
+	}
+
+	// Removing the node restores the previous neighborhood nodes
+
+	err = finder.RemoveNode(addedNode)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	removedNeighbors := getTestNeighborhoodNodes(t, finder, nodes)
+	for uuid, neighbor := range neighbors {
+		if removedNeighbors[uuid] != neighbor {
+			t.Errorf(testFinderMatchingError, neighbor, removedNeighbors[uuid])
+		}
+	}
+}
+
+func TestGetNeighborhoodNodes(t *testing.T) {
+	nodes := setupTestHashRingNodes(testHashRingNodeCount)
+	finder := NewStaticFinderWithNodes(nodes)
+
+	for _, node := range nodes {
+		replicas, err := finder.GetNeighborhoodNodes(node, 3)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(replicas) != 3 {
+			t.Errorf(testFinderNodeCountError, len(replicas), 3)
+			return
+		}
+
+		neighbor, err := finder.GetNeighborhoodNode(node)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if replicas[0].UUID() != neighbor.UUID() {
+			t.Errorf(testFinderMatchingError, neighbor.Host(), replicas[0].Host())
+		}
+
+		uuids := map[string]bool{node.UUID(): true}
+		for _, replica := range replicas {
+			if uuids[replica.UUID()] {
+				t.Errorf("%s is duplicated", replica.Host())
+			}
+			uuids[replica.UUID()] = true
+		}
+	}
+
+	// All other nodes at most
+
+	replicas, err := finder.GetNeighborhoodNodes(nodes[0], testHashRingNodeCount*2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(replicas) != (testHashRingNodeCount - 1) {
+		t.Errorf(testFinderNodeCountError, len(replicas), testHashRingNodeCount-1)
+	}
+
+	// Invalid count and no nodes
+
+	if _, err := finder.GetNeighborhoodNodes(nodes[0], 0); err == nil {
+		t.Errorf("Invalid node count is accepted")
+	}
+
+	emptyFinder := NewStaticFinderWithNodes(nil)
+	if _, err := emptyFinder.GetNeighborhoodNode(nodes[0]); err == nil {
+		t.Errorf("Empty finder returns a neighborhood node")
+	}
+}
+
+// setupTestAddressOnlyNodes returns the specified number of nodes which have no host name on the same RPC port.
+func setupTestAddressOnlyNodes(count int) []Node {
+	nodes := make([]Node, count)
+	for n := 0; n < count; n++ {
+		nodes[n] = node.NewBaseNode().SetAddress(net.IPv4(192, 0, 2, byte(n+1))).SetRPCPort(8001)
+	}
+	return nodes
+}
+
+func TestGetNeighborhoodNodeAddressOnly(t *testing.T) {
+	nodes := setupTestAddressOnlyNodes(2)
+	if nodes[0].UUID() != nodes[1].UUID() {
+		t.Skipf("%s and %s are resolved to the different hosts", nodes[0].Address(), nodes[1].Address())
+	}
+
+	// The nodes which have the same UUID but the different addresses never shadow each other
+
+	finder := NewStaticFinderWithNodes(nodes)
+	for n, node := range nodes {
+		neighbor, err := finder.GetNeighborhoodNode(node)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		other := nodes[(n+1)%len(nodes)]
+		if !neighbor.Address().Equal(other.Address()) {
+			t.Errorf(testFinderMatchingError, other.Address(), neighbor.Address())
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"time"
+)
+
+// KVLease represents a lease of the keys which are deleted when the lease expires, and zero is no lease.
+type KVLease uint64
+
+// KVEventType represents a type of the key-value store events.
+type KVEventType int
+
+const (
+	// KVEventPut is posted when a value is put.
+	KVEventPut KVEventType = iota
+	// KVEventDelete is posted when a key is deleted or the lease of the key expires.
+	KVEventDelete
+)
+
+// KVPair represents a key and the value.
+type KVPair struct {
+	Key   string
+	Value []byte
+}
+
+// KVEvent represents a change of a key, and the value is empty when the key is deleted.
+type KVEvent struct {
+	Type  KVEventType
+	Key   string
+	Value []byte
+}
+
+// KVStore represents an abstract key-value store such as etcd or Consul which the KV finder keeps the membership in.
+type KVStore interface {
+	// Put puts the value with a new lease of the specified TTL, and the key is deleted unless the lease is kept alive.
+	// The key is put without a lease when the TTL is zero.
+	Put(ctx context.Context, key string, value []byte, ttl time.Duration) (KVLease, error)
+	// KeepAlive renews the specified lease, and returns an error when the lease has expired.
+	KeepAlive(ctx context.Context, lease KVLease) error
+	// Delete deletes the specified key.
+	Delete(ctx context.Context, key string) error
+	// Get returns the pairs of the keys which have the specified prefix in the key order.
+	Get(ctx context.Context, prefix string) ([]*KVPair, error)
+	// Watch returns a channel of the events of the keys which have the specified prefix after the watch is started.
+	// The channel is closed when the context is done, or when the store cancels the watch and the watcher must get the keys again.
+	Watch(ctx context.Context, prefix string) (<-chan *KVEvent, error)
+}
@@ -0,0 +1,280 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// memoryKVStoreWatchQueueSize is the queue size of the watchers, and the slow watchers are canceled when the queue is full.
+	memoryKVStoreWatchQueueSize = 256
+)
+
+const (
+	errorKVStoreLeaseNotFound = "Lease (%d) is not found"
+	errorKVStoreKeyNotFound   = "Key %q is not found"
+)
+
+// memoryKVEntry represents a value in the memory store.
+type memoryKVEntry struct {
+	value []byte
+	lease KVLease
+}
+
+// memoryKVLease represents a lease in the memory store.
+type memoryKVLease struct {
+	ttl      time.Duration
+	deadline time.Time
+	timer    *time.Timer
+	keys     map[string]struct{}
+}
+
+// memoryKVWatcher represents a watcher in the memory store.
+type memoryKVWatcher struct {
+	prefix string
+	ch     chan *KVEvent
+}
+
+// MemoryKVStore represents an in-process key-value store for tests and embedding.
+type MemoryKVStore struct {
+	mutex     sync.Mutex
+	entries   map[string]*memoryKVEntry
+	leases    map[KVLease]*memoryKVLease
+	lastLease KVLease
+	watchers  []*memoryKVWatcher
+}
+
+// NewMemoryKVStore returns a new empty in-process store.
+func NewMemoryKVStore() *MemoryKVStore {
+	return &MemoryKVStore{
+		entries:   map[string]*memoryKVEntry{},
+		leases:    map[KVLease]*memoryKVLease{},
+		lastLease: 0,
+		watchers:  []*memoryKVWatcher{},
+	}
+}
+
+// Put puts the value with a new lease of the specified TTL.
+func (store *MemoryKVStore) Put(ctx context.Context, key string, value []byte, ttl time.Duration) (KVLease, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.detachKey(key)
+
+	var id KVLease
+	if 0 < ttl {
+		store.lastLease++
+		id = store.lastLease
+		lease := &memoryKVLease{ttl: ttl, deadline: time.Now().Add(ttl), keys: map[string]struct{}{key: {}}}
+		lease.timer = time.AfterFunc(ttl, func() { store.expireLease(id, time.Now()) })
+		store.leases[id] = lease
+	}
+	store.entries[key] = &memoryKVEntry{value: append([]byte{}, value...), lease: id}
+	store.notify(&KVEvent{Type: KVEventPut, Key: key, Value: value})
+
+	return id, nil
+}
+
+// KeepAlive renews the specified lease.
+func (store *MemoryKVStore) KeepAlive(ctx context.Context, id KVLease) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return store.keepAlive(id, time.Now())
+}
+
+// keepAlive renews the specified lease at the specified time, and expires the lease whose deadline has passed.
+func (store *MemoryKVStore) keepAlive(id KVLease, now time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	lease, ok := store.leases[id]
+	if !ok {
+		return fmt.Errorf(errorKVStoreLeaseNotFound, id)
+	}
+	if lease.deadline.Before(now) {
+		store.revokeLease(id, lease)
+		return fmt.Errorf(errorKVStoreLeaseNotFound, id)
+	}
+	lease.deadline = now.Add(lease.ttl)
+	lease.timer.Reset(lease.ttl)
+	return nil
+}
+
+// Delete deletes the specified key.
+func (store *MemoryKVStore) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if _, ok := store.entries[key]; !ok {
+		return fmt.Errorf(errorKVStoreKeyNotFound, key)
+	}
+	store.detachKey(key)
+	delete(store.entries, key)
+	store.notify(&KVEvent{Type: KVEventDelete, Key: key, Value: nil})
+	return nil
+}
+
+// Get returns the pairs of the keys which have the specified prefix in the key order.
+func (store *MemoryKVStore) Get(ctx context.Context, prefix string) ([]*KVPair, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	pairs := []*KVPair{}
+	for key, entry := range store.entries {
+		if strings.HasPrefix(key, prefix) {
+			pairs = append(pairs, &KVPair{Key: key, Value: append([]byte{}, entry.value...)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Key < pairs[j].Key
+	})
+	return pairs, nil
+}
+
+// Watch returns a channel of the events of the keys which have the specified prefix.
+func (store *MemoryKVStore) Watch(ctx context.Context, prefix string) (<-chan *KVEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	watcher := &memoryKVWatcher{prefix: prefix, ch: make(chan *KVEvent, memoryKVStoreWatchQueueSize)}
+	store.mutex.Lock()
+	store.watchers = append(store.watchers, watcher)
+	store.mutex.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		store.mutex.Lock()
+		defer store.mutex.Unlock()
+		store.cancelWatcher(watcher)
+	}()
+
+	return watcher.ch, nil
+}
+
+// Close expires no more leases, and cancels all watchers.
+func (store *MemoryKVStore) Close() error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	for _, lease := range store.leases {
+		lease.timer.Stop()
+	}
+	for len(store.watchers) != 0 {
+		store.cancelWatcher(store.watchers[0])
+	}
+	return nil
+}
+
+// detachKey detaches the specified key from the lease, and revokes the lease which has no keys.
+// The caller must hold the mutex.
+func (store *MemoryKVStore) detachKey(key string) {
+	entry, ok := store.entries[key]
+	if !ok || entry.lease == 0 {
+		return
+	}
+	lease, ok := store.leases[entry.lease]
+	if !ok {
+		return
+	}
+	delete(lease.keys, key)
+	if len(lease.keys) == 0 {
+		lease.timer.Stop()
+		delete(store.leases, entry.lease)
+	}
+}
+
+// expireLease deletes the keys of the specified lease when the deadline has passed at the specified time.
+// The timer of the lease which is renewed while the timer fires is reset to the deadline.
+func (store *MemoryKVStore) expireLease(id KVLease, now time.Time) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	lease, ok := store.leases[id]
+	if !ok {
+		return
+	}
+	if now.Before(lease.deadline) {
+		lease.timer.Reset(lease.deadline.Sub(now))
+		return
+	}
+	store.revokeLease(id, lease)
+}
+
+// revokeLease deletes the specified lease and the keys.
+// The caller must hold the mutex.
+func (store *MemoryKVStore) revokeLease(id KVLease, lease *memoryKVLease) {
+	lease.timer.Stop()
+	delete(store.leases, id)
+	keys := make([]string, 0, len(lease.keys))
+	for key := range lease.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		delete(store.entries, key)
+		store.notify(&KVEvent{Type: KVEventDelete, Key: key, Value: nil})
+	}
+}
+
+// notify posts the specified event to the watchers of the key, and cancels the watchers whose queue is full.
+// The caller must hold the mutex.
+func (store *MemoryKVStore) notify(e *KVEvent) {
+	canceledWatchers := []*memoryKVWatcher{}
+	for _, watcher := range store.watchers {
+		if !strings.HasPrefix(e.Key, watcher.prefix) {
+			continue
+		}
+		select {
+		case watcher.ch <- &KVEvent{Type: e.Type, Key: e.Key, Value: append([]byte{}, e.Value...)}:
+		default:
+			canceledWatchers = append(canceledWatchers, watcher)
+		}
+	}
+	for _, watcher := range canceledWatchers {
+		store.cancelWatcher(watcher)
+	}
+}
+
+// cancelWatcher removes the specified watcher and closes the channel, and the caller must hold the mutex.
+func (store *MemoryKVStore) cancelWatcher(watcher *memoryKVWatcher) {
+	for n, w := range store.watchers {
+		if w != watcher {
+			continue
+		}
+		store.watchers = append(store.watchers[:n:n], store.watchers[n+1:]...)
+		close(watcher.ch)
+		return
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryKVStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryKVStore()
+	defer store.Close()
+
+	events, err := store.Watch(ctx, "/cluster/")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	keys := []string{"/cluster/b", "/cluster/a", "/other/c"}
+	for _, key := range keys {
+		if _, err := store.Put(ctx, key, []byte(key), 0); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// Only the keys of the prefix are returned in the key order
+
+	pairs, err := store.Get(ctx, "/cluster/")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(pairs) != 2 {
+		t.Errorf(testFinderNodeCountError, len(pairs), 2)
+		return
+	}
+	if pairs[0].Key != "/cluster/a" || string(pairs[0].Value) != "/cluster/a" {
+		t.Errorf(testFinderMatchingError, "/cluster/a", pairs[0].Key)
+	}
+
+	// Only the changes of the prefix are watched
+
+	for _, key := range keys[:2] {
+		e := <-events
+		if e.Type != KVEventPut || e.Key != key {
+			t.Errorf(testFinderMatchingError, key, e.Key)
+		}
+	}
+
+	if err := store.Delete(ctx, "/cluster/a"); err != nil {
+		t.Error(err)
+	}
+	if e := <-events; e.Type != KVEventDelete || e.Key != "/cluster/a" {
+		t.Errorf(testFinderMatchingError, "/cluster/a", e.Key)
+	}
+	if err := store.Delete(ctx, "/cluster/a"); err == nil {
+		t.Errorf("%s is deleted", "/cluster/a")
+	}
+}
+
+func TestMemoryKVStoreLease(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryKVStore()
+	defer store.Close()
+
+	// The lease is renewed at the specified times, and the timer never fires during the test
+
+	ttl := time.Hour
+	now := time.Now()
+	lease, err := store.Put(ctx, "/cluster/a", []byte("a"), ttl)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// The key is alive while the lease is kept alive
+
+	for range 4 {
+		now = now.Add(ttl / 2)
+		if err := store.keepAlive(lease, now); err != nil {
+			t.Error(err)
+			return
+		}
+		store.expireLease(lease, now)
+		pairs, err := store.Get(ctx, "/cluster/a")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(pairs) != 1 {
+			t.Errorf(testFinderNodeCountError, len(pairs), 1)
+		}
+	}
+
+	// The key is deleted when the lease expires
+
+	events, err := store.Watch(ctx, "/cluster/")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	store.expireLease(lease, now.Add(ttl*2))
+	select {
+	case e := <-events:
+		if e.Type != KVEventDelete || e.Key != "/cluster/a" {
+			t.Errorf(testFinderMatchingError, "/cluster/a", e.Key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("%s is not expired", "/cluster/a")
+	}
+	if err := store.KeepAlive(ctx, lease); err == nil {
+		t.Errorf("Lease (%d) is not expired", lease)
+	}
+
+	// The lease whose deadline has passed is not renewed
+
+	lease, err = store.Put(ctx, "/cluster/b", []byte("b"), ttl)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err := store.keepAlive(lease, time.Now().Add(ttl*2)); err == nil {
+		t.Errorf("Lease (%d) is not expired", lease)
+	}
+	pairs, err := store.Get(ctx, "/cluster/b")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(pairs) != 0 {
+		t.Errorf(testFinderNodeCountError, len(pairs), 0)
+	}
+
+	// The key is deleted by the timer
+
+	if _, err := store.Put(ctx, "/cluster/c", []byte("c"), 10*time.Millisecond); err != nil {
+		t.Error(err)
+		return
+	}
+	isExpired := false
+	for !isExpired {
+		select {
+		case e := <-events:
+			isExpired = e.Type == KVEventDelete && e.Key == "/cluster/c"
+		case <-time.After(5 * time.Second):
+			t.Errorf("%s is not expired", "/cluster/c")
+			return
+		}
+	}
+
+	// The watch is closed when the context is done
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	events, err = store.Watch(watchCtx, "/")
+	cancel()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for range events {
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mdns
+
+const (
+	// ServiceType is the DNS-SD service type of the finder nodes.
+	ServiceType = "_finder._tcp"
+	// Domain is the domain of the multicast DNS.
+	Domain = "local."
+	// ServiceName is the DNS-SD service name which the finder nodes are browsed with.
+	ServiceName = ServiceType + "." + Domain
+)
+
+const (
+	// MulticastAddress is the IPv4 multicast address of the multicast DNS.
+	MulticastAddress = "224.0.0.251"
+	// Port is the port of the multicast DNS.
+	Port = 5353
+	// DefaultTTL is the default time-to-live of the advertised records in seconds.
+	DefaultTTL = 120
+	// MessageMaxSize is the max size of the multicast DNS messages.
+	MessageMaxSize = 9000
+)
@@ -0,0 +1,287 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mdns
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cybergarage/go-finder/finder/dns"
+	"github.com/cybergarage/go-finder/finder/node"
+	"github.com/cybergarage/go-logger/log"
+)
+
+const (
+	TXTVersion     = "txtvers"
+	TXTCluster     = "cluster"
+	TXTHost        = "host"
+	TXTCondition   = "condition"
+	TXTClock       = "clock"
+	TXTPortPrefix  = "port."
+	TXTLabelPrefix = "label."
+	TXTSeparator   = "="
+)
+
+const (
+	txtVersion        = "1"
+	instanceUUIDSize  = 32
+	hostNameSeparator = "-"
+)
+
+const (
+	errorServiceNoSRVRecord    = "no SRV record of %s"
+	errorServiceInvalidText    = "invalid TXT %q of %s"
+	errorServiceInvalidRPCPort = "invalid RPC port %d of %s"
+	msgServiceTextTooLong      = "TXT %q of %s is dropped because it is longer than %d bytes"
+)
+
+// instanceLabel returns the service instance label of the specified node.
+func instanceLabel(srcNode node.Node) string {
+	label := srcNode.Host()
+	if label == "" {
+		if addr := srcNode.Address(); addr != nil {
+			label = addr.String()
+		}
+	}
+	if label == "" || dns.LabelMaxSize < len(label) {
+		label = srcNode.UUID()[:instanceUUIDSize]
+	}
+	return label
+}
+
+// InstanceName returns the DNS-SD service instance name of the specified node such as "org.cybergarage.finder001._finder._tcp.local.".
+func InstanceName(srcNode node.Node) string {
+	return dns.EscapeLabel(instanceLabel(srcNode)) + "." + ServiceName
+}
+
+// HostName returns the host name of the SRV record target of the specified node such as "org-cybergarage-finder001.local.".
+func HostName(srcNode node.Node) string {
+	label := strings.NewReplacer(".", hostNameSeparator, ":", hostNameSeparator).Replace(instanceLabel(srcNode))
+	return label + "." + Domain
+}
+
+// NewServiceQuestion returns a new question which browses the finder nodes.
+func NewServiceQuestion() *dns.Question {
+	return &dns.Question{Name: ServiceName, Type: dns.TypePTR, Class: dns.ClassINET}
+}
+
+// NewServiceQueryMessage returns a new query message which browses the finder nodes.
+func NewServiceQueryMessage() *dns.Message {
+	return dns.NewQueryMessage(NewServiceQuestion())
+}
+
+// NewTextsWithNode returns the TXT strings of the specified node which have the cluster, named ports, labels and status.
+// The strings longer than the TXT string limit are dropped.
+func NewTextsWithNode(srcNode node.Node) []string {
+	texts := []string{
+		TXTVersion + TXTSeparator + txtVersion,
+		TXTCluster + TXTSeparator + srcNode.Cluster(),
+		TXTHost + TXTSeparator + srcNode.Host(),
+		TXTCondition + TXTSeparator + strconv.FormatUint(uint64(srcNode.Condition()), 10),
+		TXTClock + TXTSeparator + strconv.FormatUint(uint64(srcNode.Clock()), 10),
+	}
+	ports := srcNode.Ports()
+	for _, name := range ports.Names() {
+		texts = append(texts, TXTPortPrefix+name+TXTSeparator+strconv.FormatUint(uint64(ports[name]), 10))
+	}
+	labels := srcNode.Labels()
+	for _, key := range labels.Keys() {
+		texts = append(texts, TXTLabelPrefix+key+TXTSeparator+labels[key])
+	}
+
+	fittedTexts := make([]string, 0, len(texts))
+	for _, text := range texts {
+		if dns.TextMaxSize < len(text) {
+			log.Warnf(msgServiceTextTooLong, text, srcNode.Host(), dns.TextMaxSize)
+			continue
+		}
+		fittedTexts = append(fittedTexts, text)
+	}
+	return fittedTexts
+}
+
+// NewServiceRecordsWithNode returns the PTR, SRV and TXT records and the address records of the specified node.
+func NewServiceRecordsWithNode(srcNode node.Node, ttl uint32) ([]*dns.Record, []*dns.Record) {
+	instance := InstanceName(srcNode)
+	host := HostName(srcNode)
+	answers := []*dns.Record{
+		dns.NewPTRRecord(ServiceName, instance, ttl),
+		dns.NewSRVRecord(instance, host, uint16(srcNode.RPCPort()), ttl),
+		dns.NewTXTRecord(instance, NewTextsWithNode(srcNode), ttl),
+	}
+	additionals := []*dns.Record{}
+	if addr := srcNode.Address(); addr != nil {
+		additionals = append(additionals, dns.NewAddressRecord(host, addr, ttl))
+	}
+	return answers, additionals
+}
+
+// NewAnnouncementMessageWithNode returns a new response message which advertises the specified node.
+func NewAnnouncementMessageWithNode(srcNode node.Node, ttl uint32) *dns.Message {
+	answers, additionals := NewServiceRecordsWithNode(srcNode, ttl)
+	return dns.NewResponseMessage(answers...).AddAdditionals(additionals...)
+}
+
+// NewGoodbyeMessageWithNode returns a new response message which announces the specified node is stopping with zero TTL.
+func NewGoodbyeMessageWithNode(srcNode node.Node) *dns.Message {
+	return NewAnnouncementMessageWithNode(srcNode, 0)
+}
+
+// IsServiceQuery returns true when the message is a query which browses the finder nodes or asks the instance of the specified node, otherwise false.
+func IsServiceQuery(msg *dns.Message, srcNode node.Node) bool {
+	if !msg.IsQuery() {
+		return false
+	}
+	instance := InstanceName(srcNode)
+	for _, question := range msg.Questions {
+		switch {
+		case dns.NameEqual(question.Name, ServiceName) && (question.Type == dns.TypePTR || question.Type == dns.TypeANY):
+			return true
+		case dns.NameEqual(question.Name, instance) && (question.Type == dns.TypeSRV || question.Type == dns.TypeTXT || question.Type == dns.TypeANY):
+			return true
+		}
+	}
+	return false
+}
+
+// NewFinderNodesWithMessage returns the finder nodes advertised by the specified response message.
+// The nodes advertised with zero TTL have the stop condition, and the invalid nodes are skipped with the returned error.
+func NewFinderNodesWithMessage(msg *dns.Message) ([]node.Node, error) {
+	if !msg.IsResponse() {
+		return []node.Node{}, nil
+	}
+
+	records := msg.Records()
+	nodes := []node.Node{}
+	errs := []error{}
+	for _, ptr := range records {
+		if ptr.Type != dns.TypePTR || !dns.NameEqual(ptr.Name, ServiceName) {
+			continue
+		}
+		finderNode, err := newFinderNodeWithRecords(ptr, records)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		nodes = append(nodes, finderNode)
+	}
+	return nodes, errors.Join(errs...)
+}
+
+// isGoodbyeRecord returns true when the specified record is a goodbye record which has zero TTL, otherwise false.
+func isGoodbyeRecord(record *dns.Record) bool {
+	return record.TTL == 0
+}
+
+// newFinderNodeWithRecords returns the finder node of the specified PTR record with the SRV, TXT and address records.
+func newFinderNodeWithRecords(ptr *dns.Record, records []*dns.Record) (node.Node, error) {
+	instance := ptr.Target
+
+	var srv *dns.Record
+	var txt *dns.Record
+	for _, record := range records {
+		if !dns.NameEqual(record.Name, instance) {
+			continue
+		}
+		switch record.Type {
+		case dns.TypeSRV:
+			srv = record
+		case dns.TypeTXT:
+			txt = record
+		}
+	}
+	if srv == nil {
+		return nil, fmt.Errorf(errorServiceNoSRVRecord, instance)
+	}
+
+	finderNode := node.NewBaseNode()
+	finderNode.SetRPCPort(uint(srv.Port))
+	finderNode.SetCondition(node.ConditionReady)
+	if labels := dns.NameLabels(instance); 0 < len(labels) {
+		finderNode.SetHost(labels[0])
+	}
+
+	for _, record := range records {
+		if (record.Type == dns.TypeA || record.Type == dns.TypeAAAA) && dns.NameEqual(record.Name, srv.Target) {
+			finderNode.SetAddress(record.IP)
+			break
+		}
+	}
+
+	if txt != nil {
+		if err := setNodeTexts(finderNode, instance, txt.Texts); err != nil {
+			return nil, err
+		}
+	}
+
+	if isGoodbyeRecord(ptr) {
+		finderNode.SetCondition(node.ConditionStop)
+	}
+
+	return finderNode, nil
+}
+
+// setNodeTexts sets the cluster, named ports, labels and status of the specified TXT strings to the node.
+func setNodeTexts(finderNode *node.BaseNode, instance string, texts []string) error {
+	ports := node.NewPorts()
+	labels := node.NewLabels()
+	for _, text := range texts {
+		key, value, ok := strings.Cut(text, TXTSeparator)
+		if !ok {
+			continue
+		}
+		switch {
+		case key == TXTCluster:
+			finderNode.SetCluster(value)
+		case key == TXTHost:
+			if value != "" {
+				finderNode.SetHost(value)
+			}
+		case key == TXTCondition:
+			cond, err := strconv.ParseUint(value, 10, 8)
+			if err != nil {
+				return fmt.Errorf(errorServiceInvalidText, text, instance)
+			}
+			finderNode.SetCondition(node.Condition(cond))
+		case key == TXTClock:
+			clock, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf(errorServiceInvalidText, text, instance)
+			}
+			finderNode.SetClock(node.Clock(clock))
+		case strings.HasPrefix(key, TXTPortPrefix):
+			name := strings.TrimPrefix(key, TXTPortPrefix)
+			port, err := strconv.ParseUint(value, 10, 16)
+			if err != nil {
+				return fmt.Errorf(errorServiceInvalidText, text, instance)
+			}
+			if err := node.ValidatePort(name, uint(port)); err != nil {
+				return err
+			}
+			ports[name] = uint(port)
+		case strings.HasPrefix(key, TXTLabelPrefix):
+			key = strings.TrimPrefix(key, TXTLabelPrefix)
+			if err := node.ValidateLabel(key, value); err != nil {
+				return err
+			}
+			labels[key] = value
+		}
+	}
+	finderNode.SetPorts(ports)
+	finderNode.SetLabels(labels)
+	return nil
+}
@@ -0,0 +1,131 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mdns
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/cybergarage/go-finder/finder/dns"
+	"github.com/cybergarage/go-finder/finder/node"
+)
+
+func TestServiceMessage(t *testing.T) {
+	srcNode := node.NewBaseNode().SetCluster("cluster").SetHost("org.cybergarage.finder001").SetAddress(net.ParseIP("192.168.100.1")).SetRPCPort(8001)
+	srcNode.SetPort(node.PortCarbon, 2003).SetLabel("zone", "a")
+	srcNode.SetCondition(node.ConditionReady)
+	srcNode.SetClock(10)
+
+	if InstanceName(srcNode) != "org\\.cybergarage\\.finder001."+ServiceName {
+		t.Errorf("%s", InstanceName(srcNode))
+	}
+	if HostName(srcNode) != "org-cybergarage-finder001.local." {
+		t.Errorf("%s", HostName(srcNode))
+	}
+
+	msgs := []struct {
+		msg  *dns.Message
+		cond node.Condition
+	}{
+		{NewAnnouncementMessageWithNode(srcNode, DefaultTTL), node.ConditionReady},
+		{NewGoodbyeMessageWithNode(srcNode), node.ConditionStop},
+	}
+	for _, m := range msgs {
+		b, err := m.msg.Bytes()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		msg, err := dns.NewMessageWithBytes(b)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		nodes, err := NewFinderNodesWithMessage(msg)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if len(nodes) != 1 {
+			t.Errorf("%d != %d", len(nodes), 1)
+			continue
+		}
+		foundNode := nodes[0]
+		if !node.Equal(srcNode, foundNode) {
+			t.Errorf("%s:%s:%d != %s:%s:%d", foundNode.Host(), foundNode.Address(), foundNode.RPCPort(), srcNode.Host(), srcNode.Address(), srcNode.RPCPort())
+		}
+		if foundNode.Condition() != m.cond {
+			t.Errorf("%s != %s", foundNode.Condition(), m.cond)
+		}
+		if foundNode.Clock() != srcNode.Clock() {
+			t.Errorf("%d != %d", foundNode.Clock(), srcNode.Clock())
+		}
+		if !node.PortsEqual(foundNode.Ports(), srcNode.Ports()) {
+			t.Errorf("%s != %s", foundNode.Ports(), srcNode.Ports())
+		}
+		if !node.LabelsEqual(foundNode.Labels(), srcNode.Labels()) {
+			t.Errorf("%s != %s", foundNode.Labels(), srcNode.Labels())
+		}
+	}
+}
+
+func TestServiceQuery(t *testing.T) {
+	srcNode := node.NewBaseNode().SetHost("org.cybergarage.finder001").SetAddress(net.ParseIP("192.168.100.1"))
+	otherNode := node.NewBaseNode().SetHost("org.cybergarage.finder002").SetAddress(net.ParseIP("192.168.100.2"))
+
+	queries := []struct {
+		msg      *dns.Message
+		expected bool
+	}{
+		{NewServiceQueryMessage(), true},
+		{dns.NewQueryMessage(&dns.Question{Name: InstanceName(srcNode), Type: dns.TypeSRV, Class: dns.ClassINET}), true},
+		{dns.NewQueryMessage(&dns.Question{Name: InstanceName(otherNode), Type: dns.TypeSRV, Class: dns.ClassINET}), false},
+		{dns.NewQueryMessage(&dns.Question{Name: "_http._tcp.local.", Type: dns.TypePTR, Class: dns.ClassINET}), false},
+		{NewAnnouncementMessageWithNode(srcNode, DefaultTTL), false},
+	}
+	for n, query := range queries {
+		if IsServiceQuery(query.msg, srcNode) != query.expected {
+			t.Errorf("[%d] %t != %t", n, !query.expected, query.expected)
+		}
+	}
+}
+
+func TestServiceInvalidTexts(t *testing.T) {
+	srcNode := node.NewBaseNode().SetHost("org.cybergarage.finder001").SetAddress(net.ParseIP("192.168.100.1"))
+
+	invalidTexts := [][]string{
+		{"condition=ready"},
+		{"port.carbon=65536"},
+		{"label.-zone=a"},
+	}
+	for _, texts := range invalidTexts {
+		msg := NewAnnouncementMessageWithNode(srcNode, DefaultTTL)
+		msg.Answers[2].Texts = texts
+		nodes, err := NewFinderNodesWithMessage(msg)
+		if err == nil || len(nodes) != 0 {
+			t.Errorf("%v is parsed", texts)
+		}
+	}
+
+	// The texts longer than the limit are dropped
+
+	srcNode.SetLabel("long", strings.Repeat("v", dns.TextMaxSize))
+	for _, text := range NewTextsWithNode(srcNode) {
+		if strings.HasPrefix(text, TXTLabelPrefix+"long") {
+			t.Errorf("%s is not dropped", text)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mdns
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/cybergarage/go-logger/log"
+)
+
+const (
+	errorTransportNotRunning     = "transport is not running"
+	errorTransportMessageTooLong = "message is too long (%d)"
+)
+
+// TransportHandler is called when a message is received.
+type TransportHandler func(b []byte, from net.Addr)
+
+// Transport represents an abstract multicast transport of the multicast DNS messages.
+type Transport interface {
+	// SetHandler sets the handler of the received messages.
+	SetHandler(handler TransportHandler)
+	// Send sends the specified message to all peers including the sender.
+	Send(b []byte) error
+	// Start starts the transport.
+	Start() error
+	// Stop stops the transport.
+	Stop() error
+	// IsRunning returns true when the transport is running, otherwise false.
+	IsRunning() bool
+}
+
+// MulticastTransport represents a transport of the IPv4 multicast DNS group.
+type MulticastTransport struct {
+	mutex     sync.Mutex
+	ifi       *net.Interface
+	groupAddr *net.UDPAddr
+	conn      *net.UDPConn
+	handler   TransportHandler
+	done      chan struct{}
+}
+
+// NewMulticastTransport returns a new multicast transport on the system-assigned interface.
+func NewMulticastTransport() *MulticastTransport {
+	return &MulticastTransport{
+		ifi:       nil,
+		groupAddr: &net.UDPAddr{IP: net.ParseIP(MulticastAddress), Port: Port},
+		conn:      nil,
+		handler:   nil,
+		done:      nil,
+	}
+}
+
+// SetInterface sets the interface which the transport joins the multicast group on, and nil means the system-assigned interface.
+func (transport *MulticastTransport) SetInterface(ifi *net.Interface) {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	transport.ifi = ifi
+}
+
+// SetHandler sets the handler of the received messages.
+func (transport *MulticastTransport) SetHandler(handler TransportHandler) {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	transport.handler = handler
+}
+
+// Start joins the multicast group and starts receiving messages.
+func (transport *MulticastTransport) Start() error {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	if transport.conn != nil {
+		return nil
+	}
+	conn, err := net.ListenMulticastUDP("udp4", transport.ifi, transport.groupAddr)
+	if err != nil {
+		return err
+	}
+	transport.conn = conn
+	transport.done = make(chan struct{})
+	go transport.serve(conn, transport.done)
+	return nil
+}
+
+// serve receives messages until the connection is closed.
+func (transport *MulticastTransport) serve(conn *net.UDPConn, done chan struct{}) {
+	defer close(done)
+	buf := make([]byte, MessageMaxSize)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Errorf("%s", err.Error())
+			}
+			return
+		}
+		transport.mutex.Lock()
+		handler := transport.handler
+		transport.mutex.Unlock()
+		if handler == nil {
+			continue
+		}
+		handler(append([]byte{}, buf[:n]...), from)
+	}
+}
+
+// Send sends the specified message to the multicast group.
+func (transport *MulticastTransport) Send(b []byte) error {
+	transport.mutex.Lock()
+	conn := transport.conn
+	transport.mutex.Unlock()
+	if conn == nil {
+		return errors.New(errorTransportNotRunning)
+	}
+	if MessageMaxSize < len(b) {
+		return fmt.Errorf(errorTransportMessageTooLong, len(b))
+	}
+	_, err := conn.WriteToUDP(b, transport.groupAddr)
+	return err
+}
+
+// Stop leaves the multicast group and waits until the receiver is stopped.
+func (transport *MulticastTransport) Stop() error {
+	transport.mutex.Lock()
+	conn := transport.conn
+	done := transport.done
+	transport.conn = nil
+	transport.done = nil
+	transport.mutex.Unlock()
+	if conn == nil {
+		return nil
+	}
+	err := conn.Close()
+	<-done
+	return err
+}
+
+// IsRunning returns true when the transport is running, otherwise false.
+func (transport *MulticastTransport) IsRunning() bool {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	return transport.conn != nil
+}
+
+// String returns the description.
+func (transport *MulticastTransport) String() string {
+	return fmt.Sprintf("%s:%d", MulticastAddress, Port)
+}
@@ -0,0 +1,172 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mdns
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	memoryTransportQueueSize = 256
+	memoryTransportNetwork   = "memory"
+)
+
+// MemoryNetwork represents an in-process network which delivers the messages to all running transports on the network.
+type MemoryNetwork struct {
+	mutex      sync.Mutex
+	transports []*MemoryTransport
+}
+
+// memoryAddr represents an address of the in-process transports.
+type memoryAddr int
+
+// memoryPacket represents a message in the queue of the in-process transports.
+type memoryPacket struct {
+	b    []byte
+	from memoryAddr
+}
+
+// MemoryTransport represents a transport of the in-process network.
+type MemoryTransport struct {
+	network *MemoryNetwork
+	addr    memoryAddr
+	mutex   sync.Mutex
+	handler TransportHandler
+	queue   chan memoryPacket
+	done    chan struct{}
+}
+
+// NewMemoryNetwork returns a new in-process network.
+func NewMemoryNetwork() *MemoryNetwork {
+	return &MemoryNetwork{
+		transports: []*MemoryTransport{},
+	}
+}
+
+// NewTransport returns a new transport on the network.
+func (network *MemoryNetwork) NewTransport() *MemoryTransport {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+	transport := &MemoryTransport{
+		network: network,
+		addr:    memoryAddr(len(network.transports) + 1),
+		handler: nil,
+		queue:   nil,
+		done:    nil,
+	}
+	network.transports = append(network.transports, transport)
+	return transport
+}
+
+// Network returns the network name.
+func (addr memoryAddr) Network() string {
+	return memoryTransportNetwork
+}
+
+// String returns the address description.
+func (addr memoryAddr) String() string {
+	return fmt.Sprintf("%s:%d", memoryTransportNetwork, int(addr))
+}
+
+// SetHandler sets the handler of the received messages.
+func (transport *MemoryTransport) SetHandler(handler TransportHandler) {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	transport.handler = handler
+}
+
+// Start starts delivering the received messages to the handler.
+func (transport *MemoryTransport) Start() error {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	if transport.queue != nil {
+		return nil
+	}
+	transport.queue = make(chan memoryPacket, memoryTransportQueueSize)
+	transport.done = make(chan struct{})
+	go transport.serve(transport.queue, transport.done)
+	return nil
+}
+
+// serve delivers the queued messages in order until the queue is closed.
+func (transport *MemoryTransport) serve(queue chan memoryPacket, done chan struct{}) {
+	defer close(done)
+	for packet := range queue {
+		transport.mutex.Lock()
+		handler := transport.handler
+		transport.mutex.Unlock()
+		if handler == nil {
+			continue
+		}
+		handler(packet.b, packet.from)
+	}
+}
+
+// Send queues the specified message to all running transports on the network, and the message is dropped when the queue is full.
+func (transport *MemoryTransport) Send(b []byte) error {
+	if !transport.IsRunning() {
+		return errors.New(errorTransportNotRunning)
+	}
+	if MessageMaxSize < len(b) {
+		return fmt.Errorf(errorTransportMessageTooLong, len(b))
+	}
+
+	transport.network.mutex.Lock()
+	transports := make([]*MemoryTransport, len(transport.network.transports))
+	copy(transports, transport.network.transports)
+	transport.network.mutex.Unlock()
+
+	for _, peer := range transports {
+		peer.mutex.Lock()
+		if peer.queue != nil {
+			select {
+			case peer.queue <- memoryPacket{b: append([]byte{}, b...), from: transport.addr}:
+			default:
+			}
+		}
+		peer.mutex.Unlock()
+	}
+	return nil
+}
+
+// Stop stops delivering the messages and waits until the queued messages are delivered.
+func (transport *MemoryTransport) Stop() error {
+	transport.mutex.Lock()
+	queue := transport.queue
+	done := transport.done
+	transport.queue = nil
+	transport.done = nil
+	transport.mutex.Unlock()
+	if queue == nil {
+		return nil
+	}
+	close(queue)
+	<-done
+	return nil
+}
+
+// IsRunning returns true when the transport is running, otherwise false.
+func (transport *MemoryTransport) IsRunning() bool {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	return transport.queue != nil
+}
+
+// String returns the description.
+func (transport *MemoryTransport) String() string {
+	return transport.addr.String()
+}
@@ -0,0 +1,115 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mdns
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func waitTestTransportMessage(ch chan []byte) []byte {
+	select {
+	case b := <-ch:
+		return b
+	case <-time.After(5 * time.Second):
+		return nil
+	}
+}
+
+func TestMemoryTransport(t *testing.T) {
+	network := NewMemoryNetwork()
+
+	transports := []*MemoryTransport{network.NewTransport(), network.NewTransport()}
+	chs := make([]chan []byte, len(transports))
+	for n, transport := range transports {
+		ch := make(chan []byte, 1)
+		chs[n] = ch
+		transport.SetHandler(func(b []byte, from net.Addr) {
+			ch <- b
+		})
+		if err := transport.Send([]byte("x")); err == nil {
+			t.Errorf("%s : sent before started", transport)
+		}
+		if err := transport.Start(); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	// Messages are delivered to all transports including the sender
+
+	if err := transports[0].Send([]byte("hello")); err != nil {
+		t.Error(err)
+	}
+	for n, ch := range chs {
+		if b := waitTestTransportMessage(ch); string(b) != "hello" {
+			t.Errorf("[%d] %s != %s", n, b, "hello")
+		}
+	}
+
+	// Messages longer than the multicast DNS limit are rejected
+
+	if err := transports[0].Send(make([]byte, MessageMaxSize+1)); err == nil {
+		t.Errorf("%s : sent a too long message", transports[0])
+	}
+
+	for _, transport := range transports {
+		if err := transport.Stop(); err != nil {
+			t.Error(err)
+		}
+		if transport.IsRunning() {
+			t.Errorf("%s : running", transport)
+		}
+	}
+}
+
+func TestMulticastTransport(t *testing.T) {
+	transport := NewMulticastTransport()
+	ch := make(chan []byte, 16)
+	transport.SetHandler(func(b []byte, from net.Addr) {
+		ch <- b
+	})
+	if err := transport.Start(); err != nil {
+		t.Skipf("%s : %s", transport, err)
+		return
+	}
+	defer transport.Stop()
+
+	b, err := NewServiceQueryMessage().Bytes()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err := transport.Send(b); err != nil {
+		t.Skipf("%s : %s", transport, err)
+		return
+	}
+
+	// The multicast loopback delivers the sent message to the sender, but other responders may also send messages.
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case received := <-ch:
+			if string(received) == string(b) {
+				return
+			}
+		case <-timeout:
+			t.Skipf("%s : multicast loopback is not available", transport)
+			return
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"fmt"
+)
+
+const (
+	errorConditionInvalidTransition = "invalid condition transition : %s -> %s"
+)
+
+// conditionTransitions represents the allowed transitions of the node lifecycle.
+// The unknown condition is the unset condition, so the node can transit to any condition from it.
+var conditionTransitions = map[Condition][]Condition{
+	ConditionInitial:   {ConditionBootstrap, ConditionReady, ConditionStop},
+	ConditionBootstrap: {ConditionReady, ConditionStop, ConditionOutOfDate},
+	ConditionReady:     {ConditionBootstrap, ConditionStop, ConditionOutOfDate},
+	ConditionOutOfDate: {ConditionBootstrap, ConditionReady, ConditionStop},
+	ConditionStop:      {ConditionInitial, ConditionBootstrap},
+}
+
+// ConditionTransitionHook is called after the node condition is changed.
+type ConditionTransitionHook func(node *BaseNode, from Condition, to Condition)
+
+// Transitioner is an interface for nodes which change the condition with the validated transitions.
+type Transitioner interface {
+	// Transition changes the condition to the specified condition.
+	Transition(to Condition) error
+}
+
+// String returns the condition name.
+func (cond Condition) String() string {
+	switch cond {
+	case ConditionUnknown:
+		return "Unknown"
+	case ConditionInitial:
+		return "Initial"
+	case ConditionBootstrap:
+		return "Bootstrap"
+	case ConditionReady:
+		return "Ready"
+	case ConditionStop:
+		return "Stop"
+	case ConditionOutOfDate:
+		return "OutOfDate"
+	}
+	return fmt.Sprintf("0x%02X", uint(cond))
+}
+
+// CanTransition returns true when the node can transit from the specified condition to the other condition, otherwise false.
+// The transition to the same condition is always allowed.
+func CanTransition(from Condition, to Condition) bool {
+	if from == to || from == ConditionUnknown {
+		return true
+	}
+	for _, allowed := range conditionTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transit changes the condition of the specified node through the specified conditions in order when the node is a Transitioner,
+// and the other nodes are not changed.
+func Transit(node Node, conds ...Condition) error {
+	transitioner, ok := node.(Transitioner)
+	if !ok {
+		return nil
+	}
+	for _, cond := range conds {
+		if err := transitioner.Transition(cond); err != nil {
+			return err
+		}
+	}
+	return nil
+}
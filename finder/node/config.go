@@ -26,6 +26,12 @@ type Config interface {
 	Address() net.IP
 	// RPCPort returns the RPC port.
 	RPCPort() uint
+	// Ports returns the named service ports except the RPC port.
+	Ports() Ports
+	// Port returns the named service port, and false when the port is not set.
+	Port(name string) (uint, bool)
+	// Labels returns the metadata labels which are not compared as the node identity.
+	Labels() Labels
 }
 
 // ConfigEqual returns true if the other node is same with this node.
@@ -0,0 +1,125 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+const (
+	errorDescriptorNoHost         = "node has no host nor address"
+	errorDescriptorInvalidAddress = "invalid node address %q"
+	errorDescriptorInvalidRPCPort = "invalid node RPC port %d"
+)
+
+// Descriptor represents a JSON descriptor of a node which is shared with other processes such as the registries and the key-value stores.
+type Descriptor struct {
+	Cluster   string    `json:"cluster,omitempty"`
+	Host      string    `json:"host,omitempty"`
+	Address   string    `json:"address,omitempty"`
+	RPCPort   uint      `json:"rpc_port,omitempty"`
+	Ports     Ports     `json:"ports,omitempty"`
+	Labels    Labels    `json:"labels,omitempty"`
+	Condition Condition `json:"condition,omitempty"`
+	Clock     Clock     `json:"clock,omitempty"`
+}
+
+// NewDescriptorWithNode returns a new descriptor of the specified node.
+func NewDescriptorWithNode(srcNode Node) *Descriptor {
+	n := &Descriptor{
+		Cluster:   srcNode.Cluster(),
+		Host:      srcNode.Host(),
+		Address:   "",
+		RPCPort:   srcNode.RPCPort(),
+		Ports:     srcNode.Ports().Copy(),
+		Labels:    srcNode.Labels().Copy(),
+		Condition: srcNode.Condition(),
+		Clock:     srcNode.Clock(),
+	}
+	if ip := srcNode.Address(); ip != nil {
+		n.Address = ip.String()
+	}
+	return n
+}
+
+// Validate returns an error when the descriptor is invalid.
+func (n *Descriptor) Validate() error {
+	if n.Host == "" && n.Address == "" {
+		return errors.New(errorDescriptorNoHost)
+	}
+	if n.Address != "" && net.ParseIP(n.Address) == nil {
+		return fmt.Errorf(errorDescriptorInvalidAddress, n.Address)
+	}
+	if PortMax < n.RPCPort {
+		return fmt.Errorf(errorDescriptorInvalidRPCPort, n.RPCPort)
+	}
+	for name, port := range n.Ports {
+		if err := ValidatePort(name, port); err != nil {
+			return err
+		}
+	}
+	for key, value := range n.Labels {
+		if err := ValidateLabel(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ID returns the node identity which is the UUID of the node, and the address is the host of the identity when the host is empty.
+func (n *Descriptor) ID() string {
+	host := n.Host
+	if host == "" {
+		host = n.Address
+	}
+	return NewBaseNode().SetCluster(n.Cluster).SetHost(host).SetRPCPort(n.RPCPort).UUID()
+}
+
+// Node returns a new node of the descriptor.
+func (n *Descriptor) Node() *BaseNode {
+	baseNode := NewBaseNode().
+		SetCluster(n.Cluster).
+		SetHost(n.Host).
+		SetRPCPort(n.RPCPort).
+		SetPorts(n.Ports).
+		SetLabels(n.Labels)
+	if ip := net.ParseIP(n.Address); ip != nil {
+		baseNode.SetAddress(ip)
+	}
+	baseNode.SetClock(n.Clock)
+	baseNode.SetCondition(n.Condition)
+	return baseNode
+}
+
+// Copy returns a deep copy of the descriptor.
+func (n *Descriptor) Copy() *Descriptor {
+	copied := *n
+	copied.Ports = n.Ports.Copy()
+	copied.Labels = n.Labels.Copy()
+	return &copied
+}
+
+// Equal returns true when the descriptor is the same as the other descriptor, otherwise false.
+func (n *Descriptor) Equal(other *Descriptor) bool {
+	if n.Cluster != other.Cluster || n.Host != other.Host || n.Address != other.Address || n.RPCPort != other.RPCPort {
+		return false
+	}
+	if n.Condition != other.Condition || n.Clock != other.Clock {
+		return false
+	}
+	return PortsEqual(n.Ports, other.Ports) && LabelsEqual(n.Labels, other.Labels)
+}
@@ -0,0 +1,146 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// LabelSeparator is the separator of the label pairs in the label string.
+	LabelSeparator = ","
+	// LabelPairSeparator is the separator of the key and value in the label pair.
+	LabelPairSeparator = "="
+)
+
+const (
+	errorLabelInvalidKey   = "invalid label key %q"
+	errorLabelInvalidValue = "invalid label value %q"
+	errorLabelInvalidPair  = "invalid label %q"
+)
+
+var (
+	labelKeyRegexp   = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_./]*[A-Za-z0-9])?$`)
+	labelValueRegexp = regexp.MustCompile(`^([A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?)?$`)
+)
+
+// Labels represents arbitrary key/value metadata of a node such as zone, rack, role and version.
+type Labels map[string]string
+
+// IsValidLabelKey returns true when the specified key is a valid label key, otherwise false.
+// The key starts and ends with an alphanumeric character, and may have '-', '_', '.' and '/' between them.
+func IsValidLabelKey(key string) bool {
+	return labelKeyRegexp.MatchString(key)
+}
+
+// IsValidLabelValue returns true when the specified value is a valid label value, otherwise false.
+// The value is empty, or starts and ends with an alphanumeric character and may have '-', '_' and '.' between them.
+func IsValidLabelValue(value string) bool {
+	return labelValueRegexp.MatchString(value)
+}
+
+// NewLabels returns new empty labels.
+func NewLabels() Labels {
+	return Labels{}
+}
+
+// NewLabelsWithString returns new labels of the specified string such as "zone=a,role=storage".
+func NewLabelsWithString(str string) (Labels, error) {
+	labels := NewLabels()
+	if len(strings.TrimSpace(str)) == 0 {
+		return labels, nil
+	}
+	for _, pair := range strings.Split(str, LabelSeparator) {
+		kv := strings.SplitN(pair, LabelPairSeparator, 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf(errorLabelInvalidPair, pair)
+		}
+		key := strings.TrimSpace(kv[0])
+		value := strings.TrimSpace(kv[1])
+		if err := ValidateLabel(key, value); err != nil {
+			return nil, err
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// ValidateLabel returns an error when the specified label key or value is invalid.
+func ValidateLabel(key string, value string) error {
+	if !IsValidLabelKey(key) {
+		return fmt.Errorf(errorLabelInvalidKey, key)
+	}
+	if !IsValidLabelValue(value) {
+		return fmt.Errorf(errorLabelInvalidValue, value)
+	}
+	return nil
+}
+
+// Get returns the value of the specified key, and false when the key is not set.
+func (labels Labels) Get(key string) (string, bool) {
+	value, ok := labels[key]
+	return value, ok
+}
+
+// Has returns true when the specified key is set, otherwise false.
+func (labels Labels) Has(key string) bool {
+	_, ok := labels[key]
+	return ok
+}
+
+// Copy returns a copy of the labels.
+func (labels Labels) Copy() Labels {
+	copied := make(Labels, len(labels))
+	for key, value := range labels {
+		copied[key] = value
+	}
+	return copied
+}
+
+// Keys returns the sorted keys of the labels.
+func (labels Labels) Keys() []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// String returns the labels string sorted by the keys such as "role=storage,zone=a".
+func (labels Labels) String() string {
+	pairs := make([]string, 0, len(labels))
+	for _, key := range labels.Keys() {
+		pairs = append(pairs, key+LabelPairSeparator+labels[key])
+	}
+	return strings.Join(pairs, LabelSeparator)
+}
+
+// LabelsEqual returns true when the specified labels are the same, otherwise false.
+func LabelsEqual(this, other Labels) bool {
+	if len(this) != len(other) {
+		return false
+	}
+	for key, value := range this {
+		otherValue, ok := other[key]
+		if !ok || otherValue != value {
+			return false
+		}
+	}
+	return true
+}
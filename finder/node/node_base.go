@@ -15,25 +15,36 @@
 package node
 
 import (
+	"fmt"
 	"net"
+	"sync"
 )
 
 // BaseNode represents a base node.
+// The node is safe for concurrent use, so the status can be changed while the node is announced or advertised by other goroutines.
 type BaseNode struct {
 	Node
-	cluster string
-	host    string
-	address net.IP
-	rpcPort uint
-	clock   Clock
-	cond    Condition
+	// mutex guards all fields, and the host and the address are resolved lazily without holding it.
+	mutex      sync.Mutex
+	cluster    string
+	host       string
+	address    net.IP
+	rpcPort    uint
+	ports      Ports
+	labels     Labels
+	clock      Clock
+	clockHooks []ClockUpdateHook
+	cond       Condition
+	condHooks  []ConditionTransitionHook
 }
 
 // NewBaseNode returns a new base node.
 func NewBaseNode() *BaseNode {
 	node := &BaseNode{
-		cond:  ConditionInitial,
-		clock: 0,
+		ports:  NewPorts(),
+		labels: NewLabels(),
+		cond:   ConditionInitial,
+		clock:  0,
 	}
 	return node
 }
@@ -43,104 +54,270 @@ func NewNode() Node {
 	return NewBaseNode()
 }
 
-// UpdateClock increments the internal clock.
+// UpdateClock increments the internal clock, and calls the clock hooks.
 func (node *BaseNode) UpdateClock() {
+	node.mutex.Lock()
+	from := node.clock
 	node.clock++
+	node.mutex.Unlock()
+	node.postClockUpdated(from, from+1)
 }
 
-// SetStatus sets the specified status to the node.
+// SetStatus sets the specified status to the node without validating the condition transition and calling the condition hooks.
 func (node *BaseNode) SetStatus(status Status) {
-	node.clock = status.Clock()
-	node.cond = status.Condition()
+	node.SetClock(status.Clock())
+	node.SetCondition(status.Condition())
 }
 
 // SetCluster sets the specified cluster name to the node.
 func (node *BaseNode) SetCluster(name string) *BaseNode {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
 	node.cluster = name
 	return node
 }
 
 // SetHost sets the specified host name to the node.
 func (node *BaseNode) SetHost(name string) *BaseNode {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
 	node.host = name
 	return node
 }
 
 // SetAddress sets the specified address name to the node.
 func (node *BaseNode) SetAddress(addr net.IP) *BaseNode {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
 	node.address = addr
 	return node
 }
 
 // SetRPCPort sets the specified portto the node.
 func (node *BaseNode) SetRPCPort(port uint) *BaseNode {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
 	node.rpcPort = port
 	return node
 }
 
-// SetClock sets the specified clock to the node.
+// SetPort sets the specified named service port to the node.
+func (node *BaseNode) SetPort(name string, port uint) *BaseNode {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+	ports := node.ports.Copy()
+	ports[name] = port
+	node.ports = ports
+	return node
+}
+
+// SetPorts replaces all named service ports of the node with a copy of the specified ports.
+func (node *BaseNode) SetPorts(ports Ports) *BaseNode {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+	node.ports = ports.Copy()
+	return node
+}
+
+// SetLabel sets the specified label to the node.
+func (node *BaseNode) SetLabel(key string, value string) *BaseNode {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+	labels := node.labels.Copy()
+	labels[key] = value
+	node.labels = labels
+	return node
+}
+
+// SetLabels replaces all labels of the node with a copy of the specified labels.
+func (node *BaseNode) SetLabels(labels Labels) *BaseNode {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+	node.labels = labels.Copy()
+	return node
+}
+
+// SetClock sets the specified clock to the node, and calls the clock hooks when the clock is changed.
 func (node *BaseNode) SetClock(val Clock) {
+	node.mutex.Lock()
+	from := node.clock
 	node.clock = val
+	node.mutex.Unlock()
+	if from != val {
+		node.postClockUpdated(from, val)
+	}
 }
 
-// SetCondition sets the specified condition to the node.
+// AddClockHook adds the specified hook which is called after the clock is changed by UpdateClock or SetClock.
+func (node *BaseNode) AddClockHook(hook ClockUpdateHook) *BaseNode {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+	node.clockHooks = append(node.clockHooks, hook)
+	return node
+}
+
+// postClockUpdated calls the clock hooks with the specified clocks.
+func (node *BaseNode) postClockUpdated(from Clock, to Clock) {
+	node.mutex.Lock()
+	hooks := make([]ClockUpdateHook, len(node.clockHooks))
+	copy(hooks, node.clockHooks)
+	node.mutex.Unlock()
+	for _, hook := range hooks {
+		hook(node, from, to)
+	}
+}
+
+// SetCondition sets the specified condition to the node without validating the transition and calling the hooks.
+// Use Transition to change the lifecycle condition of the local node.
 func (node *BaseNode) SetCondition(val Condition) {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
 	node.cond = val
 }
 
+// AddConditionHook adds the specified hook which is called after the condition is changed by Transition.
+func (node *BaseNode) AddConditionHook(hook ConditionTransitionHook) *BaseNode {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+	node.condHooks = append(node.condHooks, hook)
+	return node
+}
+
+// Transition changes the condition to the specified condition, and calls the hooks when the condition is changed.
+// An error is returned and the condition is not changed when the transition is not allowed.
+func (node *BaseNode) Transition(to Condition) error {
+	node.mutex.Lock()
+	from := node.cond
+	if !CanTransition(from, to) {
+		node.mutex.Unlock()
+		return fmt.Errorf(errorConditionInvalidTransition, from, to)
+	}
+	node.cond = to
+	hooks := make([]ConditionTransitionHook, len(node.condHooks))
+	copy(hooks, node.condHooks)
+	node.mutex.Unlock()
+
+	if from == to {
+		return nil
+	}
+	for _, hook := range hooks {
+		hook(node, from, to)
+	}
+	return nil
+}
+
 // Cluster returns the cluster name.
 func (node *BaseNode) Cluster() string {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
 	return node.cluster
 }
 
-// Host returns the host name.
+// Host returns the host name, and the name is resolved from the address when the host name is not set.
 func (node *BaseNode) Host() string {
-	if 0 < len(node.host) {
-		return node.host
+	node.mutex.Lock()
+	host, addr := node.host, node.address
+	node.mutex.Unlock()
+
+	if 0 < len(host) {
+		return host
 	}
 
-	if len(node.address) <= 0 {
+	if len(addr) <= 0 {
 		return ""
 	}
-	names, err := net.LookupAddr(node.address.String())
+	names, err := net.LookupAddr(addr.String())
 	if err != nil {
 		return ""
 	}
-	node.host = names[0]
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+	if len(node.host) <= 0 {
+		node.host = names[0]
+	}
 
 	return node.host
 }
 
-// Address returns the interface address.
+// Address returns the interface address, and the address is resolved from the host name when the address is not set.
 func (node *BaseNode) Address() net.IP {
-	if 0 < len(node.address) {
-		return node.address
+	node.mutex.Lock()
+	host, addr := node.host, node.address
+	node.mutex.Unlock()
+
+	if 0 < len(addr) {
+		return addr
 	}
 
-	if len(node.host) <= 0 {
+	if len(host) <= 0 {
 		return nil
 	}
-	addrs, err := net.LookupIP(node.host)
+	addrs, err := net.LookupIP(host)
 	if err != nil {
 		return nil
 	}
-	node.address = addrs[0]
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+	if len(node.address) <= 0 {
+		node.address = addrs[0]
+	}
 
 	return node.address
 }
 
 // RPCPort returns the RPC port.
 func (node *BaseNode) RPCPort() uint {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
 	return node.rpcPort
 }
 
+// Ports returns the named service ports of the node.
+// The returned ports must not be modified, use SetPort or SetPorts instead.
+func (node *BaseNode) Ports() Ports {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+	return node.ports
+}
+
+// Port returns the specified named service port, and false when the port is not set.
+func (node *BaseNode) Port(name string) (uint, bool) {
+	return node.Ports().Get(name)
+}
+
+// Labels returns the labels of the node.
+// The returned labels must not be modified, use SetLabel or SetLabels instead.
+func (node *BaseNode) Labels() Labels {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+	return node.labels
+}
+
+// Label returns the value of the specified label key, and false when the label is not set.
+func (node *BaseNode) Label(key string) (string, bool) {
+	return node.Labels().Get(key)
+}
+
 // Condition returns the current status.
-func (node *BaseNode) Cndition() Condition {
+func (node *BaseNode) Condition() Condition {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
 	return node.cond
 }
 
+// Cndition returns the current status.
+//
+// Deprecated: Use Condition instead.
+func (node *BaseNode) Cndition() Condition {
+	return node.Condition()
+}
+
 // Clock returns the current logical clock.
 func (node *BaseNode) Clock() Clock {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
 	return node.clock
 }
 
@@ -15,8 +15,11 @@
 package node
 
 import (
+	"encoding/json"
+	"fmt"
 	"net"
 	"regexp"
+	"sync"
 	"testing"
 )
 
@@ -99,3 +102,251 @@ func TestEqual(t *testing.T) {
 		t.Errorf("%s == %s", node01.Host(), node02.Host())
 	}
 }
+
+func TestLabels(t *testing.T) {
+	node := NewBaseNode().SetHost("node01").SetLabel("zone", "a")
+	labels := node.Labels()
+	node.SetLabel("role", "storage")
+	if labels.Has("role") {
+		t.Errorf("%s : labels are shared", labels)
+	}
+	if value, ok := node.Label("role"); !ok || value != "storage" {
+		t.Errorf("%s != %s", value, "storage")
+	}
+	if node.Labels().String() != "role=storage,zone=a" {
+		t.Errorf("%s != %s", node.Labels().String(), "role=storage,zone=a")
+	}
+
+	parsedLabels, err := NewLabelsWithString(" zone = a , role=storage")
+	if err != nil {
+		t.Error(err)
+	}
+	if !LabelsEqual(parsedLabels, node.Labels()) {
+		t.Errorf("%s != %s", parsedLabels, node.Labels())
+	}
+
+	validLabels := [][]string{
+		{"zone", "a"},
+		{"topology.kubernetes.io/zone", "us-east-1a"},
+		{"version", "1.2.3"},
+		{"empty", ""},
+		{"a", "b_c"},
+	}
+	for _, label := range validLabels {
+		if err := ValidateLabel(label[0], label[1]); err != nil {
+			t.Error(err)
+		}
+	}
+
+	invalidLabels := [][]string{
+		{"", "a"},
+		{"-zone", "a"},
+		{"zone-", "a"},
+		{"zone=", "a"},
+		{"zone", "a,b"},
+		{"zone", "-a"},
+		{"zone", "a b"},
+	}
+	for _, label := range invalidLabels {
+		if err := ValidateLabel(label[0], label[1]); err == nil {
+			t.Errorf("%s=%s is valid", label[0], label[1])
+		}
+	}
+
+	for _, str := range []string{"zone", "zone=a,", "=a", "zone=a=b"} {
+		if _, err := NewLabelsWithString(str); err == nil {
+			t.Errorf("%s is parsed", str)
+		}
+	}
+}
+
+func TestPorts(t *testing.T) {
+	node := NewBaseNode().SetHost("node01").SetRPCPort(8001).SetPort(PortCarbon, 2003)
+	ports := node.Ports()
+	node.SetPort(PortRender, 8080)
+	if _, ok := ports.Get(PortRender); ok {
+		t.Errorf("%s : ports are shared", ports)
+	}
+	if port, ok := node.Port(PortRender); !ok || port != 8080 {
+		t.Errorf("%d != %d", port, 8080)
+	}
+	if _, ok := node.Port("rpc"); ok {
+		t.Errorf("the RPC port is a named port")
+	}
+	if node.Ports().String() != "carbon=2003,render=8080" {
+		t.Errorf("%s != %s", node.Ports().String(), "carbon=2003,render=8080")
+	}
+
+	parsedPorts, err := NewPortsWithString(" render = 8080 , carbon=2003")
+	if err != nil {
+		t.Error(err)
+	}
+	if !PortsEqual(parsedPorts, node.Ports()) {
+		t.Errorf("%s != %s", parsedPorts, node.Ports())
+	}
+
+	for _, str := range []string{"carbon", "carbon=", "carbon=-1", "carbon=65536", "-carbon=2003", "carbon=2003,"} {
+		if _, err := NewPortsWithString(str); err == nil {
+			t.Errorf("%s is parsed", str)
+		}
+	}
+}
+
+func TestConditionTransition(t *testing.T) {
+	node := NewBaseNode()
+	if node.Condition() != ConditionInitial {
+		t.Errorf("%s != %s", node.Condition(), ConditionInitial)
+	}
+
+	transitions := [][]Condition{}
+	node.AddConditionHook(func(hookNode *BaseNode, from Condition, to Condition) {
+		if hookNode != node {
+			t.Errorf("%v != %v", hookNode, node)
+		}
+		transitions = append(transitions, []Condition{from, to})
+	})
+
+	conds := []Condition{
+		ConditionBootstrap,
+		ConditionReady,
+		ConditionReady,
+		ConditionOutOfDate,
+		ConditionReady,
+		ConditionStop,
+		ConditionInitial,
+	}
+	for _, cond := range conds {
+		if err := node.Transition(cond); err != nil {
+			t.Error(err)
+		}
+		if node.Condition() != cond {
+			t.Errorf("%s != %s", node.Condition(), cond)
+		}
+	}
+	// The transition to the same condition calls no hooks
+	if len(transitions) != len(conds)-1 {
+		t.Errorf("%d != %d", len(transitions), len(conds)-1)
+	}
+
+	invalidTransitions := [][]Condition{
+		{ConditionInitial, ConditionOutOfDate},
+		{ConditionStop, ConditionReady},
+		{ConditionStop, ConditionOutOfDate},
+		{ConditionBootstrap, ConditionInitial},
+		{ConditionReady, ConditionInitial},
+	}
+	for _, invalid := range invalidTransitions {
+		node.SetCondition(invalid[0])
+		if err := node.Transition(invalid[1]); err == nil {
+			t.Errorf("%s -> %s is allowed", invalid[0], invalid[1])
+		}
+		if node.Condition() != invalid[0] {
+			t.Errorf("%s != %s", node.Condition(), invalid[0])
+		}
+	}
+
+	if !CanTransition(ConditionUnknown, ConditionReady) {
+		t.Errorf("%s -> %s is not allowed", ConditionUnknown, ConditionReady)
+	}
+	if Condition(0x99).String() != "0x99" {
+		t.Errorf("%s != %s", Condition(0x99), "0x99")
+	}
+}
+
+func TestTransit(t *testing.T) {
+	node := NewBaseNode()
+	if err := Transit(node, ConditionBootstrap, ConditionReady); err != nil {
+		t.Error(err)
+	}
+	if node.Condition() != ConditionReady {
+		t.Errorf("%s != %s", node.Condition(), ConditionReady)
+	}
+	if err := Transit(node, ConditionStop, ConditionOutOfDate); err == nil {
+		t.Errorf("%s -> %s is allowed", ConditionStop, ConditionOutOfDate)
+	}
+	if node.Condition() != ConditionStop {
+		t.Errorf("%s != %s", node.Condition(), ConditionStop)
+	}
+}
+
+func TestBaseNodeConcurrentStatus(t *testing.T) {
+	node := NewBaseNode().SetCluster("cluster").SetHost("org.cybergarage.finder001").SetRPCPort(8080)
+	node.AddClockHook(func(hookNode *BaseNode, from Clock, to Clock) {
+		if to != from+1 {
+			t.Errorf("%d != %d", to, from+1)
+		}
+	})
+
+	var wg sync.WaitGroup
+	for n := 0; n < 4; n++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				node.UpdateClock()
+				node.SetPort(PortCarbon, uint(2000+i))
+				node.SetLabel("zone", fmt.Sprintf("%d", i))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				_ = node.Clock()
+				_, _ = node.Port(PortCarbon)
+				_, _ = node.Label("zone")
+				_ = node.UUID()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if node.Clock() != 4*100 {
+		t.Errorf("%d != %d", node.Clock(), 4*100)
+	}
+}
+
+func TestDescriptor(t *testing.T) {
+	srcNode := NewBaseNode().SetCluster("cluster").SetHost(testNodeName).SetAddress(net.ParseIP("192.168.100.1")).SetRPCPort(8001)
+	srcNode.SetPort(PortCarbon, 2003)
+	srcNode.SetLabel("zone", "a")
+	srcNode.SetClock(10)
+
+	// The descriptor is encoded and decoded without losing the node
+
+	data, err := json.Marshal(NewDescriptorWithNode(srcNode))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	desc := &Descriptor{}
+	if err := json.Unmarshal(data, desc); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := desc.Validate(); err != nil {
+		t.Error(err)
+	}
+	if !desc.Equal(NewDescriptorWithNode(srcNode)) || !desc.Copy().Equal(desc) {
+		t.Errorf(testNodeMatchingError, srcNode.Host(), desc.Host)
+	}
+	if desc.ID() != srcNode.UUID() {
+		t.Errorf(testNodeMatchingError, srcNode.UUID(), desc.ID())
+	}
+	decodedNode := desc.Node()
+	if !Equal(decodedNode, srcNode) || !StatusEqual(decodedNode, srcNode) {
+		t.Errorf(testNodeMatchingError, srcNode.Host(), decodedNode.Host())
+	}
+
+	// The invalid descriptors are rejected
+
+	invalidDescs := []*Descriptor{
+		{},
+		{Address: "invalid"},
+		{Host: testNodeName, RPCPort: PortMax + 1},
+	}
+	for _, invalidDesc := range invalidDescs {
+		if err := invalidDesc.Validate(); err == nil {
+			t.Errorf("%v : validated", invalidDesc)
+		}
+	}
+}
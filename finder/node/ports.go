@@ -0,0 +1,134 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// PortCarbon is the port name of the Carbon receiver.
+	PortCarbon = "carbon"
+	// PortRender is the port name of the Graphite render API.
+	PortRender = "render"
+	// PortGossip is the port name of the gossip membership.
+	PortGossip = "gossip"
+)
+
+const (
+	// PortMax is the max port number.
+	PortMax = 65535
+)
+
+const (
+	errorPortInvalidName = "invalid port name %q"
+	errorPortInvalid     = "invalid port %q"
+	errorPortOutOfRange  = "invalid %s port %d"
+)
+
+// Ports represents named service ports of a node such as carbon and render, in addition to the RPC port.
+type Ports map[string]uint
+
+// NewPorts returns new empty ports.
+func NewPorts() Ports {
+	return Ports{}
+}
+
+// NewPortsWithString returns new ports of the specified string such as "carbon=2003,render=8080".
+func NewPortsWithString(str string) (Ports, error) {
+	ports := NewPorts()
+	if len(strings.TrimSpace(str)) == 0 {
+		return ports, nil
+	}
+	for _, pair := range strings.Split(str, LabelSeparator) {
+		kv := strings.SplitN(pair, LabelPairSeparator, 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf(errorPortInvalid, pair)
+		}
+		name := strings.TrimSpace(kv[0])
+		port, err := strconv.ParseUint(strings.TrimSpace(kv[1]), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf(errorPortInvalid, pair)
+		}
+		if err := ValidatePort(name, uint(port)); err != nil {
+			return nil, err
+		}
+		ports[name] = uint(port)
+	}
+	return ports, nil
+}
+
+// ValidatePort returns an error when the specified port name or number is invalid.
+// The port name has the same syntax as the label keys.
+func ValidatePort(name string, port uint) error {
+	if !IsValidLabelKey(name) {
+		return fmt.Errorf(errorPortInvalidName, name)
+	}
+	if PortMax < port {
+		return fmt.Errorf(errorPortOutOfRange, name, port)
+	}
+	return nil
+}
+
+// Get returns the port of the specified name, and false when the port is not set.
+func (ports Ports) Get(name string) (uint, bool) {
+	port, ok := ports[name]
+	return port, ok
+}
+
+// Copy returns a copy of the ports.
+func (ports Ports) Copy() Ports {
+	copied := make(Ports, len(ports))
+	for name, port := range ports {
+		copied[name] = port
+	}
+	return copied
+}
+
+// Names returns the sorted names of the ports.
+func (ports Ports) Names() []string {
+	names := make([]string, 0, len(ports))
+	for name := range ports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String returns the ports string sorted by the names such as "carbon=2003,render=8080".
+func (ports Ports) String() string {
+	pairs := make([]string, 0, len(ports))
+	for _, name := range ports.Names() {
+		pairs = append(pairs, fmt.Sprintf("%s%s%d", name, LabelPairSeparator, ports[name]))
+	}
+	return strings.Join(pairs, LabelSeparator)
+}
+
+// PortsEqual returns true when the specified ports are the same, otherwise false.
+func PortsEqual(this, other Ports) bool {
+	if len(this) != len(other) {
+		return false
+	}
+	for name, port := range this {
+		otherPort, ok := other[name]
+		if !ok || otherPort != port {
+			return false
+		}
+	}
+	return true
+}
@@ -20,13 +20,16 @@ type Condition uint
 // Clock represents a node clock type.
 type Clock uint
 
+// ClockUpdateHook is called after the node clock is changed.
+type ClockUpdateHook func(node *BaseNode, from Clock, to Clock)
+
 const (
-	ConditionUnknown   = 0x00
-	ConditionInitial   = 0x10
-	ConditionBootstrap = 0x20
-	ConditionReady     = 0x30
-	ConditionStop      = 0x31
-	ConditionOutOfDate = 0x32
+	ConditionUnknown   Condition = 0x00
+	ConditionInitial   Condition = 0x10
+	ConditionBootstrap Condition = 0x20
+	ConditionReady     Condition = 0x30
+	ConditionStop      Condition = 0x31
+	ConditionOutOfDate Condition = 0x32
 )
 
 // Status represents an abstractinterface for the node status.
@@ -36,3 +39,43 @@ type Status interface {
 	// Clock returns the current logical clock.
 	Clock() Clock
 }
+
+// baseStatus represents a snapshot of the node status.
+type baseStatus struct {
+	cond  Condition
+	clock Clock
+}
+
+// NewStatus returns a new status snapshot with the specified condition and clock.
+func NewStatus(cond Condition, clock Clock) Status {
+	return &baseStatus{
+		cond:  cond,
+		clock: clock,
+	}
+}
+
+// NewStatusWithStatus returns a new status snapshot of the specified status.
+func NewStatusWithStatus(status Status) Status {
+	return NewStatus(status.Condition(), status.Clock())
+}
+
+// Condition returns the condition.
+func (status *baseStatus) Condition() Condition {
+	return status.cond
+}
+
+// Clock returns the logical clock.
+func (status *baseStatus) Clock() Clock {
+	return status.clock
+}
+
+// StatusEqual returns true when the specified statuses are the same, otherwise false.
+func StatusEqual(this, other Status) bool {
+	if this.Condition() != other.Condition() {
+		return false
+	}
+	if this.Clock() != other.Clock() {
+		return false
+	}
+	return true
+}
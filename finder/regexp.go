@@ -16,25 +16,20 @@ package finder
 
 import (
 	"regexp"
-	"strings"
-)
-
-const (
-	// See : http://graphite.readthedocs.io/en/latest/render_api.html
-	exprAsteriskGraphite = "*"
-	exprAsteriskGo       = "(.*)"
 )
 
 // Regexp represents a regexp for the finder.
 type Regexp struct {
 	expr     string
 	goRegexp *regexp.Regexp
+	graphite *graphitePattern
 }
 
 // NewRegexp returns a new regexp.
 func NewRegexp() *Regexp {
 	regexp := &Regexp{
 		goRegexp: nil,
+		graphite: nil,
 	}
 	return regexp
 }
@@ -47,32 +42,42 @@ func (re *Regexp) Compile(expr string) error {
 		return err
 	}
 	re.expr = expr
+	re.graphite = nil
 	return nil
 }
 
-// CompileGraphite parses a regular expression to Graphite
+// CompileGraphite parses a Graphite path pattern.
+// The leading path components of the pattern are matched with the node name.
 // See : http://graphite.readthedocs.io/en/latest/render_api.html
 func (re *Regexp) CompileGraphite(expr string) error {
-	// FIXME : Only replacing the prefix expression string
-	if strings.HasPrefix(expr, exprAsteriskGraphite) {
-		expr = strings.Replace(expr, exprAsteriskGraphite, exprAsteriskGo, 1)
+	pattern, err := compileGraphitePattern(expr)
+	if err != nil {
+		return err
 	}
+	re.expr = expr
+	re.goRegexp = pattern.pathRegexp
+	re.graphite = pattern
+	return nil
+}
 
-	return re.Compile(expr)
+// MatchString reports whether the Regexp matches the string.
+// The Graphite pattern matches only the whole path.
+func (re *Regexp) MatchString(s string) bool {
+	if re.goRegexp == nil {
+		return false
+	}
+	return re.goRegexp.MatchString(s)
 }
 
 // matchNodeString reports whether the Regexp matches the string.
 func (re *Regexp) matchNodeString(nodeStr string) bool {
-	if len(nodeStr) <= 0 {
+	if len(nodeStr) <= 0 || re.goRegexp == nil {
 		return false
 	}
 
-	// FIXME : Only checking the prefix expression string
-	if strings.HasPrefix(re.expr, exprAsteriskGo) {
-		return true
-	}
-	if strings.HasPrefix(re.expr, nodeStr) {
-		return true
+	if re.graphite != nil {
+		_, ok := re.graphite.expandPrefix(nodeStr)
+		return ok
 	}
 
 	return re.goRegexp.MatchString(nodeStr)
@@ -85,18 +90,22 @@ func (re *Regexp) MatchNode(node Node) bool {
 		return true
 	}
 
-	return re.matchNodeString(node.Address().String())
+	addr := node.Address()
+	if addr == nil {
+		return false
+	}
+	return re.matchNodeString(addr.String())
 }
 
-// expandNodeString replaces expression to node returns the result;.
+// expandNodeString replaces the leading components of the Graphite pattern with the node string, and returns the result.
+// The regular expression which is not a Graphite pattern is returned as it is.
 func (re *Regexp) expandNodeString(nodeStr string) (string, bool) {
 	if len(nodeStr) <= 0 {
 		return "", false
 	}
 
-	// FIXME : Only replacing the prefix expression string
-	if strings.HasPrefix(re.expr, exprAsteriskGo) {
-		return strings.Replace(re.expr, exprAsteriskGo, nodeStr, 1), true
+	if re.graphite != nil {
+		return re.graphite.expandPrefix(nodeStr)
 	}
 
 	return re.expr, true
@@ -109,10 +118,30 @@ func (re *Regexp) ExpandNode(node Node) (string, bool) {
 		return result, true
 	}
 
-	result, ok = re.expandNodeString(node.Address().String())
+	addr := node.Address()
+	if addr == nil {
+		return "", false
+	}
+	result, ok = re.expandNodeString(addr.String())
 	if ok {
 		return result, true
 	}
 
 	return "", false
 }
+
+// ExpandNodes returns the expanded results of the nodes which the Regexp matches.
+func (re *Regexp) ExpandNodes(nodes []Node) map[Node]string {
+	results := map[Node]string{}
+	for _, node := range nodes {
+		if !re.MatchNode(node) {
+			continue
+		}
+		result, ok := re.ExpandNode(node)
+		if !ok {
+			continue
+		}
+		results[node] = result
+	}
+	return results
+}
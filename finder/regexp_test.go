@@ -67,3 +67,50 @@ func TestRegexpGraphite(t *testing.T) {
 		}
 	}
 }
+
+var testRegexUnmatchedTestCases = [][]string{
+	{"node01", "node02.*"},
+	{"node01", "node0[2-9].*"},
+	{"node01", "{node02,node03}.metrics01"},
+	{"node01.service", "*.metrics01"},
+}
+
+func TestRegexpGraphiteNodes(t *testing.T) {
+	testCases := [][]string{
+		{"node01", "node0?.metrics01", "node01.metrics01"},
+		{"node01", "node0[1-3].metrics01.*", "node01.metrics01.*"},
+		{"node01", "{node01,node02}.metrics01", "node01.metrics01"},
+		{"node01", "node*.{metrics01,metrics02}", "node01.{metrics01,metrics02}"},
+		{"org.cybergarage.node01", "org.*.node0?.metrics01", "org.cybergarage.node01.metrics01"},
+	}
+
+	for n, testCase := range testCases {
+		re := NewRegexp()
+		if err := re.CompileGraphite(testCase[1]); err != nil {
+			t.Errorf("[%d] %s : %s", n, testCase[1], err)
+			continue
+		}
+		node := node.NewBaseNode()
+		node.SetHost(testCase[0])
+		expandedName, ok := re.ExpandNode(node)
+		if !ok || expandedName != testCase[2] {
+			t.Errorf("[%d] %s != %s", n, testCase[2], expandedName)
+		}
+	}
+
+	for n, testCase := range testRegexUnmatchedTestCases {
+		re := NewRegexp()
+		if err := re.CompileGraphite(testCase[1]); err != nil {
+			t.Errorf("[%d] %s : %s", n, testCase[1], err)
+			continue
+		}
+		node := node.NewBaseNode()
+		node.SetHost(testCase[0])
+		if re.MatchNode(node) {
+			t.Errorf(testFinderMatchingError, testCase[1], node.Host())
+		}
+		if expandedName, ok := re.ExpandNode(node); ok {
+			t.Errorf("[%d] %s : %s", n, testCase[1], expandedName)
+		}
+	}
+}
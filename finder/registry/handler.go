@@ -0,0 +1,146 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cybergarage/go-logger/log"
+)
+
+const (
+	// NodesPath is the path of the registered nodes. The nodes are registered by POST, listed by GET and deregistered by DELETE with the node identity.
+	NodesPath = "/nodes"
+	// VersionParam is the query parameter of the version which the node list is waited to be changed from.
+	VersionParam = "version"
+	// WaitParam is the query parameter of the max duration such as "30s" to wait for the node list to be changed.
+	WaitParam = "wait"
+	// MaxWait is the max duration to wait for the node list to be changed.
+	MaxWait = 60 * time.Second
+)
+
+const (
+	requestBodyMaxSize = 1 << 20
+	contentTypeJSON    = "application/json"
+)
+
+const (
+	errorHandlerInvalidParam = "invalid %s : %q"
+	errorHandlerNotFound     = "node %q is not registered"
+)
+
+// Registration represents a response of the node registration.
+type Registration struct {
+	// ID is the node identity which the node is deregistered with.
+	ID string `json:"id"`
+	// TTL is the time-to-live of the registration in seconds, and the node must be registered again before it expires.
+	TTL float64 `json:"ttl"`
+}
+
+// Handler represents an HTTP handler of the registry.
+type Handler struct {
+	registry *Registry
+	mux      *http.ServeMux
+}
+
+// NewHandler returns a new HTTP handler of the specified registry.
+func NewHandler(registry *Registry) *Handler {
+	handler := &Handler{
+		registry: registry,
+		mux:      http.NewServeMux(),
+	}
+	handler.mux.HandleFunc("GET "+NodesPath, handler.getNodes)
+	handler.mux.HandleFunc("POST "+NodesPath, handler.postNode)
+	handler.mux.HandleFunc("DELETE "+NodesPath+"/{id}", handler.deleteNode)
+	return handler
+}
+
+// Registry returns the registry of the handler.
+func (handler *Handler) Registry() *Registry {
+	return handler.registry
+}
+
+// ServeHTTP serves the registry requests.
+func (handler *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handler.mux.ServeHTTP(w, r)
+}
+
+// writeJSON writes the specified value as a JSON response.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", contentTypeJSON)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("%s", err.Error())
+	}
+}
+
+// getNodes responds the registered nodes. When the version and the wait are specified, the response is held until the version is changed or the wait is elapsed.
+func (handler *Handler) getNodes(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	versionParam := query.Get(VersionParam)
+	waitParam := query.Get(WaitParam)
+	if versionParam == "" || waitParam == "" {
+		writeJSON(w, handler.registry.Nodes())
+		return
+	}
+
+	version, err := strconv.ParseUint(versionParam, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(errorHandlerInvalidParam, VersionParam, versionParam), http.StatusBadRequest)
+		return
+	}
+	wait, err := time.ParseDuration(waitParam)
+	if err != nil || wait < 0 {
+		http.Error(w, fmt.Sprintf(errorHandlerInvalidParam, WaitParam, waitParam), http.StatusBadRequest)
+		return
+	}
+	if MaxWait < wait {
+		wait = MaxWait
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), wait)
+	defer cancel()
+	writeJSON(w, handler.registry.Wait(ctx, version))
+}
+
+// postNode registers the requested node, and the node is registered again as the heartbeat.
+func (handler *Handler) postNode(w http.ResponseWriter, r *http.Request) {
+	n := &Node{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, requestBodyMaxSize)).Decode(n); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	id, err := handler.registry.Register(n)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, &Registration{ID: id, TTL: handler.registry.TTL().Seconds()})
+}
+
+// deleteNode deregisters the node of the requested identity.
+func (handler *Handler) deleteNode(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !handler.registry.Deregister(id) {
+		http.Error(w, fmt.Sprintf(errorHandlerNotFound, id), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
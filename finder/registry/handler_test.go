@@ -0,0 +1,138 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func postTestNode(t *testing.T, serverURL string, n *Node) *Registration {
+	t.Helper()
+	body, err := json.Marshal(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.Post(serverURL+NodesPath, contentTypeJSON, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("%d != %d", res.StatusCode, http.StatusOK)
+	}
+	registration := &Registration{}
+	if err := json.NewDecoder(res.Body).Decode(registration); err != nil {
+		t.Fatal(err)
+	}
+	return registration
+}
+
+func getTestNodes(t *testing.T, url string) *NodeList {
+	t.Helper()
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("%d != %d", res.StatusCode, http.StatusOK)
+	}
+	list := &NodeList{}
+	if err := json.NewDecoder(res.Body).Decode(list); err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
+
+func TestHandler(t *testing.T) {
+	handler := NewHandler(NewRegistry())
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	n1 := newTestNode("org.cybergarage.registry001", "192.168.100.1")
+	registration := postTestNode(t, server.URL, n1)
+	if registration.ID != n1.ID() || registration.TTL != DefaultTTL.Seconds() {
+		t.Errorf("%v", registration)
+	}
+
+	list := getTestNodes(t, server.URL+NodesPath)
+	if list.Version != 1 || len(list.Nodes) != 1 || !list.Nodes[0].Equal(n1) {
+		t.Errorf("%d : %v", list.Version, list.Nodes)
+	}
+
+	// The long polling is held until the nodes are changed
+
+	n2 := newTestNode("org.cybergarage.registry002", "192.168.100.2")
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		handler.Registry().Register(n2)
+	}()
+	list = getTestNodes(t, server.URL+NodesPath+"?version=1&wait=5s")
+	if list.Version != 2 || len(list.Nodes) != 2 {
+		t.Errorf("%d : %v", list.Version, list.Nodes)
+	}
+
+	list = getTestNodes(t, server.URL+NodesPath+"?version=2&wait=10ms")
+	if list.Version != 2 {
+		t.Errorf("%d != %d", list.Version, 2)
+	}
+
+	// The nodes are deregistered with the identity
+
+	for _, expected := range []int{http.StatusNoContent, http.StatusNotFound} {
+		req, _ := http.NewRequest(http.MethodDelete, server.URL+NodesPath+"/"+registration.ID, nil)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		res.Body.Close()
+		if res.StatusCode != expected {
+			t.Errorf("%d != %d", res.StatusCode, expected)
+		}
+	}
+
+	// Invalid requests are rejected
+
+	invalidRequests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{http.MethodPost, NodesPath, "{", http.StatusBadRequest},
+		{http.MethodPost, NodesPath, "{}", http.StatusBadRequest},
+		{http.MethodGet, NodesPath + "?version=x&wait=1s", "", http.StatusBadRequest},
+		{http.MethodGet, NodesPath + "?version=1&wait=x", "", http.StatusBadRequest},
+		{http.MethodPut, NodesPath, "{}", http.StatusMethodNotAllowed},
+	}
+	for _, r := range invalidRequests {
+		req, _ := http.NewRequest(r.method, server.URL+r.path, bytes.NewReader([]byte(r.body)))
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		res.Body.Close()
+		if res.StatusCode != r.status {
+			t.Errorf("%s %s : %d != %d", r.method, r.path, res.StatusCode, r.status)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTTL is the default time-to-live of the registered nodes which are removed unless they are registered again.
+	DefaultTTL = 30 * time.Second
+)
+
+// NodeList represents a versioned list of the registered nodes.
+type NodeList struct {
+	// Version is increased whenever the registered nodes are changed.
+	Version uint64  `json:"version"`
+	Nodes   []*Node `json:"nodes"`
+}
+
+// registryEntry represents a registered node.
+type registryEntry struct {
+	node   *Node
+	expiry time.Time
+}
+
+// Registry represents a store of the registered nodes which expire unless they send heartbeats.
+type Registry struct {
+	mutex   sync.Mutex
+	ttl     time.Duration
+	entries map[string]*registryEntry
+	version uint64
+	changed chan struct{}
+}
+
+// NewRegistry returns a new empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		ttl:     DefaultTTL,
+		entries: map[string]*registryEntry{},
+		version: 0,
+		changed: make(chan struct{}),
+	}
+}
+
+// SetTTL sets the time-to-live of the registered nodes.
+func (registry *Registry) SetTTL(ttl time.Duration) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.ttl = ttl
+}
+
+// TTL returns the time-to-live of the registered nodes.
+func (registry *Registry) TTL() time.Duration {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	return registry.ttl
+}
+
+// notifyChange increases the version and wakes the waiters, and the caller must hold the mutex.
+func (registry *Registry) notifyChange() {
+	registry.version++
+	close(registry.changed)
+	registry.changed = make(chan struct{})
+}
+
+// sweep removes the expired nodes, and the caller must hold the mutex.
+func (registry *Registry) sweep(now time.Time) {
+	isChanged := false
+	for id, entry := range registry.entries {
+		if now.Before(entry.expiry) {
+			continue
+		}
+		delete(registry.entries, id)
+		isChanged = true
+	}
+	if isChanged {
+		registry.notifyChange()
+	}
+}
+
+// Register registers the specified node or refreshes the expiry of the registered node, and returns the node identity.
+// The version is increased only when the node is new or changed.
+func (registry *Registry) Register(n *Node) (string, error) {
+	if err := n.Validate(); err != nil {
+		return "", err
+	}
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	now := time.Now()
+	registry.sweep(now)
+
+	id := n.ID()
+	entry, ok := registry.entries[id]
+	if ok && entry.node.Equal(n) {
+		entry.expiry = now.Add(registry.ttl)
+		return id, nil
+	}
+	registry.entries[id] = &registryEntry{node: n.Copy(), expiry: now.Add(registry.ttl)}
+	registry.notifyChange()
+	return id, nil
+}
+
+// Deregister removes the node of the specified identity, and returns false when the node is not registered.
+func (registry *Registry) Deregister(id string) bool {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	if _, ok := registry.entries[id]; !ok {
+		return false
+	}
+	delete(registry.entries, id)
+	registry.notifyChange()
+	return true
+}
+
+// Nodes returns the registered nodes which are not expired in the identity order.
+func (registry *Registry) Nodes() *NodeList {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.sweep(time.Now())
+	return registry.nodeList()
+}
+
+// nodeList returns copies of the registered nodes, and the caller must hold the mutex.
+func (registry *Registry) nodeList() *NodeList {
+	ids := make([]string, 0, len(registry.entries))
+	for id := range registry.entries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	list := &NodeList{
+		Version: registry.version,
+		Nodes:   make([]*Node, 0, len(ids)),
+	}
+	for _, id := range ids {
+		list.Nodes = append(list.Nodes, registry.entries[id].node.Copy())
+	}
+	return list
+}
+
+// Wait waits until the version is different from the specified version or the context is done, and returns the registered nodes.
+// The nodes which expire while waiting are removed, so the waiters are woken by the expiry too.
+func (registry *Registry) Wait(ctx context.Context, version uint64) *NodeList {
+	for {
+		registry.mutex.Lock()
+		now := time.Now()
+		registry.sweep(now)
+		if registry.version != version {
+			list := registry.nodeList()
+			registry.mutex.Unlock()
+			return list
+		}
+		changed := registry.changed
+		nextExpiry := time.Duration(-1)
+		for _, entry := range registry.entries {
+			if d := entry.expiry.Sub(now); nextExpiry < 0 || d < nextExpiry {
+				nextExpiry = d
+			}
+		}
+		registry.mutex.Unlock()
+
+		var timer *time.Timer
+		var expired <-chan time.Time
+		if 0 <= nextExpiry {
+			timer = time.NewTimer(nextExpiry)
+			expired = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-expired:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return registry.Nodes()
+		}
+	}
+}
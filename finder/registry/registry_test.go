@@ -0,0 +1,145 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-finder/finder/node"
+)
+
+func newTestNode(host string, addr string) *Node {
+	return &Node{
+		Cluster:   "cluster",
+		Host:      host,
+		Address:   addr,
+		RPCPort:   8001,
+		Ports:     node.Ports{node.PortCarbon: 2003},
+		Labels:    node.Labels{"zone": "a"},
+		Condition: node.ConditionReady,
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+
+	n1 := newTestNode("org.cybergarage.registry001", "192.168.100.1")
+	id, err := registry.Register(n1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if id != n1.ID() {
+		t.Errorf("%s != %s", id, n1.ID())
+	}
+
+	// The heartbeats do not change the version
+
+	list := registry.Nodes()
+	if list.Version != 1 || len(list.Nodes) != 1 || !list.Nodes[0].Equal(n1) {
+		t.Errorf("%d : %v", list.Version, list.Nodes)
+	}
+	if _, err := registry.Register(n1); err != nil {
+		t.Error(err)
+	}
+	if version := registry.Nodes().Version; version != 1 {
+		t.Errorf("%d != %d", version, 1)
+	}
+
+	// The changed nodes change the version
+
+	n1 = n1.Copy()
+	n1.Condition = node.ConditionOutOfDate
+	if _, err := registry.Register(n1); err != nil {
+		t.Error(err)
+	}
+	list = registry.Nodes()
+	if list.Version != 2 || len(list.Nodes) != 1 || list.Nodes[0].Condition != node.ConditionOutOfDate {
+		t.Errorf("%d : %v", list.Version, list.Nodes)
+	}
+
+	if !registry.Deregister(id) {
+		t.Errorf("%s is not deregistered", id)
+	}
+	if registry.Deregister(id) {
+		t.Errorf("%s is deregistered twice", id)
+	}
+	if list := registry.Nodes(); list.Version != 3 || len(list.Nodes) != 0 {
+		t.Errorf("%d : %v", list.Version, list.Nodes)
+	}
+
+	// Invalid nodes are not registered
+
+	invalidNodes := []*Node{
+		{},
+		{Host: "org.cybergarage.registry001", Address: "192.168.100"},
+		{Host: "org.cybergarage.registry001", RPCPort: node.PortMax + 1},
+		{Host: "org.cybergarage.registry001", Ports: node.Ports{"-": 2003}},
+		{Host: "org.cybergarage.registry001", Labels: node.Labels{"zone": "a b"}},
+	}
+	for _, invalidNode := range invalidNodes {
+		if _, err := registry.Register(invalidNode); err == nil {
+			t.Errorf("%v is registered", invalidNode)
+		}
+	}
+}
+
+func TestRegistryExpiry(t *testing.T) {
+	registry := NewRegistry()
+	registry.SetTTL(100 * time.Millisecond)
+
+	if _, err := registry.Register(newTestNode("org.cybergarage.registry001", "192.168.100.1")); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// The waiters are woken when the node expires
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	list := registry.Wait(ctx, 1)
+	if ctx.Err() != nil {
+		t.Error(ctx.Err())
+	}
+	if list.Version != 2 || len(list.Nodes) != 0 {
+		t.Errorf("%d : %v", list.Version, list.Nodes)
+	}
+}
+
+func TestRegistryWait(t *testing.T) {
+	registry := NewRegistry()
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		registry.Register(newTestNode("org.cybergarage.registry001", "192.168.100.1"))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	list := registry.Wait(ctx, 0)
+	if list.Version != 1 || len(list.Nodes) != 1 {
+		t.Errorf("%d : %v", list.Version, list.Nodes)
+	}
+
+	// The wait returns the unchanged nodes when the context is done
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if list := registry.Wait(ctx, 1); list.Version != 1 {
+		t.Errorf("%d != %d", list.Version, 1)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+)
+
+// rendezvousScore returns the highest random weight of the specified key for the specified node identity key.
+func rendezvousScore(key string, nodeKey string) uint64 {
+	h := sha256.New()
+	h.Write([]byte(nodeKey))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	return binary.BigEndian.Uint64(h.Sum(nil)[:8])
+}
+
+// rendezvousNodes returns up to the specified number of nodes ordered by the highest random weight of the specified key.
+func rendezvousNodes(key string, nodes []*foundNode, count int) []Node {
+	type scoredNode struct {
+		*foundNode
+		score uint64
+	}
+
+	scoredNodes := make([]scoredNode, len(nodes))
+	for n, node := range nodes {
+		scoredNodes[n] = scoredNode{
+			foundNode: node,
+			score:     rendezvousScore(key, node.key),
+		}
+	}
+
+	sort.Slice(scoredNodes, func(i, j int) bool {
+		if scoredNodes[i].score != scoredNodes[j].score {
+			return scoredNodes[i].score > scoredNodes[j].score
+		}
+		return scoredNodes[i].key < scoredNodes[j].key
+	})
+
+	if len(scoredNodes) < count {
+		count = len(scoredNodes)
+	}
+
+	rankedNodes := make([]Node, count)
+	for n := 0; n < count; n++ {
+		rankedNodes[n] = scoredNodes[n].Node
+	}
+	return rankedNodes
+}
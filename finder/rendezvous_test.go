@@ -0,0 +1,202 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"fmt"
+	"testing"
+)
+
+const (
+	testRendezvousNodeCount = 10
+	testRendezvousKeyCount  = 10000
+)
+
+func getTestKeyOwners(t *testing.T, finder Finder) map[string]string {
+	t.Helper()
+	owners := map[string]string{}
+	for n := 0; n < testRendezvousKeyCount; n++ {
+		key := fmt.Sprintf("servers.host%04d.cpu.load", n)
+		node, err := finder.GetNodeForKey(key)
+		if err != nil {
+			t.Error(err)
+			return owners
+		}
+		owners[key] = node.UUID()
+	}
+	return owners
+}
+
+func TestGetNodeForKeyDistribution(t *testing.T) {
+	nodes := setupTestHashRingNodes(testRendezvousNodeCount)
+	finder := NewStaticFinderWithNodes(nodes)
+
+	owners := getTestKeyOwners(t, finder)
+
+	// Each node owns a fair share of the keys
+
+	counts := map[string]int{}
+	for _, owner := range owners {
+		counts[owner]++
+	}
+	expected := testRendezvousKeyCount / testRendezvousNodeCount
+	for _, node := range nodes {
+		count := counts[node.UUID()]
+		if count < (expected*7/10) || (expected*13/10) < count {
+			t.Errorf("%s owns %d keys (expected %d)", node.Host(), count, expected)
+		}
+	}
+
+	// Adding a node moves only the keys taken by the added node
+
+	addedNode := setupTestHashRingNodes(testRendezvousNodeCount + 1)[testRendezvousNodeCount]
+	err := finder.(testNodeMutator).addNode(addedNode)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	movedCount := 0
+	for key, owner := range getTestKeyOwners(t, finder) {
+		if owner == owners[key] {
+			continue
+		}
+		if owner != addedNode.UUID() {
+			t.Errorf("%s is moved to %s", key, owner)
+			return
+		}
+		movedCount++
+	}
+	movedRatio := float64(movedCount) / float64(testRendezvousKeyCount)
+	t.Logf("%.2f%% keys are moved by adding a node", movedRatio*100)
+	if movedRatio < 0.05 || 0.15 < movedRatio {
+		t.Errorf("%.2f%% keys are moved by adding a node", movedRatio*100)
+	}
+
+	// Removing a node moves only the keys owned by the removed node
+
+	err = finder.RemoveNode(addedNode)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	removedNode := nodes[0]
+	err = finder.RemoveNode(removedNode)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	for key, owner := range getTestKeyOwners(t, finder) {
+		if owner == owners[key] {
+			continue
+		}
+		if owners[key] != removedNode.UUID() {
+			t.Errorf("%s is moved from %s", key, owners[key])
+			return
+		}
+	}
+}
+
+func TestGetNodesForKey(t *testing.T) {
+	nodes := setupTestHashRingNodes(testRendezvousNodeCount)
+	finder := NewStaticFinderWithNodes(nodes)
+
+	key := "servers.host0001.cpu.load"
+
+	replicas, err := finder.GetNodesForKey(key, 3)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(replicas) != 3 {
+		t.Errorf(testFinderNodeCountError, len(replicas), 3)
+		return
+	}
+
+	owner, err := finder.GetNodeForKey(key)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if owner.UUID() != replicas[0].UUID() {
+		t.Errorf(testFinderMatchingError, owner.Host(), replicas[0].Host())
+	}
+
+	// The replicas of the removed owner are shifted
+
+	err = finder.RemoveNode(owner)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	shiftedReplicas, err := finder.GetNodesForKey(key, 2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for n, replica := range shiftedReplicas {
+		if replica.UUID() != replicas[n+1].UUID() {
+			t.Errorf(testFinderMatchingError, replicas[n+1].Host(), replica.Host())
+		}
+	}
+
+	// All nodes at most
+
+	replicas, err = finder.GetNodesForKey(key, testRendezvousNodeCount*2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(replicas) != (testRendezvousNodeCount - 1) {
+		t.Errorf(testFinderNodeCountError, len(replicas), testRendezvousNodeCount-1)
+	}
+
+	// Invalid count and no nodes
+
+	if _, err := finder.GetNodesForKey(key, 0); err == nil {
+		t.Errorf("Invalid replica count is accepted")
+	}
+
+	emptyFinder := NewStaticFinderWithNodes(nil)
+	if _, err := emptyFinder.GetNodeForKey(key); err == nil {
+		t.Errorf("Empty finder returns an owner node")
+	}
+}
+
+func TestGetNodesForKeyAddressOnly(t *testing.T) {
+	nodes := setupTestAddressOnlyNodes(2)
+	if nodes[0].UUID() != nodes[1].UUID() {
+		t.Skipf("%s and %s are resolved to the different hosts", nodes[0].Address(), nodes[1].Address())
+	}
+
+	// The nodes which have the same UUID but the different addresses own the keys separately
+
+	finder := NewStaticFinderWithNodes(nodes)
+	owners := map[string]int{}
+	for n := 0; n < testRendezvousKeyCount; n++ {
+		owner, err := finder.GetNodeForKey(fmt.Sprintf("servers.host%04d.cpu.load", n))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		owners[owner.Address().String()]++
+	}
+	if len(owners) != len(nodes) {
+		t.Errorf(testFinderNodeCountError, len(owners), len(nodes))
+	}
+}
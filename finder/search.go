@@ -0,0 +1,127 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cybergarage/go-finder/finder/node"
+)
+
+const (
+	// DefaultSearchWait is the default wait window for search responses.
+	DefaultSearchWait = time.Second
+)
+
+const (
+	errorSearchInvalidWait         = "Invalid search wait : %s"
+	errorSearchInvalidMaxResponses = "Invalid search max responses : %d"
+)
+
+// SearchOptions represents options for SearchContext.
+type SearchOptions struct {
+	// Wait is the wait window for search responses.
+	Wait time.Duration
+	// MaxResponses returns the search early when the specified number of nodes respond, and zero waits the full window.
+	MaxResponses int
+}
+
+// NewSearchOptions returns new default search options.
+func NewSearchOptions() *SearchOptions {
+	return &SearchOptions{
+		Wait:         DefaultSearchWait,
+		MaxResponses: 0,
+	}
+}
+
+// Validate returns an error when the options are invalid.
+func (opts *SearchOptions) Validate() error {
+	if opts.Wait < 0 {
+		return fmt.Errorf(errorSearchInvalidWait, opts.Wait)
+	}
+	if opts.MaxResponses < 0 {
+		return fmt.Errorf(errorSearchInvalidMaxResponses, opts.MaxResponses)
+	}
+	return nil
+}
+
+// searchCollector collects the responding nodes of a search.
+type searchCollector struct {
+	mutex        sync.Mutex
+	nodes        []Node
+	maxResponses int
+	done         chan struct{}
+	isDone       bool
+}
+
+// newSearchCollector returns a new search collector with the specified options.
+func newSearchCollector(opts *SearchOptions) *searchCollector {
+	return &searchCollector{
+		nodes:        make([]Node, 0),
+		maxResponses: opts.MaxResponses,
+		done:         make(chan struct{}),
+		isDone:       false,
+	}
+}
+
+// add adds the specified responding node unless the node is added already.
+func (collector *searchCollector) add(respNode Node) {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+
+	if collector.isDone {
+		return
+	}
+
+	for _, collectedNode := range collector.nodes {
+		if node.Equal(respNode, collectedNode) {
+			return
+		}
+	}
+	collector.nodes = append(collector.nodes, respNode)
+
+	if 0 < collector.maxResponses && collector.maxResponses <= len(collector.nodes) {
+		collector.isDone = true
+		close(collector.done)
+	}
+}
+
+// Nodes returns the collected nodes.
+func (collector *searchCollector) Nodes() []Node {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+	nodes := make([]Node, len(collector.nodes))
+	copy(nodes, collector.nodes)
+	return nodes
+}
+
+// wait waits until the wait window is elapsed, the max responses are collected or the specified context is done.
+// The collected nodes are returned with the context error when the context is done.
+func (collector *searchCollector) wait(ctx context.Context, opts *SearchOptions) ([]Node, error) {
+	timer := time.NewTimer(opts.Wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return collector.Nodes(), ctx.Err()
+	case <-collector.done:
+	case <-timer.C:
+	}
+
+	return collector.Nodes(), nil
+}
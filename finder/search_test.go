@@ -0,0 +1,146 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSearchCollector(t *testing.T) {
+	nodes := setupTestHashRingNodes(4)
+
+	// Returns early after the max responses
+
+	finder := newBaseFinder()
+	opts := NewSearchOptions()
+	opts.Wait = time.Minute
+	opts.MaxResponses = 2
+
+	collector := finder.startSearchCollector(opts)
+	go func() {
+		for _, node := range nodes {
+			finder.postSearchResponse(node)
+			finder.postSearchResponse(node)
+		}
+	}()
+
+	start := time.Now()
+	foundNodes, err := collector.wait(context.Background(), opts)
+	finder.stopSearchCollector(collector)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(foundNodes) != opts.MaxResponses {
+		t.Errorf(testFinderNodeCountError, len(foundNodes), opts.MaxResponses)
+	}
+	if opts.Wait <= time.Since(start) {
+		t.Errorf("Search is not returned early")
+	}
+
+	// Returns the responses in the wait window
+
+	opts = NewSearchOptions()
+	opts.Wait = 100 * time.Millisecond
+
+	collector = finder.startSearchCollector(opts)
+	for _, node := range nodes {
+		finder.postSearchResponse(node)
+	}
+	foundNodes, err = collector.wait(context.Background(), opts)
+	finder.stopSearchCollector(collector)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(foundNodes) != len(nodes) {
+		t.Errorf(testFinderNodeCountError, len(foundNodes), len(nodes))
+	}
+
+	// Returns the responses with the context error
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	opts = NewSearchOptions()
+	opts.Wait = time.Minute
+
+	collector = finder.startSearchCollector(opts)
+	finder.postSearchResponse(nodes[0])
+	foundNodes, err = collector.wait(ctx, opts)
+	finder.stopSearchCollector(collector)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("%v != %v", err, context.DeadlineExceeded)
+	}
+	if len(foundNodes) != 1 {
+		t.Errorf(testFinderNodeCountError, len(foundNodes), 1)
+	}
+
+	// Stopped collectors collect nothing
+
+	finder.postSearchResponse(nodes[1])
+	if len(collector.Nodes()) != 1 {
+		t.Errorf(testFinderNodeCountError, len(collector.Nodes()), 1)
+	}
+}
+
+func TestSearchOptions(t *testing.T) {
+	opts := NewSearchOptions()
+	if err := opts.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	opts.Wait = -time.Second
+	if err := opts.Validate(); err == nil {
+		t.Errorf("Negative wait is accepted")
+	}
+
+	opts = NewSearchOptions()
+	opts.MaxResponses = -1
+	if err := opts.Validate(); err == nil {
+		t.Errorf("Negative max responses are accepted")
+	}
+}
+
+func TestSearchContext(t *testing.T) {
+	nodes := setupTestHashRingNodes(4)
+
+	finder := NewStaticFinderWithNodes(nodes)
+	foundNodes, err := finder.SearchContext(context.Background(), NewSearchOptions())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(foundNodes) != len(nodes) {
+		t.Errorf(testFinderNodeCountError, len(foundNodes), len(nodes))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	finders := []Finder{
+		finder,
+		NewEchonetFinder(),
+	}
+	for _, finder := range finders {
+		_, err := finder.SearchContext(ctx, NewSearchOptions())
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s : %v != %v", finder, err, context.Canceled)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cybergarage/go-finder/finder/node"
+)
+
+const (
+	errorSelectorUnexpectedToken = "Invalid selector %q : unexpected %q at %d"
+	errorSelectorInvalidLabel    = "Invalid selector %q : %s"
+	errorSelectorInvalidInteger  = "Invalid selector %q : %q is not an integer"
+)
+
+// selectorOperator represents an operator of the selector requirements.
+type selectorOperator string
+
+const (
+	selectorEquals       selectorOperator = "="
+	selectorDoubleEquals selectorOperator = "=="
+	selectorNotEquals    selectorOperator = "!="
+	selectorIn           selectorOperator = "in"
+	selectorNotIn        selectorOperator = "notin"
+	selectorExists       selectorOperator = "exists"
+	selectorDoesNotExist selectorOperator = "!"
+	selectorGreaterThan  selectorOperator = ">"
+	selectorLessThan     selectorOperator = "<"
+)
+
+// selectorRequirement represents a requirement of the labels.
+type selectorRequirement struct {
+	key      string
+	operator selectorOperator
+	values   []string
+}
+
+// Selector represents a label selector with the Kubernetes style syntax such as "role=storage,zone!=a".
+// The requirements separated by commas are ANDed, and the following requirements are supported.
+//
+//	key=value, key==value : the label is the value
+//	key!=value            : the label is not the value or not set
+//	key in (v1,v2)        : the label is one of the values
+//	key notin (v1,v2)     : the label is none of the values or not set
+//	key, !key             : the label is set, or not set
+//	key>n, key<n          : the label is an integer greater or less than n
+type Selector struct {
+	expr         string
+	requirements []selectorRequirement
+}
+
+// NewSelectorWithString returns a new selector of the specified string, and the empty string matches all labels.
+func NewSelectorWithString(expr string) (*Selector, error) {
+	parser := &selectorParser{expr: expr, pos: 0}
+	requirements, err := parser.parse()
+	if err != nil {
+		return nil, err
+	}
+	return &Selector{expr: expr, requirements: requirements}, nil
+}
+
+// Matches returns true when the specified labels satisfy all requirements of the selector, otherwise false.
+func (selector *Selector) Matches(labels node.Labels) bool {
+	for _, req := range selector.requirements {
+		if !req.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchNode returns true when the labels of the specified node satisfy the selector, otherwise false.
+func (selector *Selector) MatchNode(node Node) bool {
+	return selector.Matches(node.Labels())
+}
+
+// String returns the selector string.
+func (selector *Selector) String() string {
+	return selector.expr
+}
+
+// matches returns true when the specified labels satisfy the requirement, otherwise false.
+func (req *selectorRequirement) matches(labels node.Labels) bool {
+	value, ok := labels.Get(req.key)
+	switch req.operator {
+	case selectorEquals, selectorDoubleEquals, selectorIn:
+		return ok && req.hasValue(value)
+	case selectorNotEquals, selectorNotIn:
+		return !ok || !req.hasValue(value)
+	case selectorExists:
+		return ok
+	case selectorDoesNotExist:
+		return !ok
+	case selectorGreaterThan, selectorLessThan:
+		if !ok {
+			return false
+		}
+		labelValue, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false
+		}
+		reqValue, _ := strconv.ParseInt(req.values[0], 10, 64)
+		if req.operator == selectorGreaterThan {
+			return reqValue < labelValue
+		}
+		return labelValue < reqValue
+	}
+	return false
+}
+
+// hasValue returns true when the requirement has the specified value, otherwise false.
+func (req *selectorRequirement) hasValue(value string) bool {
+	for _, reqValue := range req.values {
+		if reqValue == value {
+			return true
+		}
+	}
+	return false
+}
+
+// selectorParser represents a parser of the selector strings.
+type selectorParser struct {
+	expr string
+	pos  int
+}
+
+// parse parses all requirements of the selector string.
+func (parser *selectorParser) parse() ([]selectorRequirement, error) {
+	requirements := []selectorRequirement{}
+	if parser.peek() == "" {
+		return requirements, nil
+	}
+	for {
+		req, err := parser.parseRequirement()
+		if err != nil {
+			return nil, err
+		}
+		requirements = append(requirements, req)
+		switch token, pos := parser.next(); token {
+		case "":
+			return requirements, nil
+		case ",":
+			continue
+		default:
+			return nil, fmt.Errorf(errorSelectorUnexpectedToken, parser.expr, token, pos)
+		}
+	}
+}
+
+// parseRequirement parses a requirement of the selector string.
+func (parser *selectorParser) parseRequirement() (selectorRequirement, error) {
+	req := selectorRequirement{values: []string{}}
+
+	token, pos := parser.next()
+	if token == string(selectorDoesNotExist) {
+		req.operator = selectorDoesNotExist
+		token, pos = parser.next()
+	}
+	if !node.IsValidLabelKey(token) {
+		return req, parser.unexpectedToken(token, pos)
+	}
+	req.key = token
+	if req.operator == selectorDoesNotExist {
+		return req, nil
+	}
+
+	switch token, pos := parser.peekToken(); selectorOperator(token) {
+	case "", ",":
+		req.operator = selectorExists
+		return req, nil
+	case selectorEquals, selectorDoubleEquals, selectorNotEquals:
+		parser.next()
+		req.operator = selectorOperator(token)
+		value := ""
+		if next := parser.peek(); next != "," && next != "" {
+			value, pos = parser.next()
+		}
+		if !node.IsValidLabelValue(value) {
+			return req, parser.unexpectedToken(value, pos)
+		}
+		req.values = append(req.values, value)
+	case selectorIn, selectorNotIn:
+		parser.next()
+		req.operator = selectorOperator(token)
+		values, err := parser.parseValues()
+		if err != nil {
+			return req, err
+		}
+		req.values = values
+	case selectorGreaterThan, selectorLessThan:
+		parser.next()
+		req.operator = selectorOperator(token)
+		value, _ := parser.next()
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return req, fmt.Errorf(errorSelectorInvalidInteger, parser.expr, value)
+		}
+		req.values = append(req.values, value)
+	default:
+		return req, parser.unexpectedToken(token, pos)
+	}
+
+	return req, nil
+}
+
+// parseValues parses a parenthesized value list such as (v1,v2).
+func (parser *selectorParser) parseValues() ([]string, error) {
+	if token, pos := parser.next(); token != "(" {
+		return nil, parser.unexpectedToken(token, pos)
+	}
+	values := []string{}
+	for {
+		value := ""
+		token, pos := parser.next()
+		if token != "," && token != ")" {
+			value = token
+			token, pos = parser.next()
+		}
+		if !node.IsValidLabelValue(value) {
+			return nil, fmt.Errorf(errorSelectorInvalidLabel, parser.expr, value)
+		}
+		values = append(values, value)
+		switch token {
+		case ",":
+			continue
+		case ")":
+			return values, nil
+		default:
+			return nil, parser.unexpectedToken(token, pos)
+		}
+	}
+}
+
+// unexpectedToken returns an error of the specified unexpected token.
+func (parser *selectorParser) unexpectedToken(token string, pos int) error {
+	return fmt.Errorf(errorSelectorUnexpectedToken, parser.expr, token, pos)
+}
+
+// peek returns the next token without consuming it.
+func (parser *selectorParser) peek() string {
+	token, _ := parser.peekToken()
+	return token
+}
+
+// peekToken returns the next token and the position without consuming it.
+func (parser *selectorParser) peekToken() (string, int) {
+	pos := parser.pos
+	token, tokenPos := parser.next()
+	parser.pos = pos
+	return token, tokenPos
+}
+
+// next returns the next token and the position, and returns an empty token at the end of the string.
+// The tokens are identifiers, operators (=, ==, !=, !, <, >), commas and parentheses.
+func (parser *selectorParser) next() (string, int) {
+	for parser.pos < len(parser.expr) && parser.expr[parser.pos] == ' ' {
+		parser.pos++
+	}
+	start := parser.pos
+	if len(parser.expr) <= start {
+		return "", start
+	}
+
+	switch c := parser.expr[start]; c {
+	case ',', '(', ')', '<', '>':
+		parser.pos++
+	case '=', '!':
+		parser.pos++
+		if parser.pos < len(parser.expr) && parser.expr[parser.pos] == '=' {
+			parser.pos++
+		}
+	default:
+		for parser.pos < len(parser.expr) && !strings.ContainsRune(" ,()<>=!", rune(parser.expr[parser.pos])) {
+			parser.pos++
+		}
+	}
+
+	return parser.expr[start:parser.pos], start
+}
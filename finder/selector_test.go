@@ -0,0 +1,141 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"testing"
+
+	"github.com/cybergarage/go-finder/finder/node"
+)
+
+func TestSelector(t *testing.T) {
+	labels := node.Labels{
+		"zone":    "a",
+		"role":    "storage",
+		"rack":    "r1",
+		"version": "12",
+		"empty":   "",
+	}
+
+	testCases := []struct {
+		selector string
+		match    bool
+	}{
+		{"", true},
+		{"role=storage", true},
+		{"role==storage", true},
+		{"role=compute", false},
+		{"role!=compute", true},
+		{"role!=storage", false},
+		{"owner!=someone", true},
+		{"role=storage,zone!=a", false},
+		{"role=storage,zone!=b", true},
+		{"role = storage , zone != b", true},
+		{"zone in (a,b)", true},
+		{"zone in (b,c)", false},
+		{"zone in (b)", false},
+		{"zone notin (b,c)", true},
+		{"zone notin (a)", false},
+		{"owner notin (a)", true},
+		{"owner in (a)", false},
+		{"rack", true},
+		{"owner", false},
+		{"!owner", true},
+		{"!rack", false},
+		{"empty=", true},
+		{"empty", true},
+		{"empty in (,x)", true},
+		{"version>10", true},
+		{"version>12", false},
+		{"version<13", true},
+		{"version<-1", false},
+		{"zone>1", false},
+		{"owner<1", false},
+		{"role=storage,rack in (r1,r2),!owner,version>3", true},
+		{"role=storage,rack in (r2,r3),!owner", false},
+	}
+
+	for _, testCase := range testCases {
+		selector, err := NewSelectorWithString(testCase.selector)
+		if err != nil {
+			t.Errorf("%s : %s", testCase.selector, err)
+			continue
+		}
+		if selector.Matches(labels) != testCase.match {
+			t.Errorf(testFinderMatchingError, testCase.selector, labels)
+		}
+	}
+}
+
+func TestSelectorErrors(t *testing.T) {
+	selectors := []string{
+		",",
+		"role=storage,",
+		"role=a=b",
+		"=storage",
+		"role storage",
+		"role in a",
+		"role in (a",
+		"role in (a b)",
+		"role notin",
+		"!",
+		"!role=storage",
+		"role>a",
+		"role<",
+		"-role=a",
+		"role=-a",
+		"role=(a)",
+	}
+
+	for _, selector := range selectors {
+		if _, err := NewSelectorWithString(selector); err == nil {
+			t.Errorf("%s : parsed", selector)
+		}
+	}
+}
+
+func TestFinderSelectorNodes(t *testing.T) {
+	finder, err := NewStaticFinderWithTOML(finderConfigNodesTestFilename)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	testCases := []struct {
+		selector string
+		count    int
+	}{
+		{"", 3},
+		{"role=storage", 1},
+		{"role=storage,zone!=a", 0},
+		{"zone notin (a)", 2},
+		{"!role", 2},
+	}
+
+	for _, testCase := range testCases {
+		nodes, err := finder.GetSelectorNodes(testCase.selector)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if len(nodes) != testCase.count {
+			t.Errorf(testFinderMatchingCountError, testCase.selector, len(nodes), testCase.count)
+		}
+	}
+
+	if _, err := finder.GetSelectorNodes("role in"); err == nil {
+		t.Errorf("%s : parsed", "role in")
+	}
+}
@@ -3,6 +3,8 @@ module github.com/cybergarage/go-finder
 go 1.25
 
 require (
-	github.com/cybergarage/go-logger v1.3.4 // indirect
-	github.com/cybergarage/uecho-go v1.1.0 // indirect
+	github.com/BurntSushi/toml v1.6.0
+	github.com/cybergarage/go-logger v1.3.4
+	github.com/cybergarage/uecho-go v1.1.0
+	gopkg.in/yaml.v3 v3.0.1
 )